@@ -6,6 +6,7 @@ import { ChatBox } from "./components/ChatBox";
 import { DirectMessageBox } from "./components/DirectMessageBox";
 import { type UserInProject } from "./components/ProjectUsers";
 import { useWebSockets } from "./hooks/useWebSockets";
+import { clearSession } from "./session";
 import "./App.css";
 
 type Project = {
@@ -13,7 +14,7 @@ type Project = {
   name: string;
   description: string;
   owner_id: number;
-  owner_name?: string;
+  owner?: { id: number; display_name: string; avatar_url?: string };
 };
 
 function App() {
@@ -22,7 +23,7 @@ function App() {
   const [showProjects, setShowProjects] = useState(false);
   const [isModalOpen, setIsModalOpen] = useState(false);
 
-  // projectUsersMap holds the ACTUAL DATA (emails/ids)
+  // projectUsersMap holds the ACTUAL DATA (profiles/ids)
   const [projectUsersMap, setProjectUsersMap] = useState<Record<number, UserInProject[]>>({});
 
   // UI state for accordions
@@ -215,8 +216,7 @@ function App() {
                 Create Project
               </button>
               <button onClick={() => {
-                localStorage.removeItem("token");
-                localStorage.removeItem("userId");
+                clearSession();
                 window.location.reload();
               }}>
                 Logout
@@ -4,7 +4,7 @@ import { useChat } from '../hooks/useChat';
 interface Message {
     id: number;
     sender_id: number;
-    sender_email?: string;
+    sender?: { id: number; display_name: string; avatar_url?: string };
     content: string;
     created_at?: string;
 }
@@ -110,7 +110,7 @@ export const ChatBox: React.FC<ChatBoxProps> = ({ projectId, token }) => {
                                     ...styles.sender,
                                     color: isMe ? '#e0e0e0' : '#888'
                                 }}>
-                                    {isMe ? "Me" : m.sender_email || `User ${m.sender_id}`}
+                                    {isMe ? "Me" : m.sender?.display_name || `User ${m.sender_id}`}
                                 </small>
                                 {time && (
                                     <small style={{ fontSize: '0.6rem', color: isMe ? '#ccc' : '#999' }}>
@@ -1,4 +1,5 @@
 import React, { useState } from "react";
+import { saveSession } from "../session";
 
 const LoginForm = ({ message, setMessage }: any) => {
     const [email, setEmail] = useState("");
@@ -16,9 +17,7 @@ const LoginForm = ({ message, setMessage }: any) => {
             const data = await res.json();
 
             if (res.ok) {
-                // Simple, direct storage
-                localStorage.setItem("token", data.token);
-                localStorage.setItem("userId", data.userId);
+                saveSession(data);
                 window.location.reload();
             } else {
                 setMessage(data.error || "Login failed");
@@ -9,7 +9,7 @@ interface Project {
     name: string;
     description: string;
     owner_id: number;
-    owner_name?: string;
+    owner?: { id: number; display_name: string; avatar_url?: string };
 }
 
 interface ProjectListProps {
@@ -124,7 +124,7 @@ const ProjectList: React.FC<ProjectListProps> = ({
                                         <h2>{project.name}</h2>
                                     )}
                                     <p className="project-owner">
-                                        Owner: <span>{project.owner_name || `User #${project.owner_id}`}</span>
+                                        Owner: <span>{project.owner?.display_name || `User #${project.owner_id}`}</span>
                                     </p>
                                 </div>
 
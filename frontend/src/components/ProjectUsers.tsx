@@ -2,7 +2,7 @@ import React from 'react';
 
 export interface UserInProject {
     id: number;
-    email: string;
+    profile: { id: number; display_name: string; avatar_url?: string };
     role: string;
 }
 
@@ -10,7 +10,7 @@ interface ProjectUsersProps {
     users: UserInProject[];
     // The '?' makes it optional, fixing the ts(2322) error
     onRemove?: (userId: number) => void;
-    onMessage?: (userId: number, userEmail: string) => void;
+    onMessage?: (userId: number, displayName: string) => void;
 }
 
 const ProjectUsers: React.FC<ProjectUsersProps> = ({ users, onRemove, onMessage }) => {
@@ -19,7 +19,7 @@ const ProjectUsers: React.FC<ProjectUsersProps> = ({ users, onRemove, onMessage
             {users.map((user) => (
                 <div key={user.id} className="member-item">
                     <div className="member-info">
-                        <span className="member-email">{user.email}</span>
+                        <span className="member-email">{user.profile.display_name}</span>
                         <span className="member-role" style={{ marginLeft: '5px', opacity: 0.7 }}>({user.role})</span>
                     </div>
 
@@ -28,7 +28,7 @@ const ProjectUsers: React.FC<ProjectUsersProps> = ({ users, onRemove, onMessage
                         {onMessage && (
                             <button
                                 className="btn-primary-sm"
-                                onClick={() => onMessage(user.id, user.email)}
+                                onClick={() => onMessage(user.id, user.profile.display_name)}
                             >
                                 Message
                             </button>
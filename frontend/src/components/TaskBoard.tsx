@@ -11,14 +11,14 @@ interface Task {
 
 interface User {
     id: number;
-    email: string;
+    profile: { id: number; display_name: string; avatar_url?: string };
 }
 
 interface TaskActivity {
     id: number;
     task_id: number;
     user_id: number;
-    user_email: string;
+    user: { id: number; display_name: string; avatar_url?: string };
     action: string;
     details: string;
     created_at: string;
@@ -70,12 +70,12 @@ const TaskBoard: React.FC<TaskBoardProps> = ({ projectId, refreshTick, isOwner,
         }
     };
 
-    // HELPER: Replaces "user 5" with the actual email from the members list
+    // HELPER: Replaces "user 5" with the display name from the members list
     const formatActivityDetails = (details: string) => {
         if (!details) return "";
         return details.replace(/user (\d+)/g, (match, id) => {
             const member = members.find(m => m.id === parseInt(id));
-            return member ? member.email : `User ${id}`;
+            return member ? member.profile.display_name : `User ${id}`;
         });
     };
 
@@ -160,7 +160,7 @@ const TaskBoard: React.FC<TaskBoardProps> = ({ projectId, refreshTick, isOwner,
                 </div>
                 <p style={{ fontSize: '0.8rem', color: '#555', marginBottom: '8px' }}>{task.description}</p>
                 <div style={{ fontSize: '0.7rem', color: '#888' }}>
-                    👤 {members.find(m => m.id === task.assigned_to)?.email || "Unassigned"}
+                    👤 {members.find(m => m.id === task.assigned_to)?.profile.display_name || "Unassigned"}
                 </div>
 
                 {updatingTaskId === task.id ? (
@@ -191,7 +191,7 @@ const TaskBoard: React.FC<TaskBoardProps> = ({ projectId, refreshTick, isOwner,
                                     style={{ width: '100%', marginBottom: '10px' }}
                                 >
                                     <option value="">Unassigned</option>
-                                    {members.map(m => <option key={m.id} value={m.id}>{m.email}</option>)}
+                                    {members.map(m => <option key={m.id} value={m.id}>{m.profile.display_name}</option>)}
                                 </select>
                             </>
                         )}
@@ -227,7 +227,7 @@ const TaskBoard: React.FC<TaskBoardProps> = ({ projectId, refreshTick, isOwner,
                             <textarea placeholder="Description" value={newTaskForm.description} onChange={e => setNewTaskForm({ ...newTaskForm, description: e.target.value })} style={{ width: '100%', marginBottom: '10px' }} />
                             <select value={newTaskForm.assigned_to} onChange={e => setNewTaskForm({ ...newTaskForm, assigned_to: e.target.value })} style={{ width: '100%', marginBottom: '10px' }}>
                                 <option value="">Assign to...</option>
-                                {members.map(m => <option key={m.id} value={m.id}>{m.email}</option>)}
+                                {members.map(m => <option key={m.id} value={m.id}>{m.profile.display_name}</option>)}
                             </select>
                             <div style={{ display: 'flex', gap: '10px' }}>
                                 <button type="submit" style={{ backgroundColor: '#52c41a', color: 'white', border: 'none', padding: '6px 12px', borderRadius: '4px' }}>Create</button>
@@ -262,7 +262,7 @@ const TaskBoard: React.FC<TaskBoardProps> = ({ projectId, refreshTick, isOwner,
                             history.map((act) => (
                                 <div key={act.id} style={{ fontSize: '0.85rem', padding: '10px 0', borderBottom: '1px solid #eee' }}>
                                     <div style={{ marginBottom: '4px' }}>
-                                        <strong style={{ color: '#1890ff' }}>{act.user_email || "System"}</strong>: {formatActivityDetails(act.details)}
+                                        <strong style={{ color: '#1890ff' }}>{act.user?.display_name || "System"}</strong>: {formatActivityDetails(act.details)}
                                     </div>
                                     <div style={{ color: '#999', fontSize: '0.7rem' }}>
                                         {act.created_at ? new Date(act.created_at).toLocaleString() : "Invalid Date"}
@@ -7,7 +7,7 @@ export interface Message {
     receiver_id?: number;
     content: string;
     created_at: string;
-    sender_email?: string;
+    sender?: { id: number; display_name: string; avatar_url?: string };
 }
 
 interface ChatResponse {
@@ -6,7 +6,7 @@ export interface DirectMessage {
     receiver_id?: number;
     content: string;
     created_at: string;
-    sender_email?: string;
+    sender?: { id: number; display_name: string; avatar_url?: string };
 }
 
 interface DirectChatResponse {
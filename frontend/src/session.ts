@@ -0,0 +1,82 @@
+const API_URL = "http://localhost:880";
+
+type SessionTokens = {
+    token: string;
+    refresh_token: string;
+    userId?: number;
+};
+
+// saveSession keeps what /login, /login/2fa and /auth/refresh hand out
+export function saveSession(data: SessionTokens) {
+    localStorage.setItem("token", data.token);
+    localStorage.setItem("refreshToken", data.refresh_token);
+    if (data.userId !== undefined) {
+        localStorage.setItem("userId", String(data.userId));
+    }
+}
+
+export function clearSession() {
+    localStorage.removeItem("token");
+    localStorage.removeItem("refreshToken");
+    localStorage.removeItem("userId");
+}
+
+// one refresh at a time, the refresh token is single use
+let refreshing: Promise<string | null> | null = null;
+
+function refreshAccessToken(originalFetch: typeof fetch): Promise<string | null> {
+    if (!refreshing) {
+        refreshing = (async () => {
+            const refreshToken = localStorage.getItem("refreshToken");
+            if (!refreshToken) return null;
+            try {
+                const res = await originalFetch(`${API_URL}/auth/refresh`, {
+                    method: "POST",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify({ refresh_token: refreshToken }),
+                });
+                if (!res.ok) return null;
+                const data = await res.json();
+                saveSession(data);
+                return data.token as string;
+            } catch {
+                return null;
+            }
+        })().finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+}
+
+// installSessionRefresh makes every API call survive the short access token
+// lifetime: a 401 swaps the refresh token for a new pair and retries once.
+// Components still read the token from localStorage, so the header is
+// always brought up to date before a request goes out.
+export function installSessionRefresh() {
+    const originalFetch = window.fetch.bind(window);
+
+    window.fetch = async (input: RequestInfo | URL, init?: RequestInit) => {
+        const url = typeof input === "string" ? input : input instanceof URL ? input.href : input.url;
+        const headers = new Headers(init?.headers);
+        if (!url.startsWith(API_URL) || !headers.has("Authorization")) {
+            return originalFetch(input, init);
+        }
+
+        const withToken = (token: string | null) => {
+            if (token) headers.set("Authorization", `Bearer ${token}`);
+            return { ...init, headers };
+        };
+
+        const res = await originalFetch(input, withToken(localStorage.getItem("token")));
+        if (res.status !== 401) return res;
+
+        const token = await refreshAccessToken(originalFetch);
+        if (!token) {
+            clearSession();
+            window.location.reload();
+            return res;
+        }
+        return originalFetch(input, withToken(token));
+    };
+}
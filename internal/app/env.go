@@ -0,0 +1,47 @@
+package app
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// envString reads a plain string, empty counts as unset
+func envString(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envDuration reads a duration like "15m" or "720h" from the environment,
+// anything missing or unparsable falls back to the default
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// envInt reads a whole number, same fallback rules as envDuration
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// envBool accepts anything strconv.ParseBool does ("true", "1"...)
+func envBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
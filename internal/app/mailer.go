@@ -0,0 +1,33 @@
+package app
+
+import (
+	"log"
+	"os"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+)
+
+// newMailer picks the mail driver, MAIL_DRIVER=smtp sends real mail,
+// anything else writes mails to MAIL_LOG_FILE (or stdout) for local dev
+func newMailer(logger *log.Logger) mail.Mailer {
+	if envString("MAIL_DRIVER", "log") == "smtp" {
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     envString("SMTP_HOST", "localhost"),
+			Port:     envString("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     envString("MAIL_FROM", "no-reply@playingfield.local"),
+		})
+	}
+
+	path := os.Getenv("MAIL_LOG_FILE")
+	if path == "" {
+		return mail.NewLogMailer(os.Stdout)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Fatal("failed to open mail log file:", err)
+	}
+	return mail.NewLogMailer(f)
+}
@@ -2,26 +2,37 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	stdhttp "net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
 	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/domain/privacy"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 	"github.com/nelfander/Playingfield/internal/domain/projects"
 	"github.com/nelfander/Playingfield/internal/domain/tasks"
+	"github.com/nelfander/Playingfield/internal/domain/teams"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
 	"github.com/nelfander/Playingfield/internal/domain/user"
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/oidc"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
 	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
 	"github.com/nelfander/Playingfield/internal/interfaces/http"
 	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
-	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
 	"github.com/nelfander/Playingfield/pkg/config"
 )
 
@@ -49,29 +60,258 @@ func Run() {
 	// --- SQLC wrapper ---
 	queries := sqlc.New(db)
 
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.JWTExpiry)
+	// access tokens are short-lived, sessions are kept alive by refresh tokens
+	jwtManager := auth.NewJWTManager(cfg.JWTSecret, envDuration("ACCESS_TOKEN_TTL", 15*time.Minute))
+
+	// --- Signing keys: EdDSA, rotated on a schedule, published as JWKS ---
+	keySet := auth.NewKeySet(postgres.NewSigningKeyRepository(db), envDuration("JWT_KEY_ROTATION", 30*24*time.Hour), jwtManager.TokenDuration())
+	if err := keySet.Rotate(context.Background()); err != nil {
+		logger.Fatal("failed to load signing keys:", err)
+	}
+	jwtManager.UseKeySet(keySet)
+	go keySet.Run(auth.KeyReloadInterval)
+	jwksHandler := handlers.NewJWKSHandler(jwtManager)
+
+	// --- Access token denylist (db backed, cached in memory) ---
+	denylist := auth.NewDenylist(postgres.NewDenylistRepository(db))
+	if err := denylist.Load(context.Background()); err != nil {
+		logger.Fatal("failed to load token denylist:", err)
+	}
+	jwtManager.UseDenylist(denylist)
+	go denylist.Run(30 * time.Second)
 
 	//  Initialize the Hub
 	hub := ws.NewHub()
 
+	// --- Refresh tokens repo + service ---
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	tokenService := tokens.NewService(refreshTokenRepo, envDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour))
+
+	mailer := newMailer(logger)
+
+	auditService := audit.NewService(postgres.NewAuditRepository(db))
+
+	// --- Passwords: argon2id cost and the policy new passwords must meet ---
+	auth.SetArgon2Params(auth.Argon2Params{
+		Memory:      uint32(envInt("ARGON2_MEMORY_KB", int(auth.DefaultArgon2Params.Memory))),
+		Iterations:  uint32(envInt("ARGON2_ITERATIONS", int(auth.DefaultArgon2Params.Iterations))),
+		Parallelism: uint8(envInt("ARGON2_PARALLELISM", int(auth.DefaultArgon2Params.Parallelism))),
+		SaltLength:  auth.DefaultArgon2Params.SaltLength,
+		KeyLength:   auth.DefaultArgon2Params.KeyLength,
+	})
+	passwordPolicy := user.NewPasswordPolicy(envInt("PASSWORD_MIN_LENGTH", 8))
+	if path := envString("PASSWORD_BREACHED_LIST", ""); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			logger.Fatal("failed to open breached password list:", err)
+		}
+		err = passwordPolicy.AddBreached(f)
+		f.Close()
+		if err != nil {
+			logger.Fatal("failed to read breached password list:", err)
+		}
+	}
+
 	// --- user repo + service + handler ---
 	userRepo := postgres.NewUserRepository(db, queries)
 	userService := user.NewService(userRepo)
-	userHandler := handlers.NewUserHandler(userService, jwtManager)
+
+	// role/status are re-checked on every request, cached for a few seconds
+	jwtManager.UseAccountCache(auth.NewAccountCache(func(ctx context.Context, userID int64) (string, string, error) {
+		u, err := userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return "", "", err
+		}
+		return u.Role, u.Status, nil
+	}, envDuration("ACCOUNT_CACHE_TTL", 10*time.Second)))
+
+	// --- Personal access tokens ---
+	patService := pats.NewService(postgres.NewPATRepository(db))
+	jwtManager.UsePersonalAccessTokens(func(ctx context.Context, raw string) (*auth.Claims, error) {
+		t, err := patService.Authenticate(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		u, err := userRepo.GetByID(ctx, t.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Claims{
+			UserID: u.ID,
+			Email:  u.Email,
+			Role:   u.Role,
+			Status: u.Status,
+			Scopes: t.Scopes,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID: fmt.Sprintf("pat:%d", t.ID),
+			},
+		}, nil
+	})
+	patHandler := handlers.NewPATHandler(patService)
+
+	// --- Profiles ---
+	profileService := profiles.NewService(postgres.NewProfileRepository(db))
+	profileHandler := handlers.NewProfileHandler(profileService)
+
+	verificationService := user.NewVerificationService(
+		userRepo,
+		mailer,
+		jwtManager,
+		envDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		envString("EMAIL_VERIFICATION_URL", "http://localhost:880/verify-email?token="),
+	)
+	twoFactorService := user.NewTwoFactorService(
+		userRepo,
+		postgres.NewTwoFactorRepository(db),
+		jwtManager,
+		envString("TOTP_ISSUER", "Playingfield"),
+		envDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+	)
+	userHandler := handlers.NewUserHandler(userService, jwtManager, tokenService, verificationService, twoFactorService)
+	sessionHandler := handlers.NewSessionHandler(tokenService, jwtManager, hub)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	// --- Login brute-force protection ---
+	loginThrottle := auth.NewLoginThrottle(auth.ThrottleConfig{
+		PerEmail:    envInt("LOGIN_MAX_FAILURES_PER_EMAIL", 5),
+		PerIP:       envInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		BaseLockout: envDuration("LOGIN_LOCKOUT_BASE", 30*time.Second),
+		MaxLockout:  envDuration("LOGIN_LOCKOUT_MAX", 15*time.Minute),
+		Window:      envDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+	}, func(ev auth.ThrottleEvent) {
+		action, details := "security.login_unlocked", fmt.Sprintf("%s after %d failures", ev.Key, ev.Failures)
+		if ev.Locked {
+			action = "security.login_locked"
+			details = fmt.Sprintf("%s locked until %s after %d failures", ev.Key, ev.Until.Format(time.RFC3339), ev.Failures)
+		}
+		logger.Println(action + ": " + details)
+		if err := auditService.Record(context.Background(), 0, action, "login", 0, details); err != nil {
+			logger.Printf("failed to write security audit log: %v", err)
+		}
+	})
+	userHandler.UseLoginThrottle(loginThrottle)
+	userHandler.UsePasswordPolicy(passwordPolicy)
+	userHandler.UsePersonalAccessTokens(patService)
+
+	// --- OpenID Connect sign-in, only when an issuer is configured ---
+	oidcEnabled := envString("OIDC_ISSUER_URL", "") != ""
+	if oidcEnabled {
+		provider, err := oidc.Discover(context.Background(), oidc.Config{
+			IssuerURL:    envString("OIDC_ISSUER_URL", ""),
+			ClientID:     envString("OIDC_CLIENT_ID", ""),
+			ClientSecret: envString("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  envString("OIDC_REDIRECT_URL", "http://localhost:880/auth/oidc/callback"),
+			Scopes:       strings.Fields(envString("OIDC_SCOPES", "openid email profile")),
+		}, nil)
+		if err != nil {
+			logger.Fatal("failed to discover oidc issuer:", err)
+		}
+		roleMap, err := user.ParseRoleMap(envString("OIDC_ROLE_MAP", ""))
+		if err != nil {
+			logger.Fatal("invalid OIDC_ROLE_MAP:", err)
+		}
+		userHandler.UseOIDC(user.NewOIDCService(
+			userRepo,
+			postgres.NewIdentityRepository(db),
+			provider,
+			jwtManager,
+			auditService,
+			user.RoleMapping{
+				Claim:   envString("OIDC_ROLE_CLAIM", ""),
+				Values:  roleMap,
+				Default: envString("OIDC_DEFAULT_ROLE", user.RoleUser),
+			},
+			envDuration("OIDC_STATE_TTL", 10*time.Minute),
+		))
+	}
+	go loginThrottle.Run(time.Minute)
+
+	// --- Password reset ---
+	passwordResetService := user.NewPasswordResetService(
+		userRepo,
+		postgres.NewPasswordResetRepository(db),
+		mailer,
+		tokenService,
+		jwtManager,
+		envDuration("PASSWORD_RESET_TTL", time.Hour),
+		envString("PASSWORD_RESET_URL", "http://localhost:5173/reset-password?token="),
+	)
+	passwordResetService.UsePersonalAccessTokens(patService)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService)
+	passwordHandler.UsePasswordPolicy(passwordPolicy)
+
+	// --- Organizations own projects and decide who sees whom ---
+	orgService := organizations.NewService(postgres.NewOrganizationRepository(db), userRepo)
+	orgService.UseHub(hub)
+	orgService.UseInvitations(
+		mailer,
+		jwtManager,
+		envDuration("INVITATION_TTL", 7*24*time.Hour),
+		envString("ORGANIZATION_INVITATION_URL", "http://localhost:5173/organizations/invitations/accept?token="),
+	)
+	organizationHandler := handlers.NewOrganizationHandler(orgService)
+	profileHandler.UseOrganizations(orgService)
 
 	// Projects repo + service + handler
 	projectsRepo := postgres.NewProjectRepository(db)
+	// one policy for every service, so they share its membership cache
+	projectPolicy := projects.NewCachedAuthorizer(projectsRepo, envDuration("MEMBERSHIP_CACHE_TTL", 10*time.Second))
 	projectsService := projects.NewService(projectsRepo, hub)
+	projectsService.UseAuthorizer(projectPolicy)
+	orgService.UseAuthorizer(projectPolicy)
+	projectsService.UseAudit(auditService)
+	projectsService.UseTemplates(postgres.NewProjectTemplateRepository(db))
+	projectsService.UseOrganizations(orgService)
+	projectsService.UseRetention(envDuration("PROJECT_RETENTION", projects.DefaultRetention))
+	projectPurger := projects.NewPurger(projectsService)
+	go projectPurger.Run(envDuration("PROJECT_PURGE_INTERVAL", time.Hour))
 	projectHandler := handlers.NewProjectHandler(projectsService)
 
+	// --- Teams, members get the team's role on each of its projects ---
+	teamService := teams.NewService(postgres.NewTeamRepository(db), projectsRepo, orgService, hub)
+	teamService.UseAuthorizer(projectPolicy)
+	teamHandler := handlers.NewTeamHandler(teamService)
+
+	// --- Invitations by email ---
+	invitationService := invitations.NewService(
+		postgres.NewInvitationRepository(db, projectsRepo),
+		projectsRepo,
+		userRepo,
+		mailer,
+		jwtManager,
+		hub,
+		envDuration("INVITATION_TTL", 7*24*time.Hour),
+		envString("INVITATION_URL", "http://localhost:5173/invitations/accept?token="),
+	)
+	invitationService.UseAuthorizer(projectPolicy)
+	invitationService.UseOrganizations(orgService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	userHandler.UseInvitations(invitationService)
+	inviteLinkService := invitations.NewLinkService(
+		postgres.NewInviteLinkRepository(db, projectsRepo),
+		projectsRepo,
+		hub,
+		envString("INVITE_LINK_URL", "http://localhost:5173/invites/"),
+	)
+	inviteLinkService.UseAuthorizer(projectPolicy)
+	inviteLinkService.UseOrganizations(orgService)
+	inviteLinkHandler := handlers.NewInviteLinkHandler(inviteLinkService)
+	// join requests are approved through the project service, like adding by hand
+	joinRequestService := invitations.NewJoinRequestService(postgres.NewJoinRequestRepository(db, projectsRepo), projectsRepo, hub)
+	joinRequestService.UseAuthorizer(projectPolicy)
+	joinRequestService.UseOrganizations(orgService)
+	joinRequestHandler := handlers.NewJoinRequestHandler(joinRequestService)
+
 	// --- Task repo + service + handler ---
 	taskRepo := postgres.NewTaskRepository(db)
 	taskService := tasks.NewService(taskRepo, projectsRepo, hub)
+	taskService.UseAuthorizer(projectPolicy)
 	taskHandler := handlers.NewTaskHandler(taskService)
 
 	// --- Chat/Messages repo + service + handler ---
 	messageRepo := postgres.NewMessageRepository(db)
 	chatService := messages.NewService(messageRepo, projectsRepo, hub)
+	chatService.UseAuthorizer(projectPolicy)
 	chatHandler := handlers.NewChatHandler(chatService)
 
 	//  Start the Hub in a background goroutine
@@ -82,12 +322,28 @@ func Run() {
 		log.Fatal("failed to seed admin user:", err)
 	}
 
+	adminService := user.NewAdminService(userRepo, auditService, tokenService, jwtManager, passwordResetService, verificationService, twoFactorService, hub)
+	adminService.UsePersonalAccessTokens(patService)
+	adminHandler := handlers.NewAdminHandler(adminService, auditService)
+
+	// --- Data export & account deletion ---
+	privacyService := privacy.NewService(postgres.NewPrivacyRepository(db), userRepo, profileService, jwtManager, auditService, hub)
+	privacyService.UseSessions(tokenService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+
 	// WebSocket handler creation
 	wsHandler := handlers.NewWSHandler(jwtManager, hub, chatService)
 	// --- Handler ---
 
 	// --- Echo server ---
 	e := echo.New()
+	// X-Forwarded-For is only honoured behind a proxy we control, otherwise
+	// anyone could dodge the per-ip login throttle with a made up header
+	if envBool("TRUST_PROXY_HEADERS", false) {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	e.Use(echomiddleware.CORSWithConfig(echomiddleware.CORSConfig{
 		AllowOrigins: []string{"*"},
@@ -103,56 +359,28 @@ func Run() {
 		},
 	}))
 
-	authGroup := e.Group("")
-	authGroup.Use(middleware.JWTMiddleware(jwtManager))
-	authGroup.GET("/me", userHandler.Me)
-	authGroup.GET("/users", userHandler.List)
-	// DM Chat History: /messages/direct/:other_id
-	authGroup.GET("/messages/direct/:other_id", chatHandler.GetDMHistory)
-
-	http.RegisterRoutes(e, userHandler)
-
-	// a group for all project-related routes w
-	r := e.Group("/projects")
-	r.Use(middleware.JWTMiddleware(jwtManager))
-
-	// a group for specific task actions
-	t := e.Group("/tasks")
-	t.Use(middleware.JWTMiddleware(jwtManager))
-
-	// --- Routes ---
-	e.POST("/register", userHandler.Register)
-	e.GET("/admin", userHandler.Admin, middleware.RequireRole(jwtManager, "admin"))
-	e.POST("/users", userHandler.Register) // for now i leave it public to allow user creation
-	e.POST("/login", userHandler.Login)
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(stdhttp.StatusOK, map[string]string{"status": "ok"})
+	http.RegisterRoutes(e, jwtManager, http.Handlers{
+		User:         userHandler,
+		Session:      sessionHandler,
+		TwoFactor:    twoFactorHandler,
+		PAT:          patHandler,
+		Profile:      profileHandler,
+		Password:     passwordHandler,
+		Privacy:      privacyHandler,
+		Admin:        adminHandler,
+		JWKS:         jwksHandler,
+		Organization: organizationHandler,
+		Team:         teamHandler,
+		Project:      projectHandler,
+		Invitation:   invitationHandler,
+		InviteLink:   inviteLinkHandler,
+		JoinRequest:  joinRequestHandler,
+		Task:         taskHandler,
+		Chat:         chatHandler,
+		WS:           wsHandler,
+		OIDCEnabled:  oidcEnabled,
 	})
 
-	// project routes
-	r.POST("", projectHandler.Create)
-	r.PUT("/:id", projectHandler.Update)
-	r.GET("", projectHandler.List)
-	r.GET("/:id", projectHandler.GetByID)
-	r.DELETE("/:id", projectHandler.DeleteProject)
-	r.POST("/users", projectHandler.AddUserToProject)
-	r.GET("/users", projectHandler.ListUsersInProject)
-	r.DELETE("/users", projectHandler.RemoveUserFromProject)
-
-	// task routes
-	t.POST("", taskHandler.CreateTask)
-	t.PUT("/:id", taskHandler.UpdateTask)
-	t.DELETE("/:id", taskHandler.DeleteTask)
-	t.GET("/:id/history", taskHandler.GetTaskHistory)
-
-	// project task list: /projects/:id/tasks
-	r.GET("/:id/tasks", taskHandler.ListTaskByProject)
-	// project chat history: /projects/:id/messages
-	r.GET("/:id/messages", chatHandler.GetProjectHistory)
-
-	// websocket route
-	e.GET("/ws", wsHandler.HandleConnection)
-
 	// --- Graceful shutdown prep---
 	quitCh := make(chan os.Signal, 1)
 
@@ -172,6 +400,10 @@ func Run() {
 
 	// stop broadcasting and cleanup clients
 	hub.Stop()
+	denylist.Stop()
+	keySet.Stop()
+	loginThrottle.Stop()
+	projectPurger.Stop()
 
 	// "Deadline" 10 secs
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeRepository implements Repository for testing without a real DB
+type FakeRepository struct {
+	mu      sync.Mutex
+	Entries []Entry
+	nextID  int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Entries: []Entry{},
+		nextID:  1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, e Entry) (*Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e.ID = f.nextID
+	f.nextID++
+	e.CreatedAt = time.Now()
+	f.Entries = append(f.Entries, e)
+	return &e, nil
+}
+
+func (f *FakeRepository) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []Entry
+	// newest first, like the real query
+	for i := len(f.Entries) - 1; i >= 0; i-- {
+		e := f.Entries[i]
+		if filter.ActorID != 0 && e.ActorID != filter.ActorID {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		if filter.TargetType != "" && e.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != 0 && e.TargetID != filter.TargetID {
+			continue
+		}
+		res = append(res, e)
+		if filter.Limit > 0 && len(res) == filter.Limit {
+			break
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Entry is one line of the audit trail
+type Entry struct {
+	ID         int64     `json:"id"`
+	ActorID    int64     `json:"actor_id"` // 0 = the system itself
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Filter narrows List, zero values mean "don't filter on this"
+type Filter struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   int64
+	Limit      int
+}
+
+type Repository interface {
+	Create(ctx context.Context, e Entry) (*Entry, error)
+	List(ctx context.Context, f Filter) ([]Entry, error)
+}
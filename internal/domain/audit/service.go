@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+)
+
+const defaultLimit = 100
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record writes one entry. Callers treat a failure as a failure of the
+// whole action, an admin change that isn't in the trail shouldn't happen.
+func (s *Service) Record(ctx context.Context, actorID int64, action, targetType string, targetID int64, details string) error {
+	_, err := s.repo.Create(ctx, Entry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// List returns the newest entries first
+func (s *Service) List(ctx context.Context, f Filter) ([]Entry, error) {
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = defaultLimit
+	}
+	return s.repo.List(ctx, f)
+}
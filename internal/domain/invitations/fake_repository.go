@@ -0,0 +1,289 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+)
+
+// OrganizationJoiner takes the organization half of a Membership in the fakes
+type OrganizationJoiner interface {
+	Join(ctx context.Context, orgID, userID int64) error
+}
+
+// FakeRepository implements Repository for testing without a real DB.
+// Accept writes memberships through to the project repository, and to
+// Organizations when it's set.
+type FakeRepository struct {
+	Invitations   []Invitation
+	Organizations OrganizationJoiner
+	members       projects.Repository
+	nextID        int64
+}
+
+func NewFakeRepository(members projects.Repository) *FakeRepository {
+	return &FakeRepository{
+		Invitations: []Invitation{},
+		members:     members,
+		nextID:      1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, inv Invitation) (*Invitation, error) {
+	if open, _ := f.GetPending(ctx, inv.ProjectID, inv.Email); open != nil {
+		return nil, errors.New("duplicate pending invitation")
+	}
+	inv.ID = f.nextID
+	f.nextID++
+	inv.CreatedAt = time.Now()
+	inv.SentAt = inv.CreatedAt
+	f.Invitations = append(f.Invitations, inv)
+	return &inv, nil
+}
+
+func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*Invitation, error) {
+	for _, inv := range f.Invitations {
+		if inv.ID == id {
+			c := inv
+			return &c, nil
+		}
+	}
+	return nil, errors.New("invitation not found")
+}
+
+func (f *FakeRepository) GetPending(ctx context.Context, projectID int64, email string) (*Invitation, error) {
+	for _, inv := range f.Invitations {
+		if inv.ProjectID == projectID && strings.EqualFold(inv.Email, email) && inv.AcceptedAt == nil && inv.RevokedAt == nil {
+			c := inv
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeRepository) ListPending(ctx context.Context, projectID int64) ([]Invitation, error) {
+	res := []Invitation{}
+	for _, inv := range f.Invitations {
+		if inv.ProjectID == projectID && inv.AcceptedAt == nil && inv.RevokedAt == nil {
+			res = append(res, inv)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) ListPendingForEmail(ctx context.Context, email string) ([]Invitation, error) {
+	res := []Invitation{}
+	for _, inv := range f.Invitations {
+		if strings.EqualFold(inv.Email, email) && inv.Pending(time.Now()) {
+			res = append(res, inv)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) Renew(ctx context.Context, id, projectID int64, expiresAt time.Time) (*Invitation, error) {
+	for i, inv := range f.Invitations {
+		if inv.ID == id && inv.ProjectID == projectID && inv.AcceptedAt == nil && inv.RevokedAt == nil {
+			f.Invitations[i].SentAt = time.Now()
+			f.Invitations[i].ExpiresAt = expiresAt
+			c := f.Invitations[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeRepository) Revoke(ctx context.Context, id, projectID int64) (bool, error) {
+	for i, inv := range f.Invitations {
+		if inv.ID == id && inv.ProjectID == projectID && inv.AcceptedAt == nil && inv.RevokedAt == nil {
+			now := time.Now()
+			f.Invitations[i].RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) Accept(ctx context.Context, id, userID int64, join *Membership) (bool, error) {
+	for i, inv := range f.Invitations {
+		if inv.ID == id && inv.Pending(time.Now()) {
+			// a failed insert rolls back, the invitation stays open
+			if join != nil {
+				if err := writeMembership(ctx, f.members, f.Organizations, *join); err != nil {
+					return false, err
+				}
+			}
+			now := time.Now()
+			f.Invitations[i].AcceptedAt = &now
+			f.Invitations[i].AcceptedBy = &userID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FakeLinkRepository implements LinkRepository for testing without a real DB.
+// Use writes memberships through to the project repository, and to
+// Organizations when it's set.
+type FakeLinkRepository struct {
+	Links         []InviteLink
+	Organizations OrganizationJoiner
+	members       projects.Repository
+	nextID        int64
+}
+
+func NewFakeLinkRepository(members projects.Repository) *FakeLinkRepository {
+	return &FakeLinkRepository{
+		Links:   []InviteLink{},
+		members: members,
+		nextID:  1,
+	}
+}
+
+func (f *FakeLinkRepository) Create(ctx context.Context, l InviteLink) (*InviteLink, error) {
+	l.ID = f.nextID
+	f.nextID++
+	l.CreatedAt = time.Now()
+	f.Links = append(f.Links, l)
+	return &l, nil
+}
+
+func (f *FakeLinkRepository) GetByHash(ctx context.Context, codeHash string) (*InviteLink, error) {
+	for _, l := range f.Links {
+		if l.CodeHash == codeHash {
+			c := l
+			return &c, nil
+		}
+	}
+	return nil, errors.New("invite link not found")
+}
+
+func (f *FakeLinkRepository) List(ctx context.Context, projectID int64) ([]InviteLink, error) {
+	res := []InviteLink{}
+	for _, l := range f.Links {
+		if l.ProjectID == projectID && l.RevokedAt == nil {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeLinkRepository) Revoke(ctx context.Context, id, projectID int64) (bool, error) {
+	for i, l := range f.Links {
+		if l.ID == id && l.ProjectID == projectID && l.RevokedAt == nil {
+			now := time.Now()
+			f.Links[i].RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeLinkRepository) Use(ctx context.Context, id int64, join Membership) (*InviteLink, error) {
+	for i, l := range f.Links {
+		if l.ID == id && l.Usable(time.Now()) {
+			// a failed insert rolls back, the use isn't counted
+			if err := writeMembership(ctx, f.members, f.Organizations, join); err != nil {
+				return nil, err
+			}
+			f.Links[i].UseCount++
+			c := f.Links[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// FakeJoinRequestRepository implements JoinRequestRepository for testing without a real DB.
+// Approve writes memberships through to the project repository.
+type FakeJoinRequestRepository struct {
+	Requests []JoinRequest
+	members  projects.Repository
+	nextID   int64
+}
+
+func NewFakeJoinRequestRepository(members projects.Repository) *FakeJoinRequestRepository {
+	return &FakeJoinRequestRepository{
+		Requests: []JoinRequest{},
+		members:  members,
+		nextID:   1,
+	}
+}
+
+func (f *FakeJoinRequestRepository) Create(ctx context.Context, r JoinRequest) (*JoinRequest, error) {
+	for _, existing := range f.Requests {
+		if existing.ProjectID == r.ProjectID && existing.UserID == r.UserID && existing.Status == JoinPending {
+			return nil, ErrAlreadyRequested
+		}
+	}
+	r.ID = f.nextID
+	f.nextID++
+	r.Status = JoinPending
+	r.CreatedAt = time.Now()
+	f.Requests = append(f.Requests, r)
+	return &r, nil
+}
+
+func (f *FakeJoinRequestRepository) ListPending(ctx context.Context, projectID int64) ([]JoinRequest, error) {
+	res := []JoinRequest{}
+	for _, r := range f.Requests {
+		if r.ProjectID == projectID && r.Status == JoinPending {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeJoinRequestRepository) GetPending(ctx context.Context, id, projectID int64) (*JoinRequest, error) {
+	for _, r := range f.Requests {
+		if r.ID == id && r.ProjectID == projectID && r.Status == JoinPending {
+			c := r
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeJoinRequestRepository) Decide(ctx context.Context, id, projectID int64, status string, deciderID int64) (*JoinRequest, error) {
+	for i, r := range f.Requests {
+		if r.ID == id && r.ProjectID == projectID && r.Status == JoinPending {
+			now := time.Now()
+			f.Requests[i].Status = status
+			f.Requests[i].DecidedAt = &now
+			f.Requests[i].DecidedBy = &deciderID
+			c := f.Requests[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeJoinRequestRepository) Approve(ctx context.Context, id, projectID, deciderID int64, join *Membership) (*JoinRequest, error) {
+	for _, r := range f.Requests {
+		if r.ID == id && r.ProjectID == projectID && r.Status == JoinPending {
+			// a failed insert rolls back, the request stays open
+			if join != nil {
+				if err := writeMembership(ctx, f.members, nil, *join); err != nil {
+					return nil, err
+				}
+			}
+			return f.Decide(ctx, id, projectID, JoinApproved, deciderID)
+		}
+	}
+	return nil, nil
+}
+
+// writeMembership adds the project membership and then the organization
+// one, so a failing project insert leaves both untouched like a rollback
+func writeMembership(ctx context.Context, members projects.Repository, orgs OrganizationJoiner, join Membership) error {
+	if err := members.AddUserToProject(ctx, join.ProjectID, join.UserID, join.Role); err != nil {
+		return err
+	}
+	if orgs != nil && join.OrganizationID != 0 {
+		return orgs.Join(ctx, join.OrganizationID, join.UserID)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package invitations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+// longest note a requester can leave for the owners
+const maxJoinMessageLength = 500
+
+var (
+	ErrJoinRequestNotFound = errors.New("join request not found or already decided")
+	ErrAlreadyRequested    = errors.New("you already asked to join this project")
+	ErrJoinMessageTooLong  = fmt.Errorf("the message can't be longer than %d characters", maxJoinMessageLength)
+)
+
+// JoinRequestService lets people ask for access to a project they know
+// about, and the project's managers answer them
+type JoinRequestService struct {
+	repo     JoinRequestRepository
+	projects projects.Repository
+	policy   *projects.Authorizer
+	orgs     Organizations
+	hub      *ws.Hub
+}
+
+func NewJoinRequestService(repo JoinRequestRepository, projects projects.Repository, hub *ws.Hub) *JoinRequestService {
+	return &JoinRequestService{
+		repo:     repo,
+		projects: projects,
+		policy:   projectsAuthorizer(projects),
+		hub:      hub,
+	}
+}
+
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *JoinRequestService) UseAuthorizer(a *projects.Authorizer) {
+	s.policy = a
+}
+
+// UseOrganizations keeps requests inside the project's organization,
+// nobody outside it learns the project exists
+func (s *JoinRequestService) UseOrganizations(orgs Organizations) {
+	s.orgs = orgs
+}
+
+// Request files the user's request and lets the project's managers know
+func (s *JoinRequestService) Request(ctx context.Context, userID, projectID int64, message string) (*JoinRequest, error) {
+	message = strings.TrimSpace(message)
+	if len([]rune(message)) > maxJoinMessageLength {
+		return nil, ErrJoinMessageTooLong
+	}
+
+	project, err := s.projects.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return nil, ErrProjectNotFound
+	}
+	// same answer as a missing project, or ids could be probed across organizations
+	if s.orgs != nil {
+		inOrg, err := s.orgs.IsMember(ctx, project.OrganizationID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !inOrg {
+			return nil, ErrProjectNotFound
+		}
+	}
+	if project.ArchivedAt != nil {
+		return nil, projects.ErrProjectArchived
+	}
+	member, err := isMember(ctx, s.projects, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	req, err := s.repo.Create(ctx, JoinRequest{
+		ProjectID: projectID,
+		UserID:    userID,
+		Message:   message,
+		Status:    JoinPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyManagers(ctx, req)
+	return req, nil
+}
+
+// List shows the open requests of a project to the people who can answer them
+func (s *JoinRequestService) List(ctx context.Context, requesterID, projectID int64) ([]JoinRequest, error) {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPending(ctx, projectID)
+}
+
+// Approve adds the requester to the project with the role, member when
+// none is given, with the limits of adding them by hand. Someone who
+// left the organization since asking is refused.
+func (s *JoinRequestService) Approve(ctx context.Context, requesterID, projectID, requestID int64, role string) (*JoinRequest, error) {
+	project, requesterRole, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		role = projects.RoleMember
+	}
+	if err := checkInvitableRole(requesterRole, role); err != nil {
+		return nil, err
+	}
+
+	pending, err := s.repo.GetPending(ctx, requestID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load join request: %w", err)
+	}
+	if pending == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+
+	// checked before the request is closed, adding them couldn't work anymore
+	if s.orgs != nil {
+		inOrg, err := s.orgs.IsMember(ctx, project.OrganizationID, pending.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !inOrg {
+			return nil, projects.ErrNotInOrganization
+		}
+	}
+
+	// added by hand in the meantime, only the request is left to close
+	member, err := isMember(ctx, s.projects, projectID, pending.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var join *Membership
+	if !member {
+		join = &Membership{ProjectID: projectID, UserID: pending.UserID, Role: role}
+	}
+
+	// one transaction, so a reject racing this approval either wins outright
+	// or finds the request gone, and a failed insert leaves the request open
+	req, err := s.repo.Approve(ctx, pending.ID, projectID, requesterID, join)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve join request: %w", err)
+	}
+	if req == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	if join != nil {
+		s.policy.Forget(projectID)
+		announceJoin(s.hub, projectID, pending.UserID, role)
+	}
+
+	s.notifyRequester(req)
+	return req, nil
+}
+
+// Reject closes the request, the user can ask again later
+func (s *JoinRequestService) Reject(ctx context.Context, requesterID, projectID, requestID int64) (*JoinRequest, error) {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return nil, err
+	}
+
+	pending, err := s.repo.GetPending(ctx, requestID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load join request: %w", err)
+	}
+	if pending == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	req, err := s.close(ctx, pending, JoinRejected, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyRequester(req)
+	return req, nil
+}
+
+// close decides the request, ErrJoinRequestNotFound if someone else did first
+func (s *JoinRequestService) close(ctx context.Context, pending *JoinRequest, status string, deciderID int64) (*JoinRequest, error) {
+	req, err := s.repo.Decide(ctx, pending.ID, pending.ProjectID, status, deciderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide join request: %w", err)
+	}
+	if req == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	return req, nil
+}
+
+// notifyRequester tells the requester how it went
+func (s *JoinRequestService) notifyRequester(req *JoinRequest) {
+	if s.hub != nil {
+		if payload, err := json.Marshal(map[string]interface{}{"type": "join_request_" + req.Status, "data": req}); err == nil {
+			s.hub.SendToUser(req.UserID, payload)
+		}
+	}
+}
+
+// notifyManagers tells every member who can answer the request about it.
+// A failure is only logged, the request shows up in the list either way.
+func (s *JoinRequestService) notifyManagers(ctx context.Context, req *JoinRequest) {
+	if s.hub == nil {
+		return
+	}
+	members, err := s.projects.ListUsersInProject(ctx, req.ProjectID)
+	if err != nil {
+		log.Printf("failed to notify managers of join request %d: %v", req.ID, err)
+		return
+	}
+
+	var managers []int64
+	for _, m := range members {
+		if projects.RoleAllows(m.Role, projects.PermManageMembers) {
+			managers = append(managers, m.ID)
+		}
+	}
+	if payload, err := json.Marshal(map[string]interface{}{"type": "join_request", "data": req}); err == nil {
+		s.hub.SendToProjectMembers(managers, payload)
+	}
+}
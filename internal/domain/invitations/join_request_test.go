@@ -0,0 +1,124 @@
+package invitations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinRequests(t *testing.T) {
+	ctx := context.Background()
+
+	// 1 owns the project, 2 maintains it, 3 is a member
+	setup := func() (*JoinRequestService, *FakeJoinRequestRepository, *projects.FakeRepository) {
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+		projectRepo.AddUserToProject(ctx, 1, 1, projects.RoleOwner)
+		projectRepo.AddUserToProject(ctx, 1, 2, projects.RoleMaintainer)
+		projectRepo.AddUserToProject(ctx, 1, 3, projects.RoleMember)
+
+		repo := NewFakeJoinRequestRepository(projectRepo)
+		return NewJoinRequestService(repo, projectRepo, nil), repo, projectRepo
+	}
+
+	memberRole := func(repo *projects.FakeRepository, userID int64) string {
+		role, _ := projects.NewAuthorizer(repo).Role(ctx, 1, userID)
+		return role
+	}
+
+	t.Run("Anyone outside the project can ask once", func(t *testing.T) {
+		svc, _, _ := setup()
+
+		req, err := svc.Request(ctx, 5, 1, "  I work on the launch  ")
+		assert.NoError(t, err)
+		assert.Equal(t, JoinPending, req.Status)
+		assert.Equal(t, "I work on the launch", req.Message)
+
+		_, err = svc.Request(ctx, 5, 1, "")
+		assert.ErrorIs(t, err, ErrAlreadyRequested)
+		_, err = svc.Request(ctx, 3, 1, "")
+		assert.ErrorIs(t, err, ErrAlreadyMember)
+		_, err = svc.Request(ctx, 5, 9, "")
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+	})
+
+	t.Run("Managers see the queue and approve into the project", func(t *testing.T) {
+		svc, _, projectRepo := setup()
+		req, _ := svc.Request(ctx, 5, 1, "")
+
+		_, err := svc.List(ctx, 3, 1)
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+		_, err = svc.Approve(ctx, 3, 1, req.ID, "")
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+		pending, _ := svc.List(ctx, 2, 1)
+		assert.Len(t, pending, 1)
+
+		// maintainers only grant roles below their own
+		_, err = svc.Approve(ctx, 2, 1, req.ID, projects.RoleMaintainer)
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+
+		approved, err := svc.Approve(ctx, 2, 1, req.ID, projects.RoleViewer)
+		assert.NoError(t, err)
+		assert.Equal(t, JoinApproved, approved.Status)
+		assert.Equal(t, int64(2), *approved.DecidedBy)
+		assert.Equal(t, projects.RoleViewer, memberRole(projectRepo, 5))
+
+		pending, _ = svc.List(ctx, 1, 1)
+		assert.Empty(t, pending)
+		_, err = svc.Approve(ctx, 1, 1, req.ID, "")
+		assert.ErrorIs(t, err, ErrJoinRequestNotFound)
+	})
+
+	t.Run("Rejected users stay out and can ask again", func(t *testing.T) {
+		svc, _, projectRepo := setup()
+		req, _ := svc.Request(ctx, 5, 1, "")
+
+		rejected, err := svc.Reject(ctx, 1, 1, req.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, JoinRejected, rejected.Status)
+		assert.Empty(t, memberRole(projectRepo, 5))
+		_, err = svc.Reject(ctx, 1, 1, req.ID)
+		assert.ErrorIs(t, err, ErrJoinRequestNotFound)
+
+		_, err = svc.Request(ctx, 5, 1, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("A request someone else decided first adds nobody", func(t *testing.T) {
+		svc, repo, projectRepo := setup()
+		req, _ := svc.Request(ctx, 5, 1, "")
+		svc.repo = rejectedMeanwhile{repo}
+
+		_, err := svc.Approve(ctx, 1, 1, req.ID, "")
+		assert.ErrorIs(t, err, ErrJoinRequestNotFound)
+		assert.Empty(t, memberRole(projectRepo, 5))
+	})
+
+	t.Run("A failed insert leaves the request open", func(t *testing.T) {
+		svc, repo, projectRepo := setup()
+		req, _ := svc.Request(ctx, 5, 1, "")
+		repo.members = failingMembers{projectRepo}
+
+		_, err := svc.Approve(ctx, 1, 1, req.ID, "")
+		assert.Error(t, err)
+		assert.Empty(t, memberRole(projectRepo, 5))
+		pending, _ := svc.List(ctx, 1, 1)
+		assert.Len(t, pending, 1)
+	})
+}
+
+// rejectedMeanwhile loses every decision, as if a reject committed between
+// loading the request and deciding it
+type rejectedMeanwhile struct {
+	*FakeJoinRequestRepository
+}
+
+func (rejectedMeanwhile) Decide(ctx context.Context, id, projectID int64, status string, deciderID int64) (*JoinRequest, error) {
+	return nil, nil
+}
+
+func (rejectedMeanwhile) Approve(ctx context.Context, id, projectID, deciderID int64, join *Membership) (*JoinRequest, error) {
+	return nil, nil
+}
@@ -0,0 +1,152 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+var (
+	ErrInviteLinkNotFound = errors.New("invite link not found")
+	ErrInvalidInviteLink  = errors.New("invalid, expired or used up invite link")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+	ErrInvalidMaxUses     = errors.New("max_uses must be at least 1")
+)
+
+// how much of the code is kept in the clear
+const linkPrefixLength = 8
+
+// LinkService manages join links, the chat-friendly counterpart of email
+// invitations
+type LinkService struct {
+	repo     LinkRepository
+	projects projects.Repository
+	policy   *projects.Authorizer
+	orgs     Organizations
+	hub      *ws.Hub
+	joinURL  string // the code gets appended to this
+}
+
+func NewLinkService(repo LinkRepository, projects projects.Repository, hub *ws.Hub, joinURL string) *LinkService {
+	return &LinkService{
+		repo:     repo,
+		projects: projects,
+		policy:   projectsAuthorizer(projects),
+		hub:      hub,
+		joinURL:  joinURL,
+	}
+}
+
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *LinkService) UseAuthorizer(a *projects.Authorizer) {
+	s.policy = a
+}
+
+// UseOrganizations adds whoever joins through a link to the project's organization
+func (s *LinkService) UseOrganizations(orgs Organizations) {
+	s.orgs = orgs
+}
+
+// Create makes a new link for the project. The raw code is returned once and never stored.
+func (s *LinkService) Create(ctx context.Context, requesterID, projectID int64, role string, expiresAt *time.Time, maxUses *int) (string, *InviteLink, error) {
+	_, requesterRole, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID)
+	if err != nil {
+		return "", nil, err
+	}
+	if role == "" {
+		role = projects.RoleMember
+	}
+	if err := checkInvitableRole(requesterRole, role); err != nil {
+		return "", nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, ErrInvalidExpiry
+	}
+	if maxUses != nil && *maxUses < 1 {
+		return "", nil, ErrInvalidMaxUses
+	}
+
+	code, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	link, err := s.repo.Create(ctx, InviteLink{
+		ProjectID: projectID,
+		CodeHash:  auth.HashToken(code),
+		Prefix:    code[:linkPrefixLength],
+		Role:      role,
+		CreatedBy: requesterID,
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store invite link: %w", err)
+	}
+	return code, link, nil
+}
+
+// URL is what owners paste into the chat
+func (s *LinkService) URL(code string) string {
+	return s.joinURL + code
+}
+
+func (s *LinkService) List(ctx context.Context, requesterID, projectID int64) ([]InviteLink, error) {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, projectID)
+}
+
+// Revoke stops the link from working, people who already joined stay
+func (s *LinkService) Revoke(ctx context.Context, requesterID, projectID, linkID int64) error {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.Revoke(ctx, linkID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite link: %w", err)
+	}
+	if !ok {
+		return ErrInviteLinkNotFound
+	}
+	return nil
+}
+
+// Accept adds the user to the project of the link with the link's role
+func (s *LinkService) Accept(ctx context.Context, code string, userID int64) (*InviteLink, error) {
+	link, err := s.repo.GetByHash(ctx, auth.HashToken(code))
+	if err != nil || link == nil || !link.Usable(time.Now()) {
+		return nil, ErrInvalidInviteLink
+	}
+
+	// opening the link twice shouldn't eat up a use
+	member, err := isMember(ctx, s.projects, link.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	orgID, err := organizationToJoin(ctx, s.orgs, s.projects, link.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	link, err = s.repo.Use(ctx, link.ID, Membership{ProjectID: link.ProjectID, UserID: userID, Role: link.Role, OrganizationID: orgID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to use invite link: %w", err)
+	}
+	if link == nil {
+		return nil, ErrInvalidInviteLink
+	}
+	s.policy.Forget(link.ProjectID)
+	announceJoin(s.hub, link.ProjectID, userID, link.Role)
+	return link, nil
+}
@@ -0,0 +1,167 @@
+package invitations
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*LinkService, *FakeLinkRepository, *projects.FakeRepository) {
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: 10})
+		projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+
+		repo := NewFakeLinkRepository(projectRepo)
+		return NewLinkService(repo, projectRepo, nil, "http://localhost/invites/"), repo, projectRepo
+	}
+
+	memberRole := func(repo *projects.FakeRepository, userID int64) string {
+		members, _ := repo.ListUsersInProject(ctx, 1)
+		for _, m := range members {
+			if m.ID == userID {
+				return m.Role
+			}
+		}
+		return ""
+	}
+
+	t.Run("Only managers create links and the code isn't stored", func(t *testing.T) {
+		svc, repo, projectRepo := setup()
+
+		_, _, err := svc.Create(ctx, 2, 1, "member", nil, nil)
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+		past := time.Now().Add(-time.Hour)
+		_, _, err = svc.Create(ctx, 1, 1, "member", &past, nil)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+		zero := 0
+		_, _, err = svc.Create(ctx, 1, 1, "member", nil, &zero)
+		assert.ErrorIs(t, err, ErrInvalidMaxUses)
+		_, _, err = svc.Create(ctx, 1, 1, "owner", nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidRole)
+
+		// maintainers hand out roles below their own only
+		projectRepo.AddUserToProject(ctx, 1, 3, "maintainer")
+		_, _, err = svc.Create(ctx, 3, 1, "maintainer", nil, nil)
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+		_, _, err = svc.Create(ctx, 3, 1, "viewer", nil, nil)
+		assert.NoError(t, err)
+
+		code, link, err := svc.Create(ctx, 1, 1, "", nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "member", link.Role)
+		assert.True(t, strings.HasPrefix(code, link.Prefix))
+		assert.NotEqual(t, code, repo.Links[1].CodeHash)
+		assert.Equal(t, "http://localhost/invites/"+code, svc.URL(code))
+	})
+
+	t.Run("Joining uses up the link", func(t *testing.T) {
+		svc, _, projectRepo := setup()
+		two := 2
+		code, _, _ := svc.Create(ctx, 1, 1, "maintainer", nil, &two)
+
+		link, err := svc.Accept(ctx, code, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, link.UseCount)
+		assert.Equal(t, "maintainer", memberRole(projectRepo, 2))
+
+		// opening it again doesn't count
+		_, err = svc.Accept(ctx, code, 2)
+		assert.ErrorIs(t, err, ErrAlreadyMember)
+
+		_, err = svc.Accept(ctx, code, 3)
+		assert.NoError(t, err)
+		_, err = svc.Accept(ctx, code, 4)
+		assert.ErrorIs(t, err, ErrInvalidInviteLink)
+		assert.Empty(t, memberRole(projectRepo, 4))
+	})
+
+	t.Run("Expired and revoked links don't work", func(t *testing.T) {
+		svc, repo, _ := setup()
+		soon := time.Now().Add(time.Hour)
+		expiring, _, _ := svc.Create(ctx, 1, 1, "member", &soon, nil)
+		revoked, link, _ := svc.Create(ctx, 1, 1, "member", nil, nil)
+
+		past := time.Now().Add(-time.Minute)
+		repo.Links[0].ExpiresAt = &past
+		_, err := svc.Accept(ctx, expiring, 2)
+		assert.ErrorIs(t, err, ErrInvalidInviteLink)
+
+		assert.ErrorIs(t, svc.Revoke(ctx, 2, 1, link.ID), ErrNotProjectManager)
+		assert.NoError(t, svc.Revoke(ctx, 1, 1, link.ID))
+		assert.ErrorIs(t, svc.Revoke(ctx, 1, 1, link.ID), ErrInviteLinkNotFound)
+		_, err = svc.Accept(ctx, revoked, 2)
+		assert.ErrorIs(t, err, ErrInvalidInviteLink)
+
+		_, err = svc.Accept(ctx, "made-up-code", 2)
+		assert.ErrorIs(t, err, ErrInvalidInviteLink)
+
+		links, _ := svc.List(ctx, 1, 1)
+		assert.Len(t, links, 1)
+	})
+
+	t.Run("A failed insert doesn't use up the link", func(t *testing.T) {
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+		projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+		repo := NewFakeLinkRepository(failingMembers{projectRepo})
+		svc := NewLinkService(repo, projectRepo, nil, "")
+
+		one := 1
+		code, _, _ := svc.Create(ctx, 1, 1, "member", nil, &one)
+		_, err := svc.Accept(ctx, code, 2)
+		assert.Error(t, err)
+		assert.Equal(t, 0, repo.Links[0].UseCount)
+		assert.Empty(t, memberRole(projectRepo, 2))
+	})
+
+	t.Run("The organization is only joined along with the project", func(t *testing.T) {
+		svc, repo, projectRepo := setup()
+		orgs := &joiningOrgs{}
+		svc.UseOrganizations(orgs)
+		repo.Organizations = orgs
+		code, _, _ := svc.Create(ctx, 1, 1, "member", nil, nil)
+
+		// the link ran out between the lookup and the transaction
+		svc.repo = usedUpMeanwhile{repo}
+		_, err := svc.Accept(ctx, code, 2)
+		assert.ErrorIs(t, err, ErrInvalidInviteLink)
+		assert.Empty(t, orgs.joined)
+
+		svc.repo = repo
+		_, err = svc.Accept(ctx, code, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, orgs.joined)
+		assert.Equal(t, "member", memberRole(projectRepo, 2))
+	})
+}
+
+// usedUpMeanwhile loses every use, as if the last one was taken between
+// loading the link and using it
+type usedUpMeanwhile struct {
+	*FakeLinkRepository
+}
+
+func (usedUpMeanwhile) Use(ctx context.Context, id int64, join Membership) (*InviteLink, error) {
+	return nil, nil
+}
+
+// joiningOrgs lets everyone in and records who joined
+type joiningOrgs struct {
+	joined []int64
+}
+
+func (o *joiningOrgs) IsMember(ctx context.Context, orgID, userID int64) (bool, error) {
+	return true, nil
+}
+
+func (o *joiningOrgs) Join(ctx context.Context, orgID, userID int64) error {
+	o.joined = append(o.joined, userID)
+	return nil
+}
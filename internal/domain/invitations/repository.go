@@ -0,0 +1,136 @@
+package invitations
+
+import (
+	"context"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+)
+
+// Invitation asks someone to join a project by email. It turns into a
+// membership once the invited address signs in, with or without the link.
+type Invitation struct {
+	ID         int64      `json:"id"`
+	ProjectID  int64      `json:"project_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	InvitedBy  int64      `json:"invited_by,omitempty"` // 0 once the inviter's account is gone
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     time.Time  `json:"sent_at"` // last time the mail went out
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy *int64     `json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Pending reports whether the invitation can still be accepted
+func (i Invitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// Membership is the project_users row written in the same transaction as
+// whatever grants it, so a failed insert doesn't use anything up
+type Membership struct {
+	ProjectID int64
+	UserID    int64
+	Role      string
+	// OrganizationID is the project's organization, joined as a plain member
+	// in the same transaction. 0 when organizations aren't in use.
+	OrganizationID int64
+}
+
+type Repository interface {
+	Create(ctx context.Context, inv Invitation) (*Invitation, error)
+	GetByID(ctx context.Context, id int64) (*Invitation, error)
+	// GetPending returns the open invitation of the address for the project,
+	// expired or not, nil if there is none
+	GetPending(ctx context.Context, projectID int64, email string) (*Invitation, error)
+	ListPending(ctx context.Context, projectID int64) ([]Invitation, error)
+	// ListPendingForEmail only returns invitations that haven't expired
+	ListPendingForEmail(ctx context.Context, email string) ([]Invitation, error)
+	// Renew moves the expiry of an open invitation, nil if it isn't open anymore
+	Renew(ctx context.Context, id, projectID int64, expiresAt time.Time) (*Invitation, error)
+	// Revoke returns false if the invitation isn't open or belongs to another project
+	Revoke(ctx context.Context, id, projectID int64) (bool, error)
+	// Accept marks the invitation used and adds the membership, nil when the
+	// user is in the project already. Returns false, with nothing written,
+	// if the invitation was already used, revoked or expired.
+	Accept(ctx context.Context, id, userID int64, join *Membership) (bool, error)
+}
+
+// InviteLink lets anyone holding the code join the project, e.g. from a
+// team chat. Only the hash of the code is stored.
+type InviteLink struct {
+	ID        int64      `json:"id"`
+	ProjectID int64      `json:"project_id"`
+	CodeHash  string     `json:"-"`
+	Prefix    string     `json:"prefix"` // first characters, so owners can tell links apart
+	Role      string     `json:"role"`
+	CreatedBy int64      `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil never expires
+	MaxUses   *int       `json:"max_uses,omitempty"`   // nil has no limit
+	UseCount  int        `json:"use_count"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Usable reports whether someone can still join through the link
+func (l InviteLink) Usable(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return false
+	}
+	return l.MaxUses == nil || l.UseCount < *l.MaxUses
+}
+
+type LinkRepository interface {
+	Create(ctx context.Context, l InviteLink) (*InviteLink, error)
+	GetByHash(ctx context.Context, codeHash string) (*InviteLink, error)
+	// List returns the links of a project that haven't been revoked
+	List(ctx context.Context, projectID int64) ([]InviteLink, error)
+	// Revoke returns false if the link is already revoked or belongs to another project
+	Revoke(ctx context.Context, id, projectID int64) (bool, error)
+	// Use counts a join and adds the membership in one transaction. Nil,
+	// with nothing written, if the link is revoked, expired or used up by now.
+	Use(ctx context.Context, id int64, join Membership) (*InviteLink, error)
+}
+
+// the states of a join request
+const (
+	JoinPending  = "pending"
+	JoinApproved = "approved"
+	JoinRejected = "rejected"
+)
+
+// JoinRequest is someone asking to be let into a project. Owners and
+// maintainers approve or reject it.
+type JoinRequest struct {
+	ID        int64            `json:"id"`
+	ProjectID int64            `json:"project_id"`
+	UserID    int64            `json:"user_id"`
+	Profile   profiles.Summary `json:"profile"`
+	Message   string           `json:"message,omitempty"`
+	Status    string           `json:"status"`
+	CreatedAt time.Time        `json:"created_at"`
+	DecidedAt *time.Time       `json:"decided_at,omitempty"`
+	DecidedBy *int64           `json:"decided_by,omitempty"`
+}
+
+type JoinRequestRepository interface {
+	// Create returns ErrAlreadyRequested if the user already has an open
+	// request for the project
+	Create(ctx context.Context, r JoinRequest) (*JoinRequest, error)
+	// ListPending returns the open requests of a project, oldest first
+	ListPending(ctx context.Context, projectID int64) ([]JoinRequest, error)
+	// GetPending returns the open request, nil if it was decided already or
+	// belongs to another project
+	GetPending(ctx context.Context, id, projectID int64) (*JoinRequest, error)
+	// Decide closes an open request, nil if someone else decided it first
+	Decide(ctx context.Context, id, projectID int64, status string, deciderID int64) (*JoinRequest, error)
+	// Approve decides the request as approved and writes the membership in
+	// the same transaction, join is nil when the user is a member already.
+	// nil if someone else decided it first.
+	Approve(ctx context.Context, id, projectID, deciderID int64, join *Membership) (*JoinRequest, error)
+}
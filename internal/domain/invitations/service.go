@@ -0,0 +1,367 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+const actionAcceptInvitation = "accept_invitation"
+
+var (
+	ErrProjectNotFound     = errors.New("project not found")
+	ErrNotProjectManager   = fmt.Errorf("%w: your project role can't manage invitations", projects.ErrForbidden)
+	ErrInvalidEmail        = errors.New("a valid email address is required")
+	ErrInvalidRole         = projects.ErrInvalidRole
+	ErrAlreadyMember       = errors.New("user is already a member of this project")
+	ErrAlreadyInvited      = errors.New("this address already has a pending invitation, resend it instead")
+	ErrInvitationNotFound  = errors.New("invitation not found")
+	ErrInvalidInvitation   = errors.New("invalid or expired invitation")
+	ErrInvitationMismatch  = errors.New("this invitation was sent to a different email address")
+	ErrAccountNotActivated = errors.New("verify your email address before accepting invitations")
+)
+
+// Organizations keeps projects to members of their organization. Accepting
+// an invitation joins it through the Membership the repository writes.
+type Organizations interface {
+	IsMember(ctx context.Context, orgID, userID int64) (bool, error)
+}
+
+// Service lets project owners and maintainers invite people by email, whether or not
+// they have an account yet
+type Service struct {
+	repo      Repository
+	projects  projects.Repository
+	policy    *projects.Authorizer
+	orgs      Organizations
+	users     user.Repository
+	mailer    mail.Mailer
+	jwt       *auth.JWTManager
+	hub       *ws.Hub
+	ttl       time.Duration
+	acceptURL string // the token gets appended to this
+}
+
+func NewService(
+	repo Repository,
+	projects projects.Repository,
+	users user.Repository,
+	mailer mail.Mailer,
+	jwt *auth.JWTManager,
+	hub *ws.Hub,
+	ttl time.Duration,
+	acceptURL string,
+) *Service {
+	return &Service{
+		repo:      repo,
+		projects:  projects,
+		policy:    projectsAuthorizer(projects),
+		users:     users,
+		mailer:    mailer,
+		jwt:       jwt,
+		hub:       hub,
+		ttl:       ttl,
+		acceptURL: acceptURL,
+	}
+}
+
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *Service) UseAuthorizer(a *projects.Authorizer) {
+	s.policy = a
+}
+
+// UseOrganizations adds whoever accepts an invitation to the project's organization
+func (s *Service) UseOrganizations(orgs Organizations) {
+	s.orgs = orgs
+}
+
+// Invite mails an invitation link to the address. A failed mail is only
+// logged, the invitation stands and can be resent.
+func (s *Service) Invite(ctx context.Context, requesterID, projectID int64, email, role string) (*Invitation, error) {
+	project, requesterRole, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, ErrInvalidEmail
+	}
+	if role == "" {
+		role = projects.RoleMember
+	}
+	if err := checkInvitableRole(requesterRole, role); err != nil {
+		return nil, err
+	}
+
+	if u, err := s.users.GetByEmail(ctx, email); err == nil && u != nil {
+		member, err := isMember(ctx, s.projects, projectID, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	existing, err := s.repo.GetPending(ctx, projectID, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up invitations: %w", err)
+	}
+	if existing != nil {
+		if existing.Pending(time.Now()) {
+			return nil, ErrAlreadyInvited
+		}
+		// an expired one only blocks the slot, make room for the new one
+		if _, err := s.repo.Revoke(ctx, existing.ID, projectID); err != nil {
+			return nil, fmt.Errorf("failed to replace expired invitation: %w", err)
+		}
+	}
+
+	inv, err := s.repo.Create(ctx, Invitation{
+		ProjectID: projectID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: requesterID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	if err := s.send(ctx, inv, project); err != nil {
+		log.Printf("failed to mail invitation %d: %v", inv.ID, err)
+	}
+	return inv, nil
+}
+
+// List shows the invitations of a project that haven't been accepted or revoked yet,
+// expired ones included so the owner can resend them
+func (s *Service) List(ctx context.Context, requesterID, projectID int64) ([]Invitation, error) {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPending(ctx, projectID)
+}
+
+// Resend mails a fresh link and restarts the expiry
+func (s *Service) Resend(ctx context.Context, requesterID, projectID, invitationID int64) (*Invitation, error) {
+	project, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := s.repo.Renew(ctx, invitationID, projectID, time.Now().Add(s.ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew invitation: %w", err)
+	}
+	if inv == nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	if err := s.send(ctx, inv, project); err != nil {
+		return nil, fmt.Errorf("failed to mail invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// Revoke makes the link useless, the address can be invited again later
+func (s *Service) Revoke(ctx context.Context, requesterID, projectID, invitationID int64) error {
+	if _, _, err := managedProject(ctx, s.policy, s.projects, requesterID, projectID); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.Revoke(ctx, invitationID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if !ok {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept redeems the link from the mail for a signed-in user. The link
+// only works for the address it was sent to.
+func (s *Service) Accept(ctx context.Context, token string, userID int64) (*Invitation, error) {
+	claims, err := s.jwt.VerifyActionToken(actionAcceptInvitation, token)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	inv, err := s.repo.GetByID(ctx, claims.UserID)
+	if err != nil || inv == nil || !inv.Pending(time.Now()) || !strings.EqualFold(inv.Email, claims.Subject) {
+		return nil, ErrInvalidInvitation
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, ErrInvalidInvitation
+	}
+	if u.Status != user.StatusActive {
+		return nil, ErrAccountNotActivated
+	}
+	if !strings.EqualFold(strings.TrimSpace(u.Email), inv.Email) {
+		return nil, ErrInvitationMismatch
+	}
+
+	if err := s.join(ctx, inv, u.ID); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// AcceptPending turns every open invitation for the user's address into a
+// membership. It runs on login and after email verification, so people who
+// registered without clicking the link still end up in their projects.
+// Unverified accounts are skipped, anyone can register with any address.
+func (s *Service) AcceptPending(ctx context.Context, u *user.User) ([]Invitation, error) {
+	if u == nil || u.Status != user.StatusActive {
+		return nil, nil
+	}
+
+	pending, err := s.repo.ListPendingForEmail(ctx, strings.TrimSpace(u.Email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	var joined []Invitation
+	var errs []error
+	for _, inv := range pending {
+		if err := s.join(ctx, &inv, u.ID); err != nil {
+			errs = append(errs, fmt.Errorf("invitation %d: %w", inv.ID, err))
+			continue
+		}
+		joined = append(joined, inv)
+	}
+	return joined, errors.Join(errs...)
+}
+
+// join burns the invitation and adds the membership in one transaction
+func (s *Service) join(ctx context.Context, inv *Invitation, userID int64) error {
+	// added by hand in the meantime, only the invitation is left to close
+	member, err := isMember(ctx, s.projects, inv.ProjectID, userID)
+	if err != nil {
+		return err
+	}
+
+	var membership *Membership
+	if !member {
+		orgID, err := organizationToJoin(ctx, s.orgs, s.projects, inv.ProjectID)
+		if err != nil {
+			return err
+		}
+		membership = &Membership{ProjectID: inv.ProjectID, UserID: userID, Role: inv.Role, OrganizationID: orgID}
+	}
+
+	ok, err := s.repo.Accept(ctx, inv.ID, userID, membership)
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if !ok {
+		return ErrInvalidInvitation
+	}
+	now := time.Now()
+	inv.AcceptedAt = &now
+	inv.AcceptedBy = &userID
+
+	if membership != nil {
+		s.policy.Forget(inv.ProjectID)
+		announceJoin(s.hub, inv.ProjectID, userID, inv.Role)
+	}
+	return nil
+}
+
+func (s *Service) send(ctx context.Context, inv *Invitation, project *projects.Project) error {
+	token, err := s.jwt.GenerateActionToken(actionAcceptInvitation, inv.ID, inv.Email, time.Until(inv.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      inv.Email,
+		Subject: fmt.Sprintf("You're invited to %s on Playingfield", project.Name),
+		Body: fmt.Sprintf("You've been invited to join the project %q on Playingfield.\n\n"+
+			"Open this link to accept, you can create an account on the way if you don't have one:\n%s%s\n\n"+
+			"The invitation expires on %s.", project.Name, s.acceptURL, token, inv.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST")),
+	})
+}
+
+// managedProject loads the project if the requester's role lets them
+// manage its members, and returns that role
+func managedProject(ctx context.Context, policy *projects.Authorizer, repo projects.Repository, requesterID, projectID int64) (*projects.Project, string, error) {
+	project, err := repo.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return nil, "", ErrProjectNotFound
+	}
+	role, err := policy.Require(ctx, projectID, requesterID, projects.PermManageMembers)
+	if errors.Is(err, projects.ErrNotMember) || errors.Is(err, projects.ErrPermissionDenied) {
+		return nil, "", ErrNotProjectManager
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return project, role, nil
+}
+
+// the name keeps the projects parameters of the constructors from shadowing the package
+func projectsAuthorizer(repo projects.Repository) *projects.Authorizer {
+	return projects.NewAuthorizer(repo)
+}
+
+// checkInvitableRole keeps invitations to what the inviter could grant by
+// hand, ownership is never given away this way
+func checkInvitableRole(requesterRole, role string) error {
+	if !projects.AssignableRole(role) {
+		return ErrInvalidRole
+	}
+	if !projects.CanManage(requesterRole, role) {
+		return ErrNotProjectManager
+	}
+	return nil
+}
+
+// organizationToJoin is the organization of the project, which the user
+// joins along with it, an invitation from inside it is enough to get in.
+// 0 when organizations aren't in use.
+func organizationToJoin(ctx context.Context, orgs Organizations, repo projects.Repository, projectID int64) (int64, error) {
+	if orgs == nil {
+		return 0, nil
+	}
+	project, err := repo.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return 0, ErrProjectNotFound
+	}
+	return project.OrganizationID, nil
+}
+
+func isMember(ctx context.Context, repo projects.Repository, projectID, userID int64) (bool, error) {
+	members, err := repo.ListUsersInProject(ctx, projectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list members: %w", err)
+	}
+	for _, m := range members {
+		if m.ID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// announceJoin sends the same USER_ADDED event as adding a member by hand
+func announceJoin(hub *ws.Hub, projectID, userID int64, role string) {
+	if hub != nil {
+		notification := fmt.Sprintf("USER_ADDED:%d:%d:%s", projectID, userID, role)
+		hub.Broadcast <- []byte(notification)
+	}
+}
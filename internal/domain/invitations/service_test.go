@@ -0,0 +1,180 @@
+package invitations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+var inviteLink = regexp.MustCompile(`invite=(\S+)`)
+
+func TestInvitationService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*Service, *FakeRepository, *projects.FakeRepository, *user.FakeRepository, *bytes.Buffer) {
+		users := user.NewFakeRepository()
+		users.Create(ctx, user.User{Email: "owner@example.com", Status: user.StatusActive})
+
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+		projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+
+		repo := NewFakeRepository(projectRepo)
+		outbox := &bytes.Buffer{}
+		jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+		svc := NewService(repo, projectRepo, users, mail.NewLogMailer(outbox), jwtManager, nil, 24*time.Hour, "http://localhost/join?invite=")
+		return svc, repo, projectRepo, users, outbox
+	}
+
+	isMember := func(repo *projects.FakeRepository, userID int64) bool {
+		members, _ := repo.ListUsersInProject(ctx, 1)
+		for _, m := range members {
+			if m.ID == userID {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Only owners and maintainers can invite", func(t *testing.T) {
+		svc, _, _, _, _ := setup()
+
+		_, err := svc.Invite(ctx, 2, 1, "new@example.com", "member")
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+		_, err = svc.Invite(ctx, 1, 1, "not an address", "member")
+		assert.ErrorIs(t, err, ErrInvalidEmail)
+		_, err = svc.Invite(ctx, 1, 1, "new@example.com", "owner")
+		assert.ErrorIs(t, err, ErrInvalidRole)
+		_, err = svc.Invite(ctx, 1, 1, "Owner@example.com", "member")
+		assert.ErrorIs(t, err, ErrAlreadyMember)
+	})
+
+	t.Run("An invitation converts when the address registers and logs in", func(t *testing.T) {
+		svc, _, projectRepo, users, outbox := setup()
+
+		inv, err := svc.Invite(ctx, 1, 1, " New@Example.com ", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "new@example.com", inv.Email)
+		assert.Equal(t, "member", inv.Role)
+		assert.Contains(t, outbox.String(), "Apollo")
+
+		_, err = svc.Invite(ctx, 1, 1, "new@example.com", "member")
+		assert.ErrorIs(t, err, ErrAlreadyInvited)
+
+		// registered but not verified yet: nothing happens
+		newcomer, _ := users.Create(ctx, user.User{Email: "new@example.com", Status: user.StatusPendingVerification})
+		joined, err := svc.AcceptPending(ctx, newcomer)
+		assert.NoError(t, err)
+		assert.Empty(t, joined)
+		assert.False(t, isMember(projectRepo, newcomer.ID))
+
+		newcomer.Status = user.StatusActive
+		joined, err = svc.AcceptPending(ctx, newcomer)
+		assert.NoError(t, err)
+		assert.Len(t, joined, 1)
+		assert.True(t, isMember(projectRepo, newcomer.ID))
+
+		// and it's gone from the list
+		pending, _ := svc.List(ctx, 1, 1)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("The link only works for the invited address", func(t *testing.T) {
+		svc, _, projectRepo, users, outbox := setup()
+		_, err := svc.Invite(ctx, 1, 1, "bob@example.com", "maintainer")
+		assert.NoError(t, err)
+		token := inviteLink.FindStringSubmatch(outbox.String())[1]
+
+		mallory, _ := users.Create(ctx, user.User{Email: "mallory@example.com", Status: user.StatusActive})
+		_, err = svc.Accept(ctx, token, mallory.ID)
+		assert.ErrorIs(t, err, ErrInvitationMismatch)
+		assert.False(t, isMember(projectRepo, mallory.ID))
+
+		bob, _ := users.Create(ctx, user.User{Email: "Bob@example.com", Status: user.StatusActive})
+		inv, err := svc.Accept(ctx, token, bob.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "maintainer", inv.Role)
+		assert.True(t, isMember(projectRepo, bob.ID))
+
+		// used up
+		_, err = svc.Accept(ctx, token, bob.ID)
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("Resend restarts the expiry, revoke kills the link", func(t *testing.T) {
+		svc, repo, projectRepo, users, outbox := setup()
+		inv, _ := svc.Invite(ctx, 1, 1, "carol@example.com", "member")
+		repo.Invitations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+		// expired invitations are still listed so the owner can resend them
+		pending, _ := svc.List(ctx, 1, 1)
+		assert.Len(t, pending, 1)
+
+		outbox.Reset()
+		renewed, err := svc.Resend(ctx, 1, 1, inv.ID)
+		assert.NoError(t, err)
+		assert.True(t, renewed.Pending(time.Now()))
+		token := inviteLink.FindStringSubmatch(outbox.String())[1]
+
+		_, err = svc.Resend(ctx, 2, 1, inv.ID)
+		assert.ErrorIs(t, err, ErrNotProjectManager)
+
+		assert.NoError(t, svc.Revoke(ctx, 1, 1, inv.ID))
+		assert.ErrorIs(t, svc.Revoke(ctx, 1, 1, inv.ID), ErrInvitationNotFound)
+
+		carol, _ := users.Create(ctx, user.User{Email: "carol@example.com", Status: user.StatusActive})
+		_, err = svc.Accept(ctx, token, carol.ID)
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+		joined, _ := svc.AcceptPending(ctx, carol)
+		assert.Empty(t, joined)
+		assert.False(t, isMember(projectRepo, carol.ID))
+	})
+
+	t.Run("An expired invitation can be replaced", func(t *testing.T) {
+		svc, repo, _, _, _ := setup()
+		svc.Invite(ctx, 1, 1, "dave@example.com", "member")
+		repo.Invitations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+		inv, err := svc.Invite(ctx, 1, 1, "dave@example.com", "maintainer")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), inv.ID)
+		assert.NotNil(t, repo.Invitations[0].RevokedAt)
+	})
+
+	t.Run("A failed insert leaves the invitation open", func(t *testing.T) {
+		users := user.NewFakeRepository()
+		users.Create(ctx, user.User{Email: "owner@example.com", Status: user.StatusActive})
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+		projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+
+		repo := NewFakeRepository(failingMembers{projectRepo})
+		svc := NewService(repo, projectRepo, users, mail.NewLogMailer(&bytes.Buffer{}), auth.NewJWTManager("test-secret", time.Hour), nil, time.Hour, "")
+		_, err := svc.Invite(ctx, 1, 1, "erin@example.com", "member")
+		assert.NoError(t, err)
+
+		erin, _ := users.Create(ctx, user.User{Email: "erin@example.com", Status: user.StatusActive})
+		_, err = svc.AcceptPending(ctx, erin)
+		assert.Error(t, err)
+		assert.False(t, isMember(projectRepo, erin.ID))
+		assert.True(t, repo.Invitations[0].Pending(time.Now()))
+	})
+}
+
+// failingMembers refuses every new membership, like a failing insert
+type failingMembers struct {
+	*projects.FakeRepository
+}
+
+func (failingMembers) AddUserToProject(ctx context.Context, projectID, userID int64, role string) error {
+	return errors.New("insert failed")
+}
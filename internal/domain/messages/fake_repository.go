@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 )
 
 type FakeRepository struct {
@@ -25,8 +27,8 @@ func (f *FakeRepository) Create(ctx context.Context, m Message) (*Message, error
 	m.ID = f.nextID
 	f.nextID++
 	m.CreatedAt = time.Now()
-	// In a real DB, the email comes from a join. In fake, we hardcode it for the UI.
-	m.SenderEmail = "test@example.com"
+	// In a real DB, the profile comes from a join. In fake, we hardcode it for the UI.
+	m.Sender = profiles.Summary{ID: m.SenderID, DisplayName: "test"}
 
 	f.messages = append(f.messages, m)
 	return &f.messages[len(f.messages)-1], nil
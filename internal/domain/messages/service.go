@@ -12,6 +12,7 @@ import (
 type Service struct {
 	repo        Repository
 	projectRepo projects.Repository
+	auth        *projects.Authorizer
 	hub         *ws.Hub
 }
 
@@ -19,42 +20,27 @@ func NewService(repo Repository, projectRepo projects.Repository, hub *ws.Hub) *
 	return &Service{
 		repo:        repo,
 		projectRepo: projectRepo,
+		auth:        projects.NewAuthorizer(projectRepo),
 		hub:         hub,
 	}
 }
 
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *Service) UseAuthorizer(a *projects.Authorizer) {
+	s.auth = a
+}
+
 type ChatService interface {
 	SendProjectMessage(ctx context.Context, senderID int64, projectID int64, content string) (*Message, error)
-	GetProjectHistory(ctx context.Context, projectID int64) ([]Message, error)
+	GetProjectHistory(ctx context.Context, requesterID, projectID int64) ([]Message, error)
 	SendDirectMessage(ctx context.Context, senderID, receiverID int64, content string) (*Message, error)
 	GetDMHistory(ctx context.Context, userA, userB int64) ([]Message, error)
 }
 
 func (s *Service) SendProjectMessage(ctx context.Context, senderID int64, projectID int64, content string) (*Message, error) {
-	// get Project details via the Project Interface
-	project, err := s.projectRepo.GetByID(ctx, projectID)
-	if err != nil {
-		return nil, fmt.Errorf("project not found: %w", err)
-	}
-
-	// get the member list via the Project Interface
-	members, err := s.projectRepo.ListUsersInProject(ctx, projectID)
-	if err != nil {
-		return nil, fmt.Errorf("could not fetch project members: %w", err)
-	}
-
-	// check if sender is in the member list
-	// ListUsersInProject includes the owner!
-	isAuthorized := false
-	for _, m := range members {
-		if m.ID == senderID {
-			isAuthorized = true
-			break
-		}
-	}
-
-	if !isAuthorized {
-		return nil, fmt.Errorf("unauthorized: user %d is not a member of project %s", senderID, project.Name)
+	// viewers read the chat but can't post
+	if err := s.auth.Authorize(ctx, senderID, projects.PermPostChat, projectID); err != nil {
+		return nil, err
 	}
 
 	// prepare the Message domain object
@@ -82,10 +68,19 @@ func (s *Service) SendProjectMessage(ctx context.Context, senderID int64, projec
 	return saved, nil
 }
 
-func (s *Service) GetProjectHistory(ctx context.Context, projectID int64) ([]Message, error) {
+func (s *Service) GetProjectHistory(ctx context.Context, requesterID, projectID int64) ([]Message, error) {
+	if err := s.auth.Authorize(ctx, requesterID, projects.PermReadChat, projectID); err != nil {
+		return nil, err
+	}
 	return s.repo.GetByProject(ctx, projectID)
 }
 
+// CanJoinProjectRoom is checked before a websocket joins the project's
+// room, the room carries the same messages as the history
+func (s *Service) CanJoinProjectRoom(ctx context.Context, userID, projectID int64) error {
+	return s.auth.Authorize(ctx, userID, projects.PermReadChat, projectID)
+}
+
 // SendDirectMessage checks for shared projects before saving and broadcasting
 func (s *Service) SendDirectMessage(ctx context.Context, senderID, receiverID int64, content string) (*Message, error) {
 	shared, err := s.projectRepo.UsersShareProject(ctx, senderID, receiverID)
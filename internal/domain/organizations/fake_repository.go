@@ -0,0 +1,189 @@
+package organizations
+
+import (
+	"context"
+	"time"
+)
+
+type memberEntry struct {
+	OrgID    int64
+	UserID   int64
+	Role     string
+	JoinedAt time.Time
+}
+
+// FakeRepository implements Repository in memory for tests
+type FakeRepository struct {
+	Organizations []Organization
+	members       []memberEntry
+	// ProjectOwners lists the owner of every project, by organization
+	ProjectOwners map[int64][]int64
+	// UserProjects lists the projects every user reaches, by user
+	UserProjects map[int64][]int64
+	Invitations  []Invitation
+	nextID       int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Organizations: []Organization{},
+		ProjectOwners: map[int64][]int64{},
+		UserProjects:  map[int64][]int64{},
+		nextID:        1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, name string, creatorID int64) (*Organization, error) {
+	org := Organization{ID: f.nextID, Name: name, CreatedBy: &creatorID, CreatedAt: time.Now()}
+	f.nextID++
+	f.Organizations = append(f.Organizations, org)
+	f.members = append(f.members, memberEntry{OrgID: org.ID, UserID: creatorID, Role: RoleAdmin, JoinedAt: time.Now()})
+	return &org, nil
+}
+
+func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*Organization, error) {
+	for _, o := range f.Organizations {
+		if o.ID == id {
+			c := o
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *FakeRepository) ListForUser(ctx context.Context, userID int64) ([]Organization, error) {
+	var res []Organization
+	for _, m := range f.members {
+		if m.UserID != userID {
+			continue
+		}
+		org, err := f.GetByID(ctx, m.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		org.Role = m.Role
+		res = append(res, *org)
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) ListMembers(ctx context.Context, orgID int64) ([]Member, error) {
+	var res []Member
+	for _, m := range f.members {
+		if m.OrgID == orgID {
+			res = append(res, Member{UserID: m.UserID, Role: m.Role, JoinedAt: m.JoinedAt})
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) MemberRole(ctx context.Context, orgID, userID int64) (string, error) {
+	for _, m := range f.members {
+		if m.OrgID == orgID && m.UserID == userID {
+			return m.Role, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *FakeRepository) AddMember(ctx context.Context, orgID, userID int64, role string) error {
+	if existing, _ := f.MemberRole(ctx, orgID, userID); existing != "" {
+		return nil
+	}
+	f.members = append(f.members, memberEntry{OrgID: orgID, UserID: userID, Role: role, JoinedAt: time.Now()})
+	return nil
+}
+
+func (f *FakeRepository) SetMemberRole(ctx context.Context, orgID, userID int64, role string) error {
+	for i, m := range f.members {
+		if m.OrgID == orgID && m.UserID == userID {
+			f.members[i].Role = role
+			return nil
+		}
+	}
+	return ErrMemberNotFound
+}
+
+func (f *FakeRepository) RemoveMember(ctx context.Context, orgID, userID int64) ([]int64, error) {
+	for i, m := range f.members {
+		if m.OrgID == orgID && m.UserID == userID {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			projectIDs := f.UserProjects[userID]
+			delete(f.UserProjects, userID)
+			return projectIDs, nil
+		}
+	}
+	return nil, ErrMemberNotFound
+}
+
+func (f *FakeRepository) CountAdmins(ctx context.Context, orgID int64) (int64, error) {
+	var n int64
+	for _, m := range f.members {
+		if m.OrgID == orgID && m.Role == RoleAdmin {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *FakeRepository) CountOwnedProjects(ctx context.Context, orgID, userID int64) (int64, error) {
+	var n int64
+	for _, owner := range f.ProjectOwners[orgID] {
+		if owner == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *FakeRepository) ShareOrganization(ctx context.Context, viewerID, userID int64) (bool, error) {
+	for _, a := range f.members {
+		if a.UserID != viewerID {
+			continue
+		}
+		if role, _ := f.MemberRole(ctx, a.OrgID, userID); role != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) CreateInvitation(ctx context.Context, inv Invitation) (*Invitation, error) {
+	for i, existing := range f.Invitations {
+		if existing.OrganizationID == inv.OrganizationID && existing.Email == inv.Email && existing.AcceptedAt == nil {
+			f.Invitations[i].Role = inv.Role
+			f.Invitations[i].InvitedBy = inv.InvitedBy
+			f.Invitations[i].ExpiresAt = inv.ExpiresAt
+			renewed := f.Invitations[i]
+			return &renewed, nil
+		}
+	}
+	inv.ID = int64(len(f.Invitations) + 1)
+	inv.CreatedAt = time.Now()
+	f.Invitations = append(f.Invitations, inv)
+	return &inv, nil
+}
+
+func (f *FakeRepository) GetInvitation(ctx context.Context, id int64) (*Invitation, error) {
+	for _, inv := range f.Invitations {
+		if inv.ID == id {
+			return &inv, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeRepository) AcceptInvitation(ctx context.Context, id, userID int64) (bool, error) {
+	for i, inv := range f.Invitations {
+		if inv.ID != id || !inv.Pending(time.Now()) {
+			continue
+		}
+		if err := f.AddMember(ctx, inv.OrganizationID, userID, inv.Role); err != nil {
+			return false, err
+		}
+		now := time.Now()
+		f.Invitations[i].AcceptedAt = &now
+		return true, nil
+	}
+	return false, nil
+}
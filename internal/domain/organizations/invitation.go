@@ -0,0 +1,138 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+)
+
+const actionAcceptOrganizationInvitation = "accept_organization_invitation"
+
+var (
+	ErrInvalidEmail           = errors.New("a valid email address is required")
+	ErrInvalidInvitation      = errors.New("invalid or expired invitation")
+	ErrInvitationMismatch     = errors.New("this invitation was sent to a different email address")
+	ErrAccountNotActivated    = errors.New("verify your email address before accepting invitations")
+	ErrInvitationsUnavailable = errors.New("organization invitations aren't set up")
+)
+
+// UseInvitations lets admins invite people by mail. Whoever accepts the
+// link (signed in with the invited address) joins the organization.
+func (s *Service) UseInvitations(mailer mail.Mailer, jwt *auth.JWTManager, ttl time.Duration, acceptURL string) {
+	s.mailer = mailer
+	s.jwt = jwt
+	s.ttl = ttl
+	s.acceptURL = acceptURL
+}
+
+// Invite mails an invitation to the address. The answer is the same
+// whether or not an account has that address, so admins can't use it to
+// find out who is registered, and nobody joins without accepting.
+func (s *Service) Invite(ctx context.Context, requesterID, orgID int64, email, role string) error {
+	if role == "" {
+		role = RoleMember
+	}
+	if !validRole(role) {
+		return ErrInvalidRole
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	if _, err := s.requireRole(ctx, orgID, requesterID, RoleAdmin); err != nil {
+		return err
+	}
+	org, err := s.repo.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if s.mailer == nil || s.jwt == nil {
+		return ErrInvitationsUnavailable
+	}
+
+	inv, err := s.repo.CreateInvitation(ctx, Invitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      requesterID,
+		ExpiresAt:      time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	// a failed mail is only logged, inviting again resends it
+	if err := s.sendInvitation(ctx, inv, org); err != nil {
+		log.Printf("failed to mail organization invitation %d: %v", inv.ID, err)
+	}
+	return nil
+}
+
+// AcceptInvitation redeems the link from the mail for a signed-in user,
+// it only works for the address it was sent to
+func (s *Service) AcceptInvitation(ctx context.Context, token string, userID int64) (*Organization, error) {
+	if s.jwt == nil {
+		return nil, ErrInvalidInvitation
+	}
+	claims, err := s.jwt.VerifyActionToken(actionAcceptOrganizationInvitation, token)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	inv, err := s.repo.GetInvitation(ctx, claims.UserID)
+	if err != nil || inv == nil || !inv.Pending(time.Now()) || !strings.EqualFold(inv.Email, claims.Subject) {
+		return nil, ErrInvalidInvitation
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, ErrInvalidInvitation
+	}
+	if u.Status != user.StatusActive {
+		return nil, ErrAccountNotActivated
+	}
+	if !strings.EqualFold(strings.TrimSpace(u.Email), inv.Email) {
+		return nil, ErrInvitationMismatch
+	}
+
+	ok, err := s.repo.AcceptInvitation(ctx, inv.ID, u.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if !ok {
+		return nil, ErrInvalidInvitation
+	}
+
+	org, err := s.repo.GetByID(ctx, inv.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	org.Role, err = s.repo.MemberRole(ctx, org.ID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *Service) sendInvitation(ctx context.Context, inv *Invitation, org *Organization) error {
+	token, err := s.jwt.GenerateActionToken(actionAcceptOrganizationInvitation, inv.ID, inv.Email, time.Until(inv.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      inv.Email,
+		Subject: fmt.Sprintf("You're invited to join %s on Playingfield", org.Name),
+		Body: fmt.Sprintf("You've been invited to join the organization %q on Playingfield.\n\n"+
+			"Open this link to accept, you can create an account on the way if you don't have one:\n%s%s\n\n"+
+			"The invitation expires on %s.", org.Name, s.acceptURL, token, inv.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST")),
+	})
+}
@@ -0,0 +1,73 @@
+package organizations
+
+import (
+	"context"
+	"time"
+)
+
+// Organization roles, admins manage the members
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Organization owns projects, people only see others in their organizations
+type Organization struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy *int64    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Role      string    `json:"role,omitempty"` // the requester's, when listing their organizations
+}
+
+type Member struct {
+	UserID   int64     `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Invitation is how people get into an organization, it goes to an
+// address and only the account with that address can accept it
+type Invitation struct {
+	ID             int64      `json:"id"`
+	OrganizationID int64      `json:"organization_id"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	InvitedBy      int64      `json:"invited_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+}
+
+// Pending is true while the invitation can still be accepted
+func (i *Invitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
+
+type Repository interface {
+	// Create makes the organization with its creator as the first admin
+	Create(ctx context.Context, name string, creatorID int64) (*Organization, error)
+	GetByID(ctx context.Context, id int64) (*Organization, error)
+	ListForUser(ctx context.Context, userID int64) ([]Organization, error)
+	ListMembers(ctx context.Context, orgID int64) ([]Member, error)
+	// MemberRole returns "" for someone who isn't a member
+	MemberRole(ctx context.Context, orgID, userID int64) (string, error)
+	// AddMember does nothing for someone who already is a member
+	AddMember(ctx context.Context, orgID, userID int64, role string) error
+	SetMemberRole(ctx context.Context, orgID, userID int64, role string) error
+	// RemoveMember takes the user out of the organization's projects and teams as well,
+	// and returns the projects they could reach before
+	RemoveMember(ctx context.Context, orgID, userID int64) ([]int64, error)
+	CountAdmins(ctx context.Context, orgID int64) (int64, error)
+	CountOwnedProjects(ctx context.Context, orgID, userID int64) (int64, error)
+	ShareOrganization(ctx context.Context, viewerID, userID int64) (bool, error)
+
+	// CreateInvitation renews the open invitation of the address if there is one
+	CreateInvitation(ctx context.Context, inv Invitation) (*Invitation, error)
+	// GetInvitation returns nil if there is no such invitation
+	GetInvitation(ctx context.Context, id int64) (*Invitation, error)
+	// AcceptInvitation closes the invitation and adds the member in one
+	// transaction, false if it was accepted or expired in the meantime
+	AcceptInvitation(ctx context.Context, id, userID int64) (bool, error)
+}
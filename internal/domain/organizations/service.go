@@ -0,0 +1,229 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+var (
+	ErrNotFound           = errors.New("organization not found")
+	ErrNotMember          = errors.New("you are not a member of this organization")
+	ErrNotAdmin           = errors.New("only organization admins can manage members")
+	ErrInvalidName        = errors.New("organization name is required")
+	ErrInvalidRole        = errors.New("role must be admin or member")
+	ErrMemberNotFound     = errors.New("user is not a member of this organization")
+	ErrLastAdmin          = errors.New("an organization needs at least one admin, promote someone else first")
+	ErrOwnsProjects       = errors.New("the user still owns projects in this organization, transfer them first")
+	ErrNoOrganization     = errors.New("create or join an organization first")
+	ErrChooseOrganization = errors.New("you are in several organizations, pick one with organization_id")
+)
+
+// Service manages organizations and answers who can see whom. Projects
+// ask it whether people belong to a project's organization.
+type Service struct {
+	repo   Repository
+	users  user.Repository
+	policy *projects.Authorizer
+	hub    *ws.Hub
+
+	// invitations, see UseInvitations
+	mailer    mail.Mailer
+	jwt       *auth.JWTManager
+	ttl       time.Duration
+	acceptURL string
+}
+
+func NewService(repo Repository, users user.Repository) *Service {
+	return &Service{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// UseAuthorizer shares the project policy, so removed members lose their
+// cached project access right away
+func (s *Service) UseAuthorizer(a *projects.Authorizer) {
+	s.policy = a
+}
+
+// UseHub lets the service close the sockets of removed members, which
+// would otherwise stay in their old project rooms
+func (s *Service) UseHub(h *ws.Hub) {
+	s.hub = h
+}
+
+// Create makes a new organization with the creator as its admin
+func (s *Service) Create(ctx context.Context, creatorID int64, name string) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	org, err := s.repo.Create(ctx, name, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	org.Role = RoleAdmin
+	return org, nil
+}
+
+func (s *Service) ListMine(ctx context.Context, userID int64) ([]Organization, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+func (s *Service) ListMembers(ctx context.Context, requesterID, orgID int64) ([]Member, error) {
+	if _, err := s.requireRole(ctx, orgID, requesterID, RoleMember); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, orgID)
+}
+
+func (s *Service) ChangeRole(ctx context.Context, requesterID, orgID, userID int64, role string) error {
+	if !validRole(role) {
+		return ErrInvalidRole
+	}
+	if _, err := s.requireRole(ctx, orgID, requesterID, RoleAdmin); err != nil {
+		return err
+	}
+
+	current, err := s.repo.MemberRole(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if current == RoleAdmin && role != RoleAdmin {
+		if err := s.keepAnAdmin(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetMemberRole(ctx, orgID, userID, role)
+}
+
+// RemoveMember takes someone out of the organization and its projects.
+// Admins remove anyone, everyone else can only leave.
+func (s *Service) RemoveMember(ctx context.Context, requesterID, orgID, userID int64) error {
+	need := RoleAdmin
+	if requesterID == userID {
+		need = RoleMember
+	}
+	if _, err := s.requireRole(ctx, orgID, requesterID, need); err != nil {
+		return err
+	}
+
+	current, err := s.repo.MemberRole(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if current == RoleAdmin {
+		if err := s.keepAnAdmin(ctx, orgID); err != nil {
+			return err
+		}
+	}
+
+	owned, err := s.repo.CountOwnedProjects(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if owned > 0 {
+		return ErrOwnsProjects
+	}
+	projectIDs, err := s.repo.RemoveMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if s.policy != nil {
+		for _, id := range projectIDs {
+			s.policy.Forget(id)
+		}
+	}
+	if s.hub != nil {
+		s.hub.DisconnectUser(userID, "removed from organization")
+	}
+	return nil
+}
+
+// Join adds the user as a plain member unless they already are one, for
+// people who accepted an invitation to one of the organization's projects
+func (s *Service) Join(ctx context.Context, orgID, userID int64) error {
+	return s.repo.AddMember(ctx, orgID, userID, RoleMember)
+}
+
+func (s *Service) IsMember(ctx context.Context, orgID, userID int64) (bool, error) {
+	role, err := s.repo.MemberRole(ctx, orgID, userID)
+	return role != "", err
+}
+
+func (s *Service) IsAdmin(ctx context.Context, orgID, userID int64) (bool, error) {
+	role, err := s.repo.MemberRole(ctx, orgID, userID)
+	return role == RoleAdmin, err
+}
+
+// DefaultOrganization is where a project goes when the creator didn't
+// say, which only works for people in exactly one organization
+func (s *Service) DefaultOrganization(ctx context.Context, userID int64) (int64, error) {
+	orgs, err := s.repo.ListForUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	switch len(orgs) {
+	case 0:
+		return 0, ErrNoOrganization
+	case 1:
+		return orgs[0].ID, nil
+	}
+	return 0, ErrChooseOrganization
+}
+
+// CanSee reports whether the viewer may look the user up, which takes
+// sharing an organization
+func (s *Service) CanSee(ctx context.Context, viewerID, userID int64) (bool, error) {
+	if viewerID == userID {
+		return true, nil
+	}
+	return s.repo.ShareOrganization(ctx, viewerID, userID)
+}
+
+// requireRole returns the requester's role if it is at least need
+func (s *Service) requireRole(ctx context.Context, orgID, userID int64, need string) (string, error) {
+	role, err := s.repo.MemberRole(ctx, orgID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		if _, err := s.repo.GetByID(ctx, orgID); err != nil {
+			return "", ErrNotFound
+		}
+		return "", ErrNotMember
+	}
+	if need == RoleAdmin && role != RoleAdmin {
+		return role, ErrNotAdmin
+	}
+	return role, nil
+}
+
+func (s *Service) keepAnAdmin(ctx context.Context, orgID int64) error {
+	admins, err := s.repo.CountAdmins(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func validRole(role string) bool {
+	return role == RoleAdmin || role == RoleMember
+}
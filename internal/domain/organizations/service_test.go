@@ -0,0 +1,147 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+var invitationLink = regexp.MustCompile(`token=(\S+)`)
+
+func TestOrganizations(t *testing.T) {
+	ctx := context.Background()
+
+	// alice (1) runs Acme with bob (2) in it, carol (3) is on her own
+	var outbox *bytes.Buffer
+	setup := func() (*Service, *FakeRepository, *Organization) {
+		users := user.NewFakeRepository()
+		users.Create(ctx, user.User{Email: "alice@example.com", Status: user.StatusActive})
+		users.Create(ctx, user.User{Email: "bob@example.com", Status: user.StatusActive})
+		users.Create(ctx, user.User{Email: "carol@example.com", Status: user.StatusActive})
+
+		repo := NewFakeRepository()
+		svc := NewService(repo, users)
+		outbox = &bytes.Buffer{}
+		svc.UseInvitations(mail.NewLogMailer(outbox), auth.NewJWTManager("test", time.Hour), time.Hour, "http://localhost/accept?token=")
+		org, _ := svc.Create(ctx, 1, "Acme")
+		repo.AddMember(ctx, org.ID, 2, RoleMember)
+		return svc, repo, org
+	}
+
+	t.Run("The creator is the admin and people join by invitation", func(t *testing.T) {
+		svc, _, org := setup()
+		assert.Equal(t, RoleAdmin, org.Role)
+
+		members, err := svc.ListMembers(ctx, 2, org.ID)
+		assert.NoError(t, err)
+		assert.Len(t, members, 2)
+
+		assert.ErrorIs(t, svc.Invite(ctx, 2, org.ID, "carol@example.com", ""), ErrNotAdmin)
+		_, err = svc.ListMembers(ctx, 3, org.ID)
+		assert.ErrorIs(t, err, ErrNotMember)
+		_, err = svc.ListMembers(ctx, 3, 99)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// inviting doesn't add anyone, accepting does
+		assert.NoError(t, svc.Invite(ctx, 1, org.ID, "carol@example.com", ""))
+		isMember, _ := svc.IsMember(ctx, org.ID, 3)
+		assert.False(t, isMember)
+
+		token := invitationLink.FindStringSubmatch(outbox.String())[1]
+		_, err = svc.AcceptInvitation(ctx, token, 2)
+		assert.ErrorIs(t, err, ErrInvitationMismatch)
+
+		joined, err := svc.AcceptInvitation(ctx, token, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleMember, joined.Role)
+		isMember, _ = svc.IsMember(ctx, org.ID, 3)
+		assert.True(t, isMember)
+
+		_, err = svc.AcceptInvitation(ctx, token, 3)
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("Inviting an unknown address looks like inviting a known one", func(t *testing.T) {
+		svc, _, org := setup()
+
+		assert.NoError(t, svc.Invite(ctx, 1, org.ID, "nobody@example.com", ""))
+		assert.NoError(t, svc.Invite(ctx, 1, org.ID, "carol@example.com", ""))
+		assert.ErrorIs(t, svc.Invite(ctx, 1, org.ID, "not an address", ""), ErrInvalidEmail)
+	})
+
+	t.Run("Only people sharing an organization see each other", func(t *testing.T) {
+		svc, _, _ := setup()
+
+		seen, _ := svc.CanSee(ctx, 1, 2)
+		assert.True(t, seen)
+		seen, _ = svc.CanSee(ctx, 1, 3)
+		assert.False(t, seen)
+		seen, _ = svc.CanSee(ctx, 3, 3)
+		assert.True(t, seen)
+	})
+
+	t.Run("There is always an admin left", func(t *testing.T) {
+		svc, _, org := setup()
+
+		assert.ErrorIs(t, svc.ChangeRole(ctx, 1, org.ID, 1, RoleMember), ErrLastAdmin)
+		assert.ErrorIs(t, svc.RemoveMember(ctx, 1, org.ID, 1), ErrLastAdmin)
+
+		assert.NoError(t, svc.ChangeRole(ctx, 1, org.ID, 2, RoleAdmin))
+		assert.NoError(t, svc.RemoveMember(ctx, 1, org.ID, 1))
+		isMember, _ := svc.IsMember(ctx, org.ID, 1)
+		assert.False(t, isMember)
+	})
+
+	t.Run("Members can leave, but not while they own projects", func(t *testing.T) {
+		svc, repo, org := setup()
+		repo.ProjectOwners[org.ID] = []int64{2}
+
+		assert.ErrorIs(t, svc.RemoveMember(ctx, 2, org.ID, 1), ErrNotAdmin)
+		assert.ErrorIs(t, svc.RemoveMember(ctx, 2, org.ID, 2), ErrOwnsProjects)
+
+		repo.ProjectOwners[org.ID] = nil
+		assert.NoError(t, svc.RemoveMember(ctx, 2, org.ID, 2))
+	})
+
+	t.Run("Removed members lose their cached project access", func(t *testing.T) {
+		svc, repo, org := setup()
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: org.ID})
+		projectRepo.AddUserToProject(ctx, 1, 2, projects.RoleMember)
+		policy := projects.NewCachedAuthorizer(projectRepo, time.Hour)
+		svc.UseAuthorizer(policy)
+		repo.UserProjects[2] = []int64{1}
+
+		role, _ := policy.Role(ctx, 1, 2)
+		assert.Equal(t, projects.RoleMember, role)
+
+		assert.NoError(t, svc.RemoveMember(ctx, 1, org.ID, 2))
+		// the real repository drops the project membership in the same transaction
+		projectRepo.RemoveUserFromProject(ctx, 1, 2)
+		role, _ = policy.Role(ctx, 1, 2)
+		assert.Empty(t, role)
+	})
+
+	t.Run("The default organization only exists for people in exactly one", func(t *testing.T) {
+		svc, _, org := setup()
+
+		id, err := svc.DefaultOrganization(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, org.ID, id)
+
+		_, err = svc.DefaultOrganization(ctx, 3)
+		assert.ErrorIs(t, err, ErrNoOrganization)
+
+		svc.Create(ctx, 2, "Side project")
+		_, err = svc.DefaultOrganization(ctx, 2)
+		assert.ErrorIs(t, err, ErrChooseOrganization)
+	})
+}
@@ -0,0 +1,79 @@
+package pats
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FakeRepository implements Repository for testing without a real DB
+type FakeRepository struct {
+	Tokens []Token
+	nextID int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Tokens: []Token{},
+		nextID: 1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, t Token) (*Token, error) {
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+	f.Tokens = append(f.Tokens, t)
+	return &t, nil
+}
+
+func (f *FakeRepository) GetByHash(ctx context.Context, tokenHash string) (*Token, error) {
+	for _, t := range f.Tokens {
+		if t.TokenHash == tokenHash {
+			c := t
+			return &c, nil
+		}
+	}
+	return nil, errors.New("personal access token not found")
+}
+
+func (f *FakeRepository) ListByUser(ctx context.Context, userID int64) ([]Token, error) {
+	var list []Token
+	for _, t := range f.Tokens {
+		if t.UserID == userID {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+func (f *FakeRepository) Revoke(ctx context.Context, id, userID int64) (bool, error) {
+	for i := range f.Tokens {
+		if f.Tokens[i].ID == id && f.Tokens[i].UserID == userID && f.Tokens[i].RevokedAt == nil {
+			now := time.Now()
+			f.Tokens[i].RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) RevokeAll(ctx context.Context, userID int64) error {
+	now := time.Now()
+	for i := range f.Tokens {
+		if f.Tokens[i].UserID == userID && f.Tokens[i].RevokedAt == nil {
+			f.Tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *FakeRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	for i := range f.Tokens {
+		if f.Tokens[i].ID == id {
+			now := time.Now()
+			f.Tokens[i].LastUsedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package pats
+
+import (
+	"context"
+	"time"
+)
+
+// Scopes a personal access token can be limited to
+const (
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+	ScopeTasksRead     = "tasks:read"
+	ScopeTasksWrite    = "tasks:write"
+	ScopeChatRead      = "chat:read"
+	ScopeChatWrite     = "chat:write"
+)
+
+var knownScopes = map[string]bool{
+	ScopeProjectsRead:  true,
+	ScopeProjectsWrite: true,
+	ScopeTasksRead:     true,
+	ScopeTasksWrite:    true,
+	ScopeChatRead:      true,
+	ScopeChatWrite:     true,
+}
+
+// Token is a long-lived credential for scripts, only its hash is stored
+type Token struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"` // first characters, so users can tell tokens apart
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil never expires
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type Repository interface {
+	Create(ctx context.Context, t Token) (*Token, error)
+	GetByHash(ctx context.Context, tokenHash string) (*Token, error)
+	ListByUser(ctx context.Context, userID int64) ([]Token, error)
+	// Revoke returns false if the token doesn't exist, isn't the user's or is already revoked
+	Revoke(ctx context.Context, id, userID int64) (bool, error)
+	RevokeAll(ctx context.Context, userID int64) error
+	TouchLastUsed(ctx context.Context, id int64) error
+}
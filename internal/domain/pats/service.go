@@ -0,0 +1,110 @@
+package pats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid or expired personal access token")
+	ErrTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidScope  = errors.New("unknown scope")
+	ErrNoScopes      = errors.New("at least one scope is required")
+	ErrInvalidName   = errors.New("token name is required")
+	ErrInvalidExpiry = errors.New("expiry must be in the future")
+)
+
+// last_used_at is only written this often, not on every request
+const touchInterval = time.Minute
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Create issues a new token. The raw value is returned once and never stored.
+func (s *Service) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (string, *Token, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, ErrInvalidName
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, ErrInvalidExpiry
+	}
+
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	raw := auth.PATPrefix + secret
+
+	t, err := s.repo.Create(ctx, Token{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: auth.HashToken(raw),
+		Prefix:    raw[:len(auth.PATPrefix)+6],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store personal access token: %w", err)
+	}
+	return raw, t, nil
+}
+
+func (s *Service) List(ctx context.Context, userID int64) ([]Token, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *Service) Revoke(ctx context.Context, userID, id int64) error {
+	ok, err := s.repo.Revoke(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// RevokeAll ends every token of the user, for when the account itself may be
+// compromised: a password reset or logging out everywhere
+func (s *Service) RevokeAll(ctx context.Context, userID int64) error {
+	return s.repo.RevokeAll(ctx, userID)
+}
+
+// Authenticate resolves a raw bearer token, called on every request that uses one
+func (s *Service) Authenticate(ctx context.Context, raw string) (*Token, error) {
+	t, err := s.repo.GetByHash(ctx, auth.HashToken(raw))
+	if err != nil || t == nil || t.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+	if t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > touchInterval {
+		// best effort, a failed write shouldn't fail the request
+		if err := s.repo.TouchLastUsed(ctx, t.ID); err == nil {
+			now := time.Now()
+			t.LastUsedAt = &now
+		}
+	}
+	return t, nil
+}
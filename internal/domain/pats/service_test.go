@@ -0,0 +1,70 @@
+package pats
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalAccessTokenService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Create returns the raw token once and stores only its hash", func(t *testing.T) {
+		repo := NewFakeRepository()
+		svc := NewService(repo)
+
+		raw, token, err := svc.Create(ctx, 1, "ci", []string{ScopeTasksRead}, nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw, "pf_pat_"))
+		assert.True(t, strings.HasPrefix(raw, token.Prefix))
+		assert.NotEqual(t, raw, repo.Tokens[0].TokenHash)
+
+		found, err := svc.Authenticate(ctx, raw)
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+		assert.NotNil(t, found.LastUsedAt)
+	})
+
+	t.Run("Create validates name, scopes and expiry", func(t *testing.T) {
+		svc := NewService(NewFakeRepository())
+		past := time.Now().Add(-time.Hour)
+
+		_, _, err := svc.Create(ctx, 1, " ", []string{ScopeTasksRead}, nil)
+		assert.ErrorIs(t, err, ErrInvalidName)
+		_, _, err = svc.Create(ctx, 1, "ci", nil, nil)
+		assert.ErrorIs(t, err, ErrNoScopes)
+		_, _, err = svc.Create(ctx, 1, "ci", []string{"admin"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidScope)
+		_, _, err = svc.Create(ctx, 1, "ci", []string{ScopeTasksRead}, &past)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+	})
+
+	t.Run("Revoked and expired tokens stop working", func(t *testing.T) {
+		repo := NewFakeRepository()
+		svc := NewService(repo)
+
+		raw, token, _ := svc.Create(ctx, 1, "ci", []string{ScopeTasksRead}, nil)
+		assert.NoError(t, svc.Revoke(ctx, 1, token.ID))
+		_, err := svc.Authenticate(ctx, raw)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		soon := time.Now().Add(time.Hour)
+		raw, _, _ = svc.Create(ctx, 1, "short", []string{ScopeTasksRead}, &soon)
+		expired := time.Now().Add(-time.Minute)
+		repo.Tokens[1].ExpiresAt = &expired
+		_, err = svc.Authenticate(ctx, raw)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Users can only revoke their own tokens", func(t *testing.T) {
+		svc := NewService(NewFakeRepository())
+
+		_, token, _ := svc.Create(ctx, 1, "ci", []string{ScopeTasksRead}, nil)
+		assert.ErrorIs(t, svc.Revoke(ctx, 2, token.ID), ErrTokenNotFound)
+		assert.NoError(t, svc.Revoke(ctx, 1, token.ID))
+		assert.ErrorIs(t, svc.Revoke(ctx, 1, token.ID), ErrTokenNotFound)
+	})
+}
@@ -0,0 +1,60 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/nelfander/Playingfield/internal/domain/user"
+)
+
+// OwnedProject is what the fake knows about a project the user owns
+type OwnedProject struct {
+	ID          int64
+	SuccessorID int64 // 0 when nobody else is in the project
+}
+
+// FakeRepository implements Repository for testing without a real DB.
+// Anonymize writes through to Users, like the users table.
+type FakeRepository struct {
+	Users      *user.FakeRepository
+	Data       map[int64]*Export // user id -> what PersonalData returns
+	Owned      map[int64][]OwnedProject
+	Anonymized []Anonymization
+}
+
+func NewFakeRepository(users *user.FakeRepository) *FakeRepository {
+	return &FakeRepository{
+		Users: users,
+		Data:  map[int64]*Export{},
+		Owned: map[int64][]OwnedProject{},
+	}
+}
+
+func (f *FakeRepository) PersonalData(ctx context.Context, userID int64) (*Export, error) {
+	if data, ok := f.Data[userID]; ok {
+		c := *data
+		return &c, nil
+	}
+	return &Export{}, nil
+}
+
+func (f *FakeRepository) Anonymize(ctx context.Context, a Anonymization) (*Erasure, error) {
+	erasure := &Erasure{TransferredProjects: map[int64]int64{}}
+	for _, p := range f.Owned[a.UserID] {
+		if p.SuccessorID == 0 {
+			erasure.DeletedProjects = append(erasure.DeletedProjects, p.ID)
+		} else {
+			erasure.TransferredProjects[p.ID] = p.SuccessorID
+		}
+	}
+	delete(f.Owned, a.UserID)
+
+	for i := range f.Users.Users {
+		if f.Users.Users[i].ID == a.UserID {
+			f.Users.Users[i].Email = a.Email
+			f.Users.Users[i].PasswordHash = a.PasswordHash
+			f.Users.Users[i].Status = user.StatusDeleted
+		}
+	}
+	f.Anonymized = append(f.Anonymized, a)
+	return erasure, nil
+}
@@ -0,0 +1,48 @@
+package privacy
+
+import (
+	"context"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/tasks"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+)
+
+// Export is everything we hold about a user, as handed out by GET /me/export
+type Export struct {
+	ExportedAt    time.Time            `json:"exported_at"`
+	Account       user.User            `json:"account"`
+	Profile       *profiles.Profile    `json:"profile"`
+	OwnedProjects []projects.Project   `json:"owned_projects"`
+	AssignedTasks []tasks.Task         `json:"assigned_tasks"`
+	TaskActivity  []tasks.TaskActivity `json:"task_activity"`
+	Messages      []messages.Message   `json:"messages"` // project messages sent, direct messages sent and received
+}
+
+// Anonymization replaces the personal data of an account, the row itself
+// stays so messages and task history keep pointing somewhere
+type Anonymization struct {
+	UserID       int64
+	Email        string // a unique placeholder, frees the real address
+	PasswordHash string // never matches a password
+	DisplayName  string // what others now see on old messages
+}
+
+// Erasure reports what happened to the projects the user owned
+type Erasure struct {
+	TransferredProjects map[int64]int64 // project id -> new owner
+	DeletedProjects     []int64         // nobody else was in them, soft deleted
+}
+
+type Repository interface {
+	// PersonalData fills the owned projects, tasks, activity and messages of an export
+	PersonalData(ctx context.Context, userID int64) (*Export, error)
+	// Anonymize scrubs the account in one transaction: credentials, tokens,
+	// identities, profile, memberships and assignments. Shared projects go
+	// to a successor, a direct member or else one a team brings in. Projects
+	// nobody else is in are soft deleted and purged after the retention period.
+	Anonymize(ctx context.Context, a Anonymization) (*Erasure, error)
+}
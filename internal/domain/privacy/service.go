@@ -0,0 +1,170 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	// accounts with a password confirm with it
+	ErrConfirmationFailed = errors.New("confirmation does not match")
+	// accounts that only sign in through the identity provider have no
+	// password, they prove it's them by having just signed in
+	ErrRecentSignInRequired = errors.New("sign in again to delete this account")
+)
+
+// recentSignIn is how old the login behind the request may be when an
+// account without a password is deleted
+const recentSignIn = 5 * time.Minute
+
+// DeletedDisplayName is what other users see on the history of a deleted account
+const DeletedDisplayName = "Deleted user"
+
+// Service is the self-service side of GDPR: take your data with you,
+// or have it removed
+type Service struct {
+	repo     Repository
+	users    user.Repository
+	profiles *profiles.Service
+	jwt      *auth.JWTManager
+	audit    *audit.Service
+	hub      *ws.Hub
+	sessions *tokens.Service
+}
+
+func NewService(repo Repository, users user.Repository, profiles *profiles.Service, jwt *auth.JWTManager, audit *audit.Service, hub *ws.Hub) *Service {
+	return &Service{
+		repo:     repo,
+		users:    users,
+		profiles: profiles,
+		jwt:      jwt,
+		audit:    audit,
+		hub:      hub,
+	}
+}
+
+// UseSessions lets accounts without a password be deleted, by checking when
+// the session making the request signed in
+func (s *Service) UseSessions(sessions *tokens.Service) {
+	s.sessions = sessions
+}
+
+// Export collects the user's account, profile, owned projects, assigned
+// tasks, task activity and messages
+func (s *Service) Export(ctx context.Context, userID int64) (*Export, error) {
+	u, err := s.getAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := s.repo.PersonalData(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect personal data: %w", err)
+	}
+	export.ExportedAt = time.Now().UTC()
+	export.Account = *u
+
+	if s.profiles != nil {
+		p, err := s.profiles.Get(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile: %w", err)
+		}
+		export.Profile = p
+	}
+
+	s.record(ctx, userID, "user.data_exported", "")
+	return export, nil
+}
+
+// DeleteAccount anonymizes the account after the user confirmed it. Their
+// messages and task history stay for everyone else, shown as DeletedDisplayName.
+// sessionID is the login session of the access token making the request.
+func (s *Service) DeleteAccount(ctx context.Context, userID, sessionID int64, password string) (*Erasure, error) {
+	u, err := s.getAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a stolen access token alone mustn't be enough to wipe an account, and
+	// it already carries the email, so that's no proof either
+	if auth.IsPasswordHash(u.PasswordHash) {
+		if !auth.CheckPasswordHash(password, u.PasswordHash) {
+			return nil, ErrConfirmationFailed
+		}
+	} else if !s.signedInRecently(ctx, userID, sessionID) {
+		return nil, ErrRecentSignInRequired
+	}
+
+	// a placeholder nobody knows the password for, like ForcePasswordReset
+	junk, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	erasure, err := s.repo.Anonymize(ctx, Anonymization{
+		UserID:       userID,
+		Email:        fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+		PasswordHash: "deleted:" + junk,
+		DisplayName:  DeletedDisplayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize account: %w", err)
+	}
+
+	// the account is gone at this point, what follows only cuts short what's
+	// still in flight, the status check rejects the rest anyway
+	if s.jwt != nil {
+		s.jwt.ForgetAccount(userID)
+		if err := s.jwt.RevokeUserTokens(ctx, userID); err != nil {
+			log.Printf("failed to revoke access tokens of deleted user %d: %v", userID, err)
+		}
+	}
+	if s.hub != nil {
+		s.hub.DisconnectUser(userID, "account deleted")
+	}
+
+	details := fmt.Sprintf("%d projects transferred, %d deleted", len(erasure.TransferredProjects), len(erasure.DeletedProjects))
+	s.record(ctx, userID, "user.deleted", details)
+	return erasure, nil
+}
+
+// signedInRecently is true when the session was started, not just refreshed,
+// within recentSignIn
+func (s *Service) signedInRecently(ctx context.Context, userID, sessionID int64) bool {
+	if s.sessions == nil || sessionID == 0 {
+		return false
+	}
+	session, err := s.sessions.Session(ctx, userID, sessionID)
+	if err != nil {
+		return false
+	}
+	return time.Since(session.CreatedAt) <= recentSignIn
+}
+
+func (s *Service) getAccount(ctx context.Context, userID int64) (*user.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil || u.Status == user.StatusDeleted {
+		return nil, ErrAccountNotFound
+	}
+	return u, nil
+}
+
+func (s *Service) record(ctx context.Context, userID int64, action, details string) {
+	if s.audit == nil {
+		return
+	}
+	if err := s.audit.Record(ctx, userID, action, "user", userID, details); err != nil {
+		log.Printf("failed to audit %s for user %d: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,105 @@
+package privacy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivacyService(t *testing.T) {
+	ctx := context.Background()
+
+	var sessions *tokens.FakeRepository
+	setup := func() (*Service, *FakeRepository, *user.FakeRepository, *audit.FakeRepository) {
+		users := user.NewFakeRepository()
+		hash, _ := auth.HashPassword("supersecret")
+		users.Create(ctx, user.User{Email: "alice@example.com", PasswordHash: hash, Status: user.StatusActive})
+		users.Create(ctx, user.User{Email: "sso@example.com", PasswordHash: "oidc:placeholder", Status: user.StatusActive})
+
+		profileRepo := profiles.NewFakeRepository()
+		profileRepo.Users[1] = "alice@example.com"
+		profileRepo.Users[2] = "sso@example.com"
+
+		repo := NewFakeRepository(users)
+		auditRepo := audit.NewFakeRepository()
+		svc := NewService(repo, users, profiles.NewService(profileRepo), nil, audit.NewService(auditRepo), nil)
+		sessions = tokens.NewFakeRepository()
+		svc.UseSessions(tokens.NewService(sessions, time.Hour))
+		return svc, repo, users, auditRepo
+	}
+
+	t.Run("Export bundles the account, profile and personal data", func(t *testing.T) {
+		svc, repo, _, auditRepo := setup()
+		repo.Data[1] = &Export{Messages: []messages.Message{{ID: 7, SenderID: 1, Content: "hi"}}}
+
+		export, err := svc.Export(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "alice@example.com", export.Account.Email)
+		assert.NotNil(t, export.Profile)
+		assert.Len(t, export.Messages, 1)
+		assert.False(t, export.ExportedAt.IsZero())
+		assert.Equal(t, "user.data_exported", auditRepo.Entries[0].Action)
+	})
+
+	t.Run("Deleting needs the password", func(t *testing.T) {
+		svc, repo, _, _ := setup()
+
+		_, err := svc.DeleteAccount(ctx, 1, 0, "wrong")
+		assert.ErrorIs(t, err, ErrConfirmationFailed)
+		assert.Empty(t, repo.Anonymized)
+	})
+
+	t.Run("Accounts without a password need a recent sign-in", func(t *testing.T) {
+		svc, _, users, _ := setup()
+		old, _ := sessions.CreateSession(ctx, tokens.Session{UserID: 2})
+		sessions.Sessions[0].CreatedAt = time.Now().Add(-time.Hour)
+		fresh, _ := sessions.CreateSession(ctx, tokens.Session{UserID: 2})
+		someoneElses, _ := sessions.CreateSession(ctx, tokens.Session{UserID: 1})
+
+		_, err := svc.DeleteAccount(ctx, 2, 0, "")
+		assert.ErrorIs(t, err, ErrRecentSignInRequired)
+		_, err = svc.DeleteAccount(ctx, 2, old.ID, "")
+		assert.ErrorIs(t, err, ErrRecentSignInRequired)
+		_, err = svc.DeleteAccount(ctx, 2, someoneElses.ID, "")
+		assert.ErrorIs(t, err, ErrRecentSignInRequired)
+
+		_, err = svc.DeleteAccount(ctx, 2, fresh.ID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, user.StatusDeleted, users.Users[1].Status)
+	})
+
+	t.Run("Deleting anonymizes the account and hands over shared projects", func(t *testing.T) {
+		svc, repo, users, auditRepo := setup()
+		repo.Owned[1] = []OwnedProject{{ID: 10, SuccessorID: 3}, {ID: 11}}
+
+		erasure, err := svc.DeleteAccount(ctx, 1, 0, "supersecret")
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64]int64{10: 3}, erasure.TransferredProjects)
+		assert.Equal(t, []int64{11}, erasure.DeletedProjects)
+
+		u := users.Users[0]
+		assert.Equal(t, user.StatusDeleted, u.Status)
+		assert.Equal(t, "deleted-1@deleted.invalid", u.Email)
+		assert.False(t, auth.CheckPasswordHash("supersecret", u.PasswordHash))
+		assert.Equal(t, DeletedDisplayName, repo.Anonymized[0].DisplayName)
+		assert.Equal(t, "user.deleted", auditRepo.Entries[len(auditRepo.Entries)-1].Action)
+
+		// the real address is free again
+		_, err = users.Create(ctx, user.User{Email: "alice@example.com"})
+		assert.NoError(t, err)
+
+		// and there's nothing left to export or delete
+		_, err = svc.Export(ctx, 1)
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+		_, err = svc.DeleteAccount(ctx, 1, 0, "supersecret")
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+}
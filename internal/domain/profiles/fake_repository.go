@@ -0,0 +1,57 @@
+package profiles
+
+import (
+	"context"
+	"time"
+)
+
+// FakeRepository implements Repository for testing without a real DB.
+// Users has to contain an id for Get to find it, like the users table.
+type FakeRepository struct {
+	Users    map[int64]string // id -> email
+	Profiles map[int64]Profile
+	Avatars  map[int64]Avatar
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Users:    map[int64]string{},
+		Profiles: map[int64]Profile{},
+		Avatars:  map[int64]Avatar{},
+	}
+}
+
+func (f *FakeRepository) Get(ctx context.Context, userID int64) (*Profile, error) {
+	if p, ok := f.Profiles[userID]; ok {
+		return &p, nil
+	}
+	if _, ok := f.Users[userID]; !ok {
+		return nil, ErrProfileNotFound
+	}
+	return &Profile{UserID: userID, Timezone: "UTC", Locale: "en"}, nil
+}
+
+func (f *FakeRepository) Upsert(ctx context.Context, p Profile) (*Profile, error) {
+	p.UpdatedAt = time.Now()
+	f.Profiles[p.UserID] = p
+	return &p, nil
+}
+
+func (f *FakeRepository) SaveAvatar(ctx context.Context, a Avatar) error {
+	a.UpdatedAt = time.Now()
+	f.Avatars[a.UserID] = a
+	return nil
+}
+
+func (f *FakeRepository) GetAvatar(ctx context.Context, userID int64) (*Avatar, error) {
+	a, ok := f.Avatars[userID]
+	if !ok {
+		return nil, ErrAvatarNotFound
+	}
+	return &a, nil
+}
+
+func (f *FakeRepository) DeleteAvatar(ctx context.Context, userID int64) error {
+	delete(f.Avatars, userID)
+	return nil
+}
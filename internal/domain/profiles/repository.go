@@ -0,0 +1,43 @@
+package profiles
+
+import (
+	"context"
+	"time"
+)
+
+// Profile is what other users see about someone, never the email
+type Profile struct {
+	UserID      int64     `json:"user_id"`
+	DisplayName string    `json:"display_name"` // empty until the user picks one
+	AvatarURL   string    `json:"avatar_url"`
+	Timezone    string    `json:"timezone"` // IANA name, e.g. "Europe/Tallinn"
+	Locale      string    `json:"locale"`   // BCP 47 tag, e.g. "en-GB"
+	Bio         string    `json:"bio"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Summary is the compact profile embedded in messages, task history and
+// member lists. Without a display name it shows the email's local part.
+type Summary struct {
+	ID          int64  `json:"id"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// Avatar is an uploaded image, served from /users/:id/avatar
+type Avatar struct {
+	UserID      int64
+	ContentType string
+	Data        []byte
+	UpdatedAt   time.Time
+}
+
+type Repository interface {
+	// Get returns defaults for users that never saved a profile
+	Get(ctx context.Context, userID int64) (*Profile, error)
+	Upsert(ctx context.Context, p Profile) (*Profile, error)
+
+	SaveAvatar(ctx context.Context, a Avatar) error
+	GetAvatar(ctx context.Context, userID int64) (*Avatar, error)
+	DeleteAvatar(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,173 @@
+package profiles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones validate even on hosts without a zoneinfo database
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrAvatarNotFound  = errors.New("avatar not found")
+	ErrInvalidProfile  = errors.New("invalid profile")
+	ErrInvalidAvatar   = errors.New("avatar must be a png, jpeg, gif or webp image")
+	ErrAvatarTooLarge  = errors.New("avatar image is too large")
+)
+
+const (
+	maxDisplayName = 64
+	maxBio         = 500
+	maxAvatarURL   = 2048
+	// MaxAvatarBytes caps uploads, avatars are shown small anyway
+	MaxAvatarBytes = 1 << 20
+)
+
+var avatarTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Update changes only the fields that are set
+type Update struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Timezone    *string `json:"timezone"`
+	Locale      *string `json:"locale"`
+	Bio         *string `json:"bio"`
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Get(ctx context.Context, userID int64) (*Profile, error) {
+	p, err := s.repo.Get(ctx, userID)
+	if err != nil || p == nil {
+		return nil, ErrProfileNotFound
+	}
+	return p, nil
+}
+
+// Update validates and saves the changed fields
+func (s *Service) Update(ctx context.Context, userID int64, u Update) (*Profile, error) {
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.DisplayName != nil {
+		name := strings.TrimSpace(*u.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayName {
+			return nil, fmt.Errorf("%w: display_name must be at most %d characters", ErrInvalidProfile, maxDisplayName)
+		}
+		p.DisplayName = name
+	}
+	if u.AvatarURL != nil {
+		avatar := strings.TrimSpace(*u.AvatarURL)
+		if err := validateAvatarURL(avatar); err != nil {
+			return nil, err
+		}
+		// pointing somewhere else drops the uploaded image
+		if err := s.repo.DeleteAvatar(ctx, userID); err != nil {
+			return nil, err
+		}
+		p.AvatarURL = avatar
+	}
+	if u.Timezone != nil {
+		if _, err := time.LoadLocation(*u.Timezone); err != nil || *u.Timezone == "" || *u.Timezone == "Local" {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidProfile, *u.Timezone)
+		}
+		p.Timezone = *u.Timezone
+	}
+	if u.Locale != nil {
+		tag, err := language.Parse(*u.Locale)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown locale %q", ErrInvalidProfile, *u.Locale)
+		}
+		p.Locale = tag.String()
+	}
+	if u.Bio != nil {
+		bio := strings.TrimSpace(*u.Bio)
+		if utf8.RuneCountInString(bio) > maxBio {
+			return nil, fmt.Errorf("%w: bio must be at most %d characters", ErrInvalidProfile, maxBio)
+		}
+		p.Bio = bio
+	}
+
+	return s.repo.Upsert(ctx, *p)
+}
+
+// SetAvatarImage stores an uploaded image and points the profile at it
+func (s *Service) SetAvatarImage(ctx context.Context, userID int64, data []byte) (*Profile, error) {
+	if len(data) > MaxAvatarBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	// trust the bytes, not whatever content type the client claimed
+	contentType := http.DetectContentType(data)
+	if !avatarTypes[contentType] {
+		return nil, ErrInvalidAvatar
+	}
+
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveAvatar(ctx, Avatar{UserID: userID, ContentType: contentType, Data: data}); err != nil {
+		return nil, err
+	}
+
+	// the version busts browser caches when the image changes
+	p.AvatarURL = fmt.Sprintf("/users/%d/avatar?v=%d", userID, time.Now().Unix())
+	return s.repo.Upsert(ctx, *p)
+}
+
+// RemoveAvatar clears both an uploaded image and an external url
+func (s *Service) RemoveAvatar(ctx context.Context, userID int64) (*Profile, error) {
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteAvatar(ctx, userID); err != nil {
+		return nil, err
+	}
+	p.AvatarURL = ""
+	return s.repo.Upsert(ctx, *p)
+}
+
+func (s *Service) Avatar(ctx context.Context, userID int64) (*Avatar, error) {
+	a, err := s.repo.GetAvatar(ctx, userID)
+	if err != nil || a == nil {
+		return nil, ErrAvatarNotFound
+	}
+	return a, nil
+}
+
+// only absolute https urls, "javascript:" and friends would end up in an <img src>
+// and plain http would be mixed content on the frontend
+func validateAvatarURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > maxAvatarURL {
+		return fmt.Errorf("%w: avatar_url is too long", ErrInvalidProfile)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: avatar_url must be an https url", ErrInvalidProfile)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package profiles
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smallest valid png header, enough for content sniffing
+var pngBytes = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+
+func TestProfileService(t *testing.T) {
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	newService := func() (*Service, *FakeRepository) {
+		repo := NewFakeRepository()
+		repo.Users[1] = "alice@example.com"
+		return NewService(repo), repo
+	}
+
+	t.Run("Users without a saved profile get defaults", func(t *testing.T) {
+		svc, _ := newService()
+
+		p, err := svc.Get(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "UTC", p.Timezone)
+		assert.Equal(t, "en", p.Locale)
+		assert.Empty(t, p.DisplayName)
+
+		_, err = svc.Get(ctx, 99)
+		assert.ErrorIs(t, err, ErrProfileNotFound)
+	})
+
+	t.Run("Update only touches the fields that are set", func(t *testing.T) {
+		svc, _ := newService()
+
+		_, err := svc.Update(ctx, 1, Update{DisplayName: str(" Alice "), Bio: str("Hi")})
+		assert.NoError(t, err)
+		p, err := svc.Update(ctx, 1, Update{Timezone: str("Europe/Tallinn"), Locale: str("en-gb")})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Alice", p.DisplayName)
+		assert.Equal(t, "Hi", p.Bio)
+		assert.Equal(t, "Europe/Tallinn", p.Timezone)
+		assert.Equal(t, "en-GB", p.Locale)
+	})
+
+	t.Run("Update rejects invalid values", func(t *testing.T) {
+		svc, _ := newService()
+
+		for _, u := range []Update{
+			{Timezone: str("Mars/Olympus")},
+			{Timezone: str("Local")},
+			{Locale: str("not a locale")},
+			{DisplayName: str(strings.Repeat("a", maxDisplayName+1))},
+			{Bio: str(strings.Repeat("a", maxBio+1))},
+			{AvatarURL: str("javascript:alert(1)")},
+			{AvatarURL: str("http://cdn.example.com/a.png")},
+		} {
+			_, err := svc.Update(ctx, 1, u)
+			assert.ErrorIs(t, err, ErrInvalidProfile)
+		}
+	})
+
+	t.Run("Uploaded avatars are sniffed and replace external urls", func(t *testing.T) {
+		svc, repo := newService()
+
+		_, err := svc.Update(ctx, 1, Update{AvatarURL: str("https://cdn.example.com/a.png")})
+		assert.NoError(t, err)
+
+		p, err := svc.SetAvatarImage(ctx, 1, pngBytes)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(p.AvatarURL, "/users/1/avatar?v="))
+
+		a, err := svc.Avatar(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", a.ContentType)
+
+		_, err = svc.SetAvatarImage(ctx, 1, []byte("<svg onload=alert(1)>"))
+		assert.ErrorIs(t, err, ErrInvalidAvatar)
+		_, err = svc.SetAvatarImage(ctx, 1, append(pngBytes, bytes.Repeat([]byte{0}, MaxAvatarBytes)...))
+		assert.ErrorIs(t, err, ErrAvatarTooLarge)
+
+		// pointing at an external url again drops the stored image
+		_, err = svc.Update(ctx, 1, Update{AvatarURL: str("https://cdn.example.com/b.png")})
+		assert.NoError(t, err)
+		assert.Empty(t, repo.Avatars)
+	})
+
+	t.Run("RemoveAvatar clears the url and the image", func(t *testing.T) {
+		svc, _ := newService()
+
+		_, err := svc.SetAvatarImage(ctx, 1, pngBytes)
+		assert.NoError(t, err)
+
+		p, err := svc.RemoveAvatar(ctx, 1)
+		assert.NoError(t, err)
+		assert.Empty(t, p.AvatarURL)
+		_, err = svc.Avatar(ctx, 1)
+		assert.ErrorIs(t, err, ErrAvatarNotFound)
+	})
+}
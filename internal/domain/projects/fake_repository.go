@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 )
 
 type projectUserEntry struct {
@@ -13,9 +15,16 @@ type projectUserEntry struct {
 	Role      string
 }
 
+type projectTaskEntry struct {
+	ProjectID int64
+	Task      TemplateTask
+}
+
 type FakeRepository struct {
 	projects     []Project
 	projectUsers []projectUserEntry
+	tasks        []projectTaskEntry
+	teams        TeamSource
 	nextID       int64
 }
 
@@ -27,6 +36,12 @@ func NewFakeRepository() *FakeRepository {
 	}
 }
 
+// UseTeams lets the fake see who teams bring into projects, the way the
+// database joins them in
+func (f *FakeRepository) UseTeams(t TeamSource) {
+	f.teams = t
+}
+
 func (f *FakeRepository) CreateProject(ctx context.Context, p Project) (*Project, error) {
 	p.ID = f.nextID
 	f.nextID++
@@ -39,6 +54,37 @@ func (f *FakeRepository) CreateProject(ctx context.Context, p Project) (*Project
 	return &f.projects[len(f.projects)-1], nil
 }
 
+func (f *FakeRepository) CreateProjectFrom(ctx context.Context, p Project, seed Blueprint) (*Project, error) {
+	for _, m := range seed.Members {
+		if m.UserID == p.OwnerID || !AssignableRole(m.Role) {
+			return nil, fmt.Errorf("invalid member %d (%s) in blueprint", m.UserID, m.Role)
+		}
+	}
+
+	project, err := f.CreateProject(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	f.projectUsers = append(f.projectUsers, projectUserEntry{ProjectID: project.ID, UserID: p.OwnerID, Role: RoleOwner})
+	for _, m := range seed.Members {
+		f.projectUsers = append(f.projectUsers, projectUserEntry{ProjectID: project.ID, UserID: m.UserID, Role: m.Role})
+	}
+	for _, t := range seed.Tasks {
+		f.tasks = append(f.tasks, projectTaskEntry{ProjectID: project.ID, Task: t})
+	}
+	return project, nil
+}
+
+func (f *FakeRepository) ListTaskSkeleton(ctx context.Context, projectID int64) ([]TemplateTask, error) {
+	var res []TemplateTask
+	for _, t := range f.tasks {
+		if t.ProjectID == projectID {
+			res = append(res, t.Task)
+		}
+	}
+	return res, nil
+}
+
 func (f *FakeRepository) Update(ctx context.Context, p Project) (*Project, error) {
 	//  find the existing project by id
 	for i, proj := range f.projects {
@@ -51,9 +97,12 @@ func (f *FakeRepository) Update(ctx context.Context, p Project) (*Project, error
 	return nil, fmt.Errorf("project not found in fake repo")
 }
 
-func (f *FakeRepository) GetAllByOwner(ctx context.Context, ownerID int64) ([]Project, error) {
+func (f *FakeRepository) GetAllByOwner(ctx context.Context, ownerID int64, includeArchived bool) ([]Project, error) {
 	var res []Project
 	for _, p := range f.projects {
+		if p.DeletedAt != nil || (p.ArchivedAt != nil && !includeArchived) {
+			continue
+		}
 		if p.OwnerID == ownerID {
 			res = append(res, p)
 		}
@@ -63,7 +112,7 @@ func (f *FakeRepository) GetAllByOwner(ctx context.Context, ownerID int64) ([]Pr
 
 func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*Project, error) {
 	for i := range f.projects {
-		if f.projects[i].ID == id {
+		if f.projects[i].ID == id && f.projects[i].DeletedAt == nil {
 			// return a pointer to the actual element in the slice
 			return &f.projects[i], nil
 		}
@@ -71,15 +120,63 @@ func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*Project, error
 	return nil, errors.New("project not found")
 }
 
-func (f *FakeRepository) DeleteProject(ctx context.Context, id int64, ownerID int64) error {
+func (f *FakeRepository) SetArchived(ctx context.Context, id int64, archived bool) error {
+	p, err := f.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !archived:
+		p.ArchivedAt = nil
+	case p.ArchivedAt == nil:
+		now := time.Now()
+		p.ArchivedAt = &now
+	}
+	return nil
+}
+
+func (f *FakeRepository) SoftDeleteProject(ctx context.Context, id int64) error {
+	p, err := f.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	return nil
+}
+
+func (f *FakeRepository) ListDeleted(ctx context.Context, ownerID int64, deletedAfter time.Time) ([]Project, error) {
+	var res []Project
+	for _, p := range f.projects {
+		if p.OwnerID == ownerID && p.DeletedAt != nil && p.DeletedAt.After(deletedAfter) {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) RestoreProject(ctx context.Context, id, ownerID int64, deletedAfter time.Time) (bool, error) {
 	for i, p := range f.projects {
-		if p.ID == id && p.OwnerID == ownerID {
-			// Remove the project from the slice
-			f.projects = append(f.projects[:i], f.projects[i+1:]...)
-			return nil
+		if p.ID == id && p.OwnerID == ownerID && p.DeletedAt != nil && p.DeletedAt.After(deletedAfter) {
+			f.projects[i].DeletedAt = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var kept []Project
+	var purged int64
+	for _, p := range f.projects {
+		if p.DeletedAt != nil && !p.DeletedAt.After(deletedBefore) {
+			purged++
+			continue
 		}
+		kept = append(kept, p)
 	}
-	return errors.New("project not found")
+	f.projects = kept
+	return purged, nil
 }
 
 func (f *FakeRepository) AddUserToProject(ctx context.Context, projectID int64, userID int64, role string) error {
@@ -103,13 +200,20 @@ func (f *FakeRepository) ListUsersInProject(ctx context.Context, projectID int64
 		if pu.ProjectID == projectID {
 			// mapping to clean domain struct
 			res = append(res, ProjectMember{
-				ID:    pu.UserID,
-				Email: "fake@example.com",
-				Role:  pu.Role,
+				ID:      pu.UserID,
+				Profile: profiles.Summary{ID: pu.UserID, DisplayName: "fake"},
+				Role:    pu.Role,
 			})
 		}
 	}
-	return res, nil
+	if f.teams == nil {
+		return WithTeams(res, nil), nil
+	}
+	teams, err := f.teams.ProjectTeamMembers(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return WithTeams(res, teams), nil
 }
 
 func (f *FakeRepository) RemoveUserFromProject(ctx context.Context, projectID int64, userID int64) error {
@@ -122,21 +226,106 @@ func (f *FakeRepository) RemoveUserFromProject(ctx context.Context, projectID in
 	return errors.New("user not found in project")
 }
 
-func (f *FakeRepository) UsersShareProject(ctx context.Context, userA, userB int64) (bool, error) {
-	// Track which projects each user belongs to
-	userAProjects := make(map[int64]bool)
+func (f *FakeRepository) UpdateMemberRole(ctx context.Context, projectID int64, userID int64, role string) error {
+	for i, pu := range f.projectUsers {
+		if pu.ProjectID == projectID && pu.UserID == userID {
+			f.projectUsers[i].Role = role
+			return nil
+		}
+	}
+	return errors.New("user not found in project")
+}
 
-	for _, pu := range f.projectUsers {
-		if pu.UserID == userA {
-			userAProjects[pu.ProjectID] = true
+func (f *FakeRepository) TransferOwnership(ctx context.Context, projectID, currentOwnerID, newOwnerID int64) error {
+	moved := false
+	for i, p := range f.projects {
+		if p.ID == projectID && p.OwnerID == currentOwnerID {
+			f.projects[i].OwnerID = newOwnerID
+			moved = true
+		}
+	}
+	if !moved {
+		return ErrOwnerChanged
+	}
+	for i, pu := range f.projectUsers {
+		if pu.ProjectID != projectID {
+			continue
+		}
+		switch pu.UserID {
+		case newOwnerID:
+			f.projectUsers[i].Role = RoleOwner
+		case currentOwnerID:
+			f.projectUsers[i].Role = RoleMaintainer
 		}
 	}
+	return nil
+}
 
-	for _, pu := range f.projectUsers {
-		if pu.UserID == userB && userAProjects[pu.ProjectID] {
+func (f *FakeRepository) UsersShareProject(ctx context.Context, userA, userB int64) (bool, error) {
+	for _, p := range f.projects {
+		members, err := f.ListUsersInProject(ctx, p.ID)
+		if err != nil {
+			return false, err
+		}
+		var hasA, hasB bool
+		for _, m := range members {
+			hasA = hasA || m.ID == userA
+			hasB = hasB || m.ID == userB
+		}
+		if hasA && hasB {
 			return true, nil
 		}
 	}
-
 	return false, nil
 }
+
+// FakeTemplateRepository implements TemplateRepository in memory for tests
+type FakeTemplateRepository struct {
+	Templates []Template
+	nextID    int64
+}
+
+func NewFakeTemplateRepository() *FakeTemplateRepository {
+	return &FakeTemplateRepository{
+		Templates: []Template{},
+		nextID:    1,
+	}
+}
+
+func (f *FakeTemplateRepository) CreateTemplate(ctx context.Context, t Template) (*Template, error) {
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+	f.Templates = append(f.Templates, t)
+	return &t, nil
+}
+
+func (f *FakeTemplateRepository) GetTemplate(ctx context.Context, id, ownerID int64) (*Template, error) {
+	for _, t := range f.Templates {
+		if t.ID == id && t.OwnerID == ownerID {
+			c := t
+			return &c, nil
+		}
+	}
+	return nil, ErrTemplateNotFound
+}
+
+func (f *FakeTemplateRepository) ListTemplates(ctx context.Context, ownerID int64) ([]Template, error) {
+	var res []Template
+	for _, t := range f.Templates {
+		if t.OwnerID == ownerID {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeTemplateRepository) DeleteTemplate(ctx context.Context, id, ownerID int64) error {
+	for i, t := range f.Templates {
+		if t.ID == id && t.OwnerID == ownerID {
+			f.Templates = append(f.Templates[:i], f.Templates[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTemplateNotFound
+}
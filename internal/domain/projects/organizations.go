@@ -0,0 +1,51 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrNotInOrganization = fmt.Errorf("%w: the user is not in the project's organization", ErrForbidden)
+
+// Organizations is what projects need to know about the organizations
+// that own them
+type Organizations interface {
+	IsMember(ctx context.Context, orgID, userID int64) (bool, error)
+	// DefaultOrganization picks the organization for a project created
+	// without one
+	DefaultOrganization(ctx context.Context, userID int64) (int64, error)
+}
+
+// UseOrganizations keeps projects and their members inside organizations
+func (s *Service) UseOrganizations(orgs Organizations) {
+	s.orgs = orgs
+}
+
+// organizationFor resolves where a new project goes and checks the creator
+// belongs there
+func (s *Service) organizationFor(ctx context.Context, orgID, userID int64) (int64, error) {
+	if s.orgs == nil {
+		return orgID, nil
+	}
+	if orgID == 0 {
+		return s.orgs.DefaultOrganization(ctx, userID)
+	}
+	if err := s.requireOrganization(ctx, orgID, userID); err != nil {
+		return 0, err
+	}
+	return orgID, nil
+}
+
+func (s *Service) requireOrganization(ctx context.Context, orgID, userID int64) error {
+	if s.orgs == nil {
+		return nil
+	}
+	ok, err := s.orgs.IsMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotInOrganization
+	}
+	return nil
+}
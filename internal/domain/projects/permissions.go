@@ -0,0 +1,105 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Roles a project member can have, from most to least powerful
+const (
+	RoleOwner      = "owner"
+	RoleMaintainer = "maintainer"
+	RoleMember     = "member"
+	RoleViewer     = "viewer"
+)
+
+var (
+	ErrNotMember        = fmt.Errorf("%w: you are not a member of this project", ErrForbidden)
+	ErrPermissionDenied = fmt.Errorf("%w: your project role does not allow this", ErrForbidden)
+	ErrInvalidRole      = errors.New("role must be one of maintainer, member or viewer")
+	ErrOwnerRole        = errors.New("the owner can't be removed or change role, transfer the project instead")
+	ErrProjectArchived  = fmt.Errorf("%w: the project is archived and read-only", ErrForbidden)
+)
+
+// Permission is something a member can do in a project
+type Permission string
+
+const (
+	PermViewProject      Permission = "project:view"
+	PermEditProject      Permission = "project:edit"
+	PermDeleteProject    Permission = "project:delete"
+	PermArchiveProject   Permission = "project:archive" // archive and unarchive
+	PermTransferProject  Permission = "project:transfer"
+	PermManageMembers    Permission = "members:manage" // add, remove, change roles, invite
+	PermCreateTask       Permission = "task:create"
+	PermEditAnyTask      Permission = "task:edit"
+	PermEditAssignedTask Permission = "task:edit_assigned"
+	PermDeleteTask       Permission = "task:delete"
+	PermViewTasks        Permission = "task:view"
+	PermPostChat         Permission = "chat:post"
+	PermReadChat         Permission = "chat:read"
+)
+
+// rolePermissions is the permission matrix, every check in the services goes through it
+var rolePermissions = map[string]map[Permission]bool{
+	RoleOwner: {
+		PermViewProject: true, PermEditProject: true, PermDeleteProject: true, PermArchiveProject: true, PermTransferProject: true, PermManageMembers: true,
+		PermCreateTask: true, PermEditAnyTask: true, PermEditAssignedTask: true, PermDeleteTask: true, PermViewTasks: true,
+		PermPostChat: true, PermReadChat: true,
+	},
+	RoleMaintainer: {
+		PermViewProject: true, PermEditProject: true, PermArchiveProject: true, PermManageMembers: true,
+		PermCreateTask: true, PermEditAnyTask: true, PermEditAssignedTask: true, PermDeleteTask: true, PermViewTasks: true,
+		PermPostChat: true, PermReadChat: true,
+	},
+	RoleMember: {
+		PermViewProject: true,
+		PermCreateTask:  true, PermEditAssignedTask: true, PermViewTasks: true,
+		PermPostChat: true, PermReadChat: true,
+	},
+	RoleViewer: {
+		PermViewProject: true,
+		PermViewTasks:   true,
+		PermReadChat:    true,
+	},
+}
+
+// archivedPermissions is what is left of the matrix while a project is
+// archived: reading, and the way out again
+var archivedPermissions = map[Permission]bool{
+	PermViewProject:     true,
+	PermViewTasks:       true,
+	PermReadChat:        true,
+	PermArchiveProject:  true,
+	PermDeleteProject:   true,
+	PermTransferProject: true,
+}
+
+// roleRank orders roles, members can only manage people below themselves
+var roleRank = map[string]int{
+	RoleOwner:      4,
+	RoleMaintainer: 3,
+	RoleMember:     2,
+	RoleViewer:     1,
+}
+
+// RoleAllows looks a permission up in the matrix
+func RoleAllows(role string, p Permission) bool {
+	return rolePermissions[role][p]
+}
+
+// AssignableRole reports whether members can be given the role directly,
+// ownership only changes hands through a transfer
+func AssignableRole(role string) bool {
+	return role != RoleOwner && roleRank[role] > 0
+}
+
+// CanManage reports whether someone with the actor's role may give the
+// target role to, or take it from, another member. The owner manages
+// everyone, maintainers only members and viewers.
+func CanManage(actorRole, targetRole string) bool {
+	if !RoleAllows(actorRole, PermManageMembers) {
+		return false
+	}
+	return actorRole == RoleOwner || roleRank[targetRole] < roleRank[actorRole]
+}
@@ -0,0 +1,126 @@
+package projects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePermissions(t *testing.T) {
+	t.Run("Only the owner deletes the project", func(t *testing.T) {
+		assert.True(t, RoleAllows(RoleOwner, PermDeleteProject))
+		assert.False(t, RoleAllows(RoleMaintainer, PermDeleteProject))
+		assert.True(t, RoleAllows(RoleMaintainer, PermManageMembers))
+	})
+
+	t.Run("Members edit their own tasks, viewers only read", func(t *testing.T) {
+		assert.True(t, RoleAllows(RoleMember, PermEditAssignedTask))
+		assert.False(t, RoleAllows(RoleMember, PermEditAnyTask))
+		assert.True(t, RoleAllows(RoleViewer, PermReadChat))
+		assert.False(t, RoleAllows(RoleViewer, PermPostChat))
+		assert.False(t, RoleAllows(RoleViewer, PermCreateTask))
+		assert.False(t, RoleAllows("admin", PermViewProject))
+	})
+
+	t.Run("Roles are managed from above only", func(t *testing.T) {
+		assert.True(t, CanManage(RoleOwner, RoleMaintainer))
+		assert.True(t, CanManage(RoleMaintainer, RoleViewer))
+		assert.False(t, CanManage(RoleMaintainer, RoleMaintainer))
+		assert.False(t, AssignableRole(RoleOwner))
+	})
+}
+
+func TestMemberRoles(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*Service, *FakeRepository) {
+		repo := NewFakeRepository()
+		repo.CreateProject(ctx, Project{Name: "Apollo", OwnerID: 1})
+		repo.AddUserToProject(ctx, 1, 1, RoleOwner)
+		repo.AddUserToProject(ctx, 1, 2, RoleMaintainer)
+		repo.AddUserToProject(ctx, 1, 3, RoleMember)
+		repo.AddUserToProject(ctx, 1, 4, RoleViewer)
+		return NewService(repo, nil), repo
+	}
+
+	t.Run("Legacy roles count as members and the owner column wins", func(t *testing.T) {
+		_, repo := setup()
+		repo.AddUserToProject(ctx, 1, 5, "admin")
+		auth := NewAuthorizer(repo)
+
+		role, err := auth.Role(ctx, 1, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleMember, role)
+		role, _ = auth.Role(ctx, 1, 1)
+		assert.Equal(t, RoleOwner, role)
+		role, _ = auth.Role(ctx, 1, 99)
+		assert.Equal(t, "", role)
+
+		_, err = auth.Require(ctx, 1, 99, PermViewProject)
+		assert.ErrorIs(t, err, ErrNotMember)
+		_, err = auth.Require(ctx, 1, 4, PermCreateTask)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("Maintainers manage members below them", func(t *testing.T) {
+		svc, _ := setup()
+
+		assert.NoError(t, svc.ChangeMemberRole(ctx, 2, 1, 3, RoleViewer))
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 2, 1, 3, RoleMaintainer), ErrPermissionDenied)
+		assert.ErrorIs(t, svc.AddUserToProject(ctx, 2, 1, 6, RoleMaintainer), ErrPermissionDenied)
+		assert.NoError(t, svc.AddUserToProject(ctx, 2, 1, 6, ""))
+		assert.ErrorIs(t, svc.RemoveUserFromProject(2, 1, 1), ErrOwnerRole)
+	})
+
+	t.Run("Members and viewers can't manage anyone", func(t *testing.T) {
+		svc, _ := setup()
+
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 3, 1, 4, RoleMember), ErrPermissionDenied)
+		assert.ErrorIs(t, svc.RemoveUserFromProject(4, 1, 3), ErrPermissionDenied)
+		_, err := svc.UpdateProject(ctx, 3, 1, "Renamed", "")
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		assert.ErrorIs(t, svc.DeleteProject(ctx, 1, 2), ErrPermissionDenied)
+	})
+
+	t.Run("The owner's role is off limits", func(t *testing.T) {
+		svc, _ := setup()
+
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 1, 1, 1, RoleMember), ErrOwnerRole)
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 1, 1, 2, RoleOwner), ErrInvalidRole)
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 1, 1, 42, RoleMember), ErrMemberNotFound)
+
+		assert.NoError(t, svc.ChangeMemberRole(ctx, 1, 1, 2, RoleViewer))
+		members, _ := svc.ListUsersInProject(ctx, 1, 1)
+		for _, m := range members {
+			if m.ID == 2 {
+				assert.Equal(t, RoleViewer, m.Role)
+			}
+		}
+	})
+}
+
+func TestAuthorizerCache(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeRepository()
+	repo.CreateProject(ctx, Project{Name: "Apollo", OwnerID: 1})
+	repo.AddUserToProject(ctx, 1, 2, RoleViewer)
+
+	policy := NewCachedAuthorizer(repo, time.Hour)
+	assert.ErrorIs(t, policy.Authorize(ctx, 2, PermPostChat, 1), ErrForbidden)
+
+	// a change behind the policy's back waits for the cache
+	repo.UpdateMemberRole(ctx, 1, 2, RoleMember)
+	assert.ErrorIs(t, policy.Authorize(ctx, 2, PermPostChat, 1), ErrForbidden)
+	policy.Forget(1)
+	assert.NoError(t, policy.Authorize(ctx, 2, PermPostChat, 1))
+
+	// changes through the service are seen right away
+	svc := NewService(repo, nil)
+	svc.UseAuthorizer(policy)
+	assert.NoError(t, svc.RemoveUserFromProject(1, 1, 2))
+	assert.ErrorIs(t, policy.Authorize(ctx, 2, PermViewProject, 1), ErrNotMember)
+
+	assert.ErrorIs(t, policy.Authorize(ctx, 1, PermViewProject, 42), ErrProjectNotFound)
+}
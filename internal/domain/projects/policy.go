@@ -0,0 +1,149 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrForbidden is wrapped by every refusal of the policy, handlers
+	// turn it into a 403 whatever the reason was
+	ErrForbidden       = errors.New("unauthorized")
+	ErrProjectNotFound = errors.New("project not found")
+)
+
+// membership is what the policy knows about a project's members
+type membership struct {
+	ownerID   int64
+	archived  bool
+	roles     map[int64]string
+	direct    map[int64]bool // false for people only there through teams
+	fetchedAt time.Time
+}
+
+// Authorizer is the policy every service asks "may this user do that in
+// this project", so the rules live in one place instead of OwnerID
+// comparisons. Memberships are cached per project for ttl, changes made
+// through this instance are seen right away.
+type Authorizer struct {
+	repo    Repository
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[int64]membership
+}
+
+// NewAuthorizer looks memberships up on every check
+func NewAuthorizer(repo Repository) *Authorizer {
+	return NewCachedAuthorizer(repo, 0)
+}
+
+func NewCachedAuthorizer(repo Repository, ttl time.Duration) *Authorizer {
+	return &Authorizer{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[int64]membership),
+	}
+}
+
+// Authorize fails with an error wrapping ErrForbidden unless the user's
+// role in the project grants the action. Archived projects only allow
+// reading, anything else is ErrProjectArchived.
+func (a *Authorizer) Authorize(ctx context.Context, userID int64, action Permission, projectID int64) error {
+	_, err := a.Require(ctx, projectID, userID, action)
+	return err
+}
+
+// Require is Authorize for callers that need the role for finer checks
+func (a *Authorizer) Require(ctx context.Context, projectID, userID int64, p Permission) (string, error) {
+	m, err := a.membership(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	role := m.role(userID)
+	if role == "" {
+		return "", ErrNotMember
+	}
+	if !RoleAllows(role, p) {
+		return role, ErrPermissionDenied
+	}
+	if m.archived && !archivedPermissions[p] {
+		return role, ErrProjectArchived
+	}
+	return role, nil
+}
+
+// Role returns the user's role in the project, "" if they aren't a member.
+// The project's OwnerID always counts as owner.
+func (a *Authorizer) Role(ctx context.Context, projectID, userID int64) (string, error) {
+	m, err := a.membership(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	return m.role(userID), nil
+}
+
+// Direct reports whether the user is a member in their own right rather
+// than only through a team. The owner always is.
+func (a *Authorizer) Direct(ctx context.Context, projectID, userID int64) (bool, error) {
+	m, err := a.membership(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+	return m.ownerID == userID || m.direct[userID], nil
+}
+
+func (m membership) role(userID int64) string {
+	if m.ownerID == userID {
+		return RoleOwner
+	}
+
+	role, ok := m.roles[userID]
+	if !ok {
+		return ""
+	}
+	// rows from before roles meant anything count as plain members
+	if roleRank[role] == 0 || role == RoleOwner {
+		return RoleMember
+	}
+	return role
+}
+
+// Forget drops the project's cached members, call it after changing them
+func (a *Authorizer) Forget(projectID int64) {
+	a.mu.Lock()
+	delete(a.entries, projectID)
+	a.mu.Unlock()
+}
+
+func (a *Authorizer) membership(ctx context.Context, projectID int64) (membership, error) {
+	a.mu.RLock()
+	entry, ok := a.entries[projectID]
+	a.mu.RUnlock()
+	if ok && time.Since(entry.fetchedAt) < a.ttl {
+		return entry, nil
+	}
+
+	project, err := a.repo.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return membership{}, fmt.Errorf("%w: %v", ErrProjectNotFound, err)
+	}
+	members, err := a.repo.ListUsersInProject(ctx, projectID)
+	if err != nil {
+		return membership{}, fmt.Errorf("could not verify project membership: %w", err)
+	}
+
+	entry = membership{ownerID: project.OwnerID, archived: project.ArchivedAt != nil, roles: make(map[int64]string, len(members)), direct: make(map[int64]bool, len(members)), fetchedAt: time.Now()}
+	for _, m := range members {
+		entry.roles[m.ID] = m.Role
+		entry.direct[m.ID] = m.Direct
+	}
+	if a.ttl > 0 {
+		a.mu.Lock()
+		a.entries[projectID] = entry
+		a.mu.Unlock()
+	}
+	return entry, nil
+}
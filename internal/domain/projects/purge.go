@@ -0,0 +1,46 @@
+package projects
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Purger removes soft deleted projects once their retention period is over
+type Purger struct {
+	service *Service
+	stop    chan struct{}
+}
+
+func NewPurger(service *Service) *Purger {
+	return &Purger{
+		service: service,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Run purges on every tick. Call it in a goroutine, Stop ends it.
+func (p *Purger) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			n, err := p.service.PurgeDeleted(ctx)
+			cancel()
+			if err != nil {
+				log.Printf("project purge failed: %v", err)
+			} else if n > 0 {
+				log.Printf("purged %d deleted projects", n)
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *Purger) Stop() {
+	close(p.stop)
+}
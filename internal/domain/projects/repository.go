@@ -3,32 +3,58 @@ package projects
 import (
 	"context"
 	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 )
 
 type ProjectMember struct {
-	ID    int64  `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID      int64            `json:"id"`
+	Profile profiles.Summary `json:"profile"`
+	Role    string           `json:"role"`
+	// Direct is false for people who are only in the project through teams
+	Direct bool      `json:"direct"`
+	Teams  []TeamRef `json:"teams,omitempty"`
 }
 
 type Project struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	OwnerID     int64     `json:"owner_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	OwnerName   string    `json:"owner_name"`
+	ID             int64            `json:"id"`
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	OwnerID        int64            `json:"owner_id"`
+	OrganizationID int64            `json:"organization_id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	ArchivedAt     *time.Time       `json:"archived_at,omitempty"`
+	DeletedAt      *time.Time       `json:"deleted_at,omitempty"`
+	Owner          profiles.Summary `json:"owner"`
 }
 
 type Repository interface {
 	CreateProject(ctx context.Context, p Project) (*Project, error)
+	// CreateProjectFrom creates the project with its owner, members and tasks
+	// in one transaction, either all of it exists afterwards or none of it
+	CreateProjectFrom(ctx context.Context, p Project, seed Blueprint) (*Project, error)
+	ListTaskSkeleton(ctx context.Context, projectID int64) ([]TemplateTask, error)
 	Update(ctx context.Context, p Project) (*Project, error)
-	GetAllByOwner(ctx context.Context, ownerID int64) ([]Project, error)
+	// GetAllByOwner leaves archived projects out unless includeArchived is set
+	GetAllByOwner(ctx context.Context, ownerID int64, includeArchived bool) ([]Project, error)
+	// GetByID doesn't find soft deleted projects
 	GetByID(ctx context.Context, id int64) (*Project, error)
-	DeleteProject(ctx context.Context, id int64, ownerID int64) error
+	SetArchived(ctx context.Context, id int64, archived bool) error
+	// SoftDeleteProject hides the project until it is restored or purged
+	SoftDeleteProject(ctx context.Context, id int64) error
+	ListDeleted(ctx context.Context, ownerID int64, deletedAfter time.Time) ([]Project, error)
+	// RestoreProject reports false if nothing deleted after deletedAfter matched
+	RestoreProject(ctx context.Context, id, ownerID int64, deletedAfter time.Time) (bool, error)
+	// PurgeDeleted removes projects deleted before the cutoff for good
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 	AddUserToProject(ctx context.Context, projectID int64, userID int64, role string) error
 	RemoveUserFromProject(ctx context.Context, projectID int64, userID int64) error
+	UpdateMemberRole(ctx context.Context, projectID int64, userID int64, role string) error
+	// TransferOwnership fails with ErrOwnerChanged if currentOwnerID no longer owns the project
+	TransferOwnership(ctx context.Context, projectID, currentOwnerID, newOwnerID int64) error
+	// ListUsersInProject includes the people the project's teams bring in
 	ListUsersInProject(ctx context.Context, projectID int64) ([]ProjectMember, error)
+	// UsersShareProject counts membership through teams as well
 	UsersShareProject(ctx context.Context, userA, userB int64) (bool, error)
 }
@@ -4,45 +4,117 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nelfander/Playingfield/internal/domain/audit"
 	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
 )
 
+var (
+	ErrMemberNotFound = errors.New("user is not a member of this project")
+	ErrAlreadyOwner   = errors.New("that user already owns the project")
+	ErrOwnerChanged   = errors.New("the project changed owner in the meantime, try again")
+	ErrNotRestorable  = errors.New("no deleted project of yours to restore, it may already have been purged")
+)
+
+// DefaultRetention is how long a deleted project can be restored
+const DefaultRetention = 30 * 24 * time.Hour
+
 type Service struct {
-	repo Repository
-	hub  *ws.Hub
+	repo      Repository
+	templates TemplateRepository
+	orgs      Organizations
+	auth      *Authorizer
+	audit     *audit.Service
+	hub       *ws.Hub
+	retention time.Duration
 }
 
 func NewService(repo Repository, hub *ws.Hub) *Service {
 	return &Service{
-		repo: repo,
-		hub:  hub,
+		repo:      repo,
+		auth:      NewAuthorizer(repo),
+		hub:       hub,
+		retention: DefaultRetention,
 	}
 }
 
-func (s *Service) ListUsersInProject(ctx context.Context, projectID int64) ([]ProjectMember, error) {
+// UseAuthorizer shares one policy (and its membership cache) with the
+// other services, so a change made here is seen by all of them
+func (s *Service) UseAuthorizer(a *Authorizer) {
+	s.auth = a
+}
+
+// UseRetention sets how long deleted projects can be restored before the
+// purge removes them for good
+func (s *Service) UseRetention(d time.Duration) {
+	s.retention = d
+}
+
+// UseTemplates turns on saving projects as templates and creating projects from them
+func (s *Service) UseTemplates(repo TemplateRepository) {
+	s.templates = repo
+}
+
+// UseAudit records ownership transfers in the audit log
+func (s *Service) UseAudit(a *audit.Service) {
+	s.audit = a
+}
+
+func (s *Service) ListUsersInProject(ctx context.Context, requesterID, projectID int64) ([]ProjectMember, error) {
+	if err := s.auth.Authorize(ctx, requesterID, PermViewProject, projectID); err != nil {
+		return nil, err
+	}
 	return s.repo.ListUsersInProject(ctx, projectID)
 }
 
-func (s *Service) CreateProject(ctx context.Context, name, description string, ownerID int64) (*Project, error) {
+// CreateProject creates an empty project, or with a templateID one that
+// starts with the template's tasks and members. The template's description
+// is used when none is given. An orgID of 0 puts it in the owner's only
+// organization.
+func (s *Service) CreateProject(ctx context.Context, orgID int64, name, description string, ownerID, templateID int64) (*Project, error) {
+	orgID, err := s.organizationFor(ctx, orgID, ownerID)
+	if err != nil {
+		return nil, err
+	}
 	p := Project{
-		Name:        name,
-		Description: description,
-		OwnerID:     ownerID,
+		Name:           name,
+		Description:    description,
+		OwnerID:        ownerID,
+		OrganizationID: orgID,
+	}
+
+	if templateID != 0 {
+		tmpl, err := s.template(ctx, templateID, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Description == "" {
+			p.Description = tmpl.Description
+		}
+		seed := Blueprint{Tasks: tmpl.Tasks}
+		for _, m := range tmpl.Members {
+			if m.UserID == ownerID {
+				continue
+			}
+			// people who left the organization since don't come along
+			if s.requireOrganization(ctx, orgID, m.UserID) != nil {
+				continue
+			}
+			seed.Members = append(seed.Members, m)
+		}
+		return s.createFrom(ctx, p, seed)
 	}
 
 	project, err := s.repo.CreateProject(ctx, p)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return nil, fmt.Errorf("you already have a project with the name '%s'", name)
-		}
-		return nil, err
+		return nil, createError(name, err)
 	}
 
 	// This will call the Fake in tests and the Real DB in production
-	err = s.repo.AddUserToProject(ctx, project.ID, ownerID, "owner")
+	err = s.repo.AddUserToProject(ctx, project.ID, ownerID, RoleOwner)
 	if err != nil {
 		return nil, fmt.Errorf("project created but failed to assign ownership: %w", err)
 	}
@@ -54,18 +126,150 @@ func (s *Service) CreateProject(ctx context.Context, name, description string, o
 	return project, nil
 }
 
+// CloneProject copies a project the requester can see into a new one they
+// own. Copied tasks start over unassigned in the initial status, copying
+// members takes the right to manage them in the source project.
+func (s *Service) CloneProject(ctx context.Context, requesterID, projectID int64, opts CloneOptions) (*Project, error) {
+	source, seed, err := s.blueprint(ctx, requesterID, projectID, opts.Tasks, opts.Members, opts.Descriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Project{Name: opts.Name, OwnerID: requesterID, OrganizationID: source.OrganizationID}
+	if p.Name == "" {
+		p.Name = source.Name + " (copy)"
+	}
+	if opts.Descriptions {
+		p.Description = source.Description
+	}
+	return s.createFrom(ctx, p, seed)
+}
+
+// SaveTemplate snapshots the project's tasks, with their descriptions, and
+// optionally its members as a template of the requester's
+func (s *Service) SaveTemplate(ctx context.Context, requesterID, projectID int64, name string, includeMembers bool) (*Template, error) {
+	if s.templates == nil {
+		return nil, ErrTemplateNotFound
+	}
+	source, seed, err := s.blueprint(ctx, requesterID, projectID, true, includeMembers, true)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = source.Name
+	}
+
+	return s.templates.CreateTemplate(ctx, Template{
+		OwnerID:     requesterID,
+		Name:        name,
+		Description: source.Description,
+		Tasks:       seed.Tasks,
+		Members:     seed.Members,
+	})
+}
+
+func (s *Service) ListTemplates(ctx context.Context, ownerID int64) ([]Template, error) {
+	if s.templates == nil {
+		return nil, nil
+	}
+	return s.templates.ListTemplates(ctx, ownerID)
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, ownerID, templateID int64) error {
+	if s.templates == nil {
+		return ErrTemplateNotFound
+	}
+	return s.templates.DeleteTemplate(ctx, templateID, ownerID)
+}
+
+func (s *Service) template(ctx context.Context, id, ownerID int64) (*Template, error) {
+	if s.templates == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return s.templates.GetTemplate(ctx, id, ownerID)
+}
+
+// blueprint reads what a clone or template takes from the source project.
+// The requester becomes the owner of whatever is made from it, so they
+// are left out of the members and the source's owner stays on as a
+// maintainer.
+func (s *Service) blueprint(ctx context.Context, requesterID, projectID int64, tasks, members, descriptions bool) (*Project, Blueprint, error) {
+	var seed Blueprint
+	role, err := s.auth.Require(ctx, projectID, requesterID, PermViewProject)
+	if err != nil {
+		return nil, seed, err
+	}
+	if members && !RoleAllows(role, PermManageMembers) {
+		return nil, seed, ErrPermissionDenied
+	}
+
+	source, err := s.repo.GetByID(ctx, projectID)
+	if err != nil || source == nil {
+		return nil, seed, fmt.Errorf("%w: %v", ErrProjectNotFound, err)
+	}
+
+	if tasks {
+		skeleton, err := s.repo.ListTaskSkeleton(ctx, projectID)
+		if err != nil {
+			return nil, seed, err
+		}
+		for _, t := range skeleton {
+			if !descriptions {
+				t.Description = ""
+			}
+			seed.Tasks = append(seed.Tasks, t)
+		}
+	}
+
+	if members {
+		list, err := s.repo.ListUsersInProject(ctx, projectID)
+		if err != nil {
+			return nil, seed, err
+		}
+		for _, m := range list {
+			if m.ID == requesterID {
+				continue
+			}
+			memberRole, _ := s.auth.Role(ctx, projectID, m.ID)
+			if memberRole == RoleOwner {
+				memberRole = RoleMaintainer
+			}
+			seed.Members = append(seed.Members, TemplateMember{UserID: m.ID, Role: memberRole})
+		}
+	}
+	return source, seed, nil
+}
+
+func (s *Service) createFrom(ctx context.Context, p Project, seed Blueprint) (*Project, error) {
+	project, err := s.repo.CreateProjectFrom(ctx, p, seed)
+	if err != nil {
+		return nil, createError(p.Name, err)
+	}
+
+	if s.hub != nil {
+		s.hub.Broadcast <- []byte("PROJECT_CREATED")
+	}
+	return project, nil
+}
+
+func createError(name string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("you already have a project with the name '%s'", name)
+	}
+	return err
+}
+
 func (s *Service) UpdateProject(ctx context.Context, requesterID, projectID int64, name, description string) (*Project, error) {
-	// get current project to check ownership
+	if err := s.auth.Authorize(ctx, requesterID, PermEditProject, projectID); err != nil {
+		return nil, err
+	}
+
 	project, err := s.repo.GetByID(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("project not found: %w", err)
 	}
 
-	//  only owner can update
-	if project.OwnerID != requesterID {
-		return nil, fmt.Errorf("unauthorized: user %d is not the owner", requesterID)
-	}
-
 	// update the fields
 	project.Name = name
 	project.Description = description
@@ -85,25 +289,64 @@ func (s *Service) UpdateProject(ctx context.Context, requesterID, projectID int6
 	return updatedProject, nil
 }
 
-func (s *Service) ListProjects(ctx context.Context, ownerID int64) ([]Project, error) {
-	return s.repo.GetAllByOwner(ctx, ownerID)
+// ListProjects lists the user's projects, only those of one organization
+// when orgID isn't 0
+func (s *Service) ListProjects(ctx context.Context, ownerID, orgID int64, includeArchived bool) ([]Project, error) {
+	list, err := s.repo.GetAllByOwner(ctx, ownerID, includeArchived)
+	if err != nil || orgID == 0 {
+		return list, err
+	}
+
+	filtered := []Project{}
+	for _, p := range list {
+		if p.OrganizationID == orgID {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// ArchiveProject makes the project read-only and hides it from the project
+// list, archiving an archived project does nothing
+func (s *Service) ArchiveProject(ctx context.Context, requesterID, projectID int64) error {
+	return s.setArchived(ctx, requesterID, projectID, true)
 }
 
-func (s *Service) DeleteProject(ctx context.Context, projectID, ownerID int64) error {
-	project, err := s.repo.GetByID(ctx, projectID)
-	if err != nil {
-		return fmt.Errorf("failed to fetch project: %w", err)
+func (s *Service) UnarchiveProject(ctx context.Context, requesterID, projectID int64) error {
+	return s.setArchived(ctx, requesterID, projectID, false)
+}
+
+func (s *Service) setArchived(ctx context.Context, requesterID, projectID int64, archived bool) error {
+	if err := s.auth.Authorize(ctx, requesterID, PermArchiveProject, projectID); err != nil {
+		return err
 	}
 
-	if project.OwnerID != ownerID {
-		return fmt.Errorf("only the project owner can delete this project")
+	if err := s.repo.SetArchived(ctx, projectID, archived); err != nil {
+		return err
 	}
+	s.auth.Forget(projectID)
 
-	//  repo.DeleteProject (Safe for tests)
-	err = s.repo.DeleteProject(ctx, projectID, ownerID)
-	if err != nil {
+	if s.hub != nil {
+		event := "PROJECT_UNARCHIVED"
+		if archived {
+			event = "PROJECT_ARCHIVED"
+		}
+		s.hub.Broadcast <- []byte(fmt.Sprintf("%s:%d", event, projectID))
+	}
+	return nil
+}
+
+// DeleteProject only marks the project deleted, its tasks and chat stay
+// until the purge runs after the retention period
+func (s *Service) DeleteProject(ctx context.Context, projectID, requesterID int64) error {
+	if err := s.auth.Authorize(ctx, requesterID, PermDeleteProject, projectID); err != nil {
+		return err
+	}
+
+	if err := s.repo.SoftDeleteProject(ctx, projectID); err != nil {
 		return err
 	}
+	s.auth.Forget(projectID)
 
 	if s.hub != nil {
 		notification := fmt.Sprintf("PROJECT_DELETED:%d", projectID)
@@ -113,22 +356,70 @@ func (s *Service) DeleteProject(ctx context.Context, projectID, ownerID int64) e
 	return nil
 }
 
+// ListDeletedProjects returns the owner's deleted projects that can still be restored
+func (s *Service) ListDeletedProjects(ctx context.Context, ownerID int64) ([]Project, error) {
+	return s.repo.ListDeleted(ctx, ownerID, time.Now().Add(-s.retention))
+}
+
+// RestoreProject brings a deleted project back as it was. Only its owner
+// can, and only within the retention period.
+func (s *Service) RestoreProject(ctx context.Context, requesterID, projectID int64) error {
+	restored, err := s.repo.RestoreProject(ctx, projectID, requesterID, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotRestorable
+	}
+	s.auth.Forget(projectID)
+
+	if s.hub != nil {
+		notification := fmt.Sprintf("PROJECT_RESTORED:%d", projectID)
+		s.hub.Broadcast <- []byte(notification)
+	}
+	return nil
+}
+
+// PurgeDeleted removes the projects deleted longer than the retention
+// period ago, with their tasks, history and chat
+func (s *Service) PurgeDeleted(ctx context.Context) (int64, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-s.retention))
+}
+
 func (s *Service) AddUserToProject(ctx context.Context, requesterID int64, projectID int64, userID int64, role string) error {
-	project, err := s.repo.GetByID(ctx, projectID)
+	if role == "" {
+		role = RoleMember
+	}
+	if !AssignableRole(role) {
+		return ErrInvalidRole
+	}
+
+	// owners add anyone, maintainers only members and viewers
+	requesterRole, err := s.auth.Require(ctx, projectID, requesterID, PermManageMembers)
 	if err != nil {
-		return fmt.Errorf("project not found: %w", err)
+		return err
+	}
+	if !CanManage(requesterRole, role) {
+		return ErrPermissionDenied
 	}
 
-	// only project owner can add members
-	if project.OwnerID != requesterID {
-		return fmt.Errorf("unauthorized: user %d is not the owner of project %d", requesterID, projectID)
+	// only people in the project's organization can join it
+	if s.orgs != nil {
+		project, err := s.repo.GetByID(ctx, projectID)
+		if err != nil || project == nil {
+			return fmt.Errorf("%w: %v", ErrProjectNotFound, err)
+		}
+		if err := s.requireOrganization(ctx, project.OrganizationID, userID); err != nil {
+			return err
+		}
 	}
 
 	// duplicate check
 	members, err := s.repo.ListUsersInProject(ctx, projectID)
 	if err == nil {
 		for _, m := range members {
-			if m.ID == userID {
+			// people in through a team can still be added in their own right
+			if m.ID == userID && m.Direct {
 				return fmt.Errorf("user is already a member of this project")
 			}
 		}
@@ -140,6 +431,7 @@ func (s *Service) AddUserToProject(ctx context.Context, requesterID int64, proje
 	if err != nil {
 		return err
 	}
+	s.auth.Forget(projectID)
 
 	// broadcast the change
 	if s.hub != nil {
@@ -151,19 +443,31 @@ func (s *Service) AddUserToProject(ctx context.Context, requesterID int64, proje
 }
 
 func (s *Service) RemoveUserFromProject(requesterID, projectID, userID int64) error {
-	project, err := s.repo.GetByID(context.Background(), projectID)
+	ctx := context.Background()
+	requesterRole, err := s.auth.Require(ctx, projectID, requesterID, PermManageMembers)
 	if err != nil {
-		return fmt.Errorf("failed to fetch project: %w", err)
+		return err
 	}
 
-	if project.OwnerID != requesterID {
-		return fmt.Errorf("only the project owner can remove users")
+	targetRole, err := s.auth.Role(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+	if targetRole == RoleOwner {
+		return ErrOwnerRole
+	}
+	if targetRole != "" && !CanManage(requesterRole, targetRole) {
+		return ErrPermissionDenied
+	}
+	if err := s.requireDirect(ctx, projectID, userID, targetRole); err != nil {
+		return err
 	}
 
-	err = s.repo.RemoveUserFromProject(context.Background(), projectID, userID)
+	err = s.repo.RemoveUserFromProject(ctx, projectID, userID)
 	if err != nil {
 		return err
 	}
+	s.auth.Forget(projectID)
 
 	if s.hub != nil {
 		notification := fmt.Sprintf("USER_REMOVED:%d:%d", projectID, userID)
@@ -173,6 +477,117 @@ func (s *Service) RemoveUserFromProject(requesterID, projectID, userID int64) er
 	return nil
 }
 
-func (s *Service) GetProject(ctx context.Context, id int64) (*Project, error) {
+// ChangeMemberRole moves a member to another role. The owner's role is
+// off limits, and maintainers can't promote anyone to their own level.
+func (s *Service) ChangeMemberRole(ctx context.Context, requesterID, projectID, userID int64, role string) error {
+	if !AssignableRole(role) {
+		return ErrInvalidRole
+	}
+
+	requesterRole, err := s.auth.Require(ctx, projectID, requesterID, PermManageMembers)
+	if err != nil {
+		return err
+	}
+
+	targetRole, err := s.auth.Role(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case targetRole == "":
+		return ErrMemberNotFound
+	case targetRole == RoleOwner:
+		return ErrOwnerRole
+	case !CanManage(requesterRole, targetRole), !CanManage(requesterRole, role):
+		return ErrPermissionDenied
+	}
+	if err := s.requireDirect(ctx, projectID, userID, targetRole); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateMemberRole(ctx, projectID, userID, role); err != nil {
+		return fmt.Errorf("failed to change role: %w", err)
+	}
+	s.auth.Forget(projectID)
+
+	if s.hub != nil {
+		notification := fmt.Sprintf("MEMBER_ROLE_CHANGED:%d:%d:%s", projectID, userID, role)
+		s.hub.Broadcast <- []byte(notification)
+	}
+	return nil
+}
+
+// TransferOwnership hands the project to an existing member, the previous
+// owner stays on as a maintainer. System admins can do it for any project,
+// for when the owner has left and can't do it themselves.
+func (s *Service) TransferOwnership(ctx context.Context, requesterID int64, systemAdmin bool, projectID, newOwnerID int64) (*Project, error) {
+	if !systemAdmin {
+		if err := s.auth.Authorize(ctx, requesterID, PermTransferProject, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	project, err := s.repo.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return nil, fmt.Errorf("%w: %v", ErrProjectNotFound, err)
+	}
+
+	role, err := s.auth.Role(ctx, projectID, newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	switch role {
+	case "":
+		return nil, ErrMemberNotFound
+	case RoleOwner:
+		return nil, ErrAlreadyOwner
+	}
+	if err := s.requireDirect(ctx, projectID, newOwnerID, role); err != nil {
+		return nil, err
+	}
+
+	previousOwnerID := project.OwnerID
+	if err := s.repo.TransferOwnership(ctx, projectID, previousOwnerID, newOwnerID); err != nil {
+		return nil, err
+	}
+	s.auth.Forget(projectID)
+	project.OwnerID = newOwnerID
+
+	// the transfer is committed, failing now would only make clients retry
+	// against the new owner
+	if s.audit != nil {
+		details := fmt.Sprintf("owner %d -> %d", previousOwnerID, newOwnerID)
+		if err := s.audit.Record(ctx, requesterID, "project.ownership_transferred", "project", projectID, details); err != nil {
+			log.Printf("failed to audit ownership transfer of project %d: %v", projectID, err)
+		}
+	}
+
+	if s.hub != nil {
+		notification := fmt.Sprintf("OWNERSHIP_CHANGED:%d:%d:%d", projectID, previousOwnerID, newOwnerID)
+		s.hub.Broadcast <- []byte(notification)
+	}
+	return project, nil
+}
+
+// requireDirect refuses changes to the membership of people who are only
+// in the project through a team, they come and go with the team
+func (s *Service) requireDirect(ctx context.Context, projectID, userID int64, role string) error {
+	if role == "" {
+		return nil
+	}
+	direct, err := s.auth.Direct(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !direct {
+		return ErrThroughTeam
+	}
+	return nil
+}
+
+func (s *Service) GetProject(ctx context.Context, requesterID, id int64) (*Project, error) {
+	if err := s.auth.Authorize(ctx, requesterID, PermViewProject, id); err != nil {
+		return nil, err
+	}
 	return s.repo.GetByID(ctx, id)
 }
@@ -0,0 +1,383 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*Service, *FakeRepository, *audit.FakeRepository) {
+		repo := NewFakeRepository()
+		repo.CreateProject(ctx, Project{Name: "Apollo", OwnerID: 1})
+		repo.AddUserToProject(ctx, 1, 1, RoleOwner)
+		repo.AddUserToProject(ctx, 1, 2, RoleMaintainer)
+		repo.AddUserToProject(ctx, 1, 3, RoleViewer)
+
+		auditRepo := audit.NewFakeRepository()
+		svc := NewService(repo, nil)
+		svc.UseAudit(audit.NewService(auditRepo))
+		return svc, repo, auditRepo
+	}
+
+	roleOf := func(repo *FakeRepository, userID int64) string {
+		role, _ := NewAuthorizer(repo).Role(ctx, 1, userID)
+		return role
+	}
+
+	t.Run("The owner hands over and stays on as maintainer", func(t *testing.T) {
+		svc, repo, auditRepo := setup()
+
+		project, err := svc.TransferOwnership(ctx, 1, false, 1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), project.OwnerID)
+		assert.Equal(t, RoleOwner, roleOf(repo, 3))
+		assert.Equal(t, RoleMaintainer, roleOf(repo, 1))
+
+		assert.Len(t, auditRepo.Entries, 1)
+		assert.Equal(t, "project.ownership_transferred", auditRepo.Entries[0].Action)
+		assert.Equal(t, "owner 1 -> 3", auditRepo.Entries[0].Details)
+	})
+
+	t.Run("Only the owner or a system admin can transfer", func(t *testing.T) {
+		svc, repo, _ := setup()
+
+		_, err := svc.TransferOwnership(ctx, 2, false, 1, 2)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = svc.TransferOwnership(ctx, 99, false, 1, 2)
+		assert.ErrorIs(t, err, ErrNotMember)
+
+		_, err = svc.TransferOwnership(ctx, 99, true, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleOwner, roleOf(repo, 2))
+	})
+
+	t.Run("The new owner has to be a member already", func(t *testing.T) {
+		svc, _, auditRepo := setup()
+
+		_, err := svc.TransferOwnership(ctx, 1, false, 1, 42)
+		assert.ErrorIs(t, err, ErrMemberNotFound)
+		_, err = svc.TransferOwnership(ctx, 1, false, 1, 1)
+		assert.ErrorIs(t, err, ErrAlreadyOwner)
+		_, err = svc.TransferOwnership(ctx, 1, true, 7, 2)
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+		assert.Empty(t, auditRepo.Entries)
+	})
+
+	t.Run("A failed audit write doesn't undo a committed transfer", func(t *testing.T) {
+		svc, repo, _ := setup()
+		svc.UseAudit(audit.NewService(failingAuditRepo{}))
+
+		project, err := svc.TransferOwnership(ctx, 1, false, 1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), project.OwnerID)
+		assert.Equal(t, RoleOwner, roleOf(repo, 3))
+	})
+}
+
+type failingAuditRepo struct{}
+
+func (failingAuditRepo) Create(ctx context.Context, e audit.Entry) (*audit.Entry, error) {
+	return nil, errors.New("audit log unavailable")
+}
+
+func (failingAuditRepo) List(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	return nil, errors.New("audit log unavailable")
+}
+
+func TestArchiveAndSoftDelete(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*Service, *FakeRepository) {
+		repo := NewFakeRepository()
+		repo.CreateProject(ctx, Project{Name: "Apollo", OwnerID: 1})
+		repo.AddUserToProject(ctx, 1, 1, RoleOwner)
+		repo.AddUserToProject(ctx, 1, 2, RoleMaintainer)
+		repo.AddUserToProject(ctx, 1, 3, RoleMember)
+		return NewService(repo, nil), repo
+	}
+
+	t.Run("Archived projects are hidden and read-only", func(t *testing.T) {
+		svc, _ := setup()
+		policy := NewCachedAuthorizer(svc.repo, time.Hour)
+		svc.UseAuthorizer(policy)
+
+		assert.ErrorIs(t, svc.ArchiveProject(ctx, 3, 1), ErrPermissionDenied)
+		assert.NoError(t, svc.ArchiveProject(ctx, 2, 1))
+		assert.NoError(t, svc.ArchiveProject(ctx, 2, 1))
+
+		list, _ := svc.ListProjects(ctx, 1, 0, false)
+		assert.Empty(t, list)
+		list, _ = svc.ListProjects(ctx, 1, 0, true)
+		assert.Len(t, list, 1)
+
+		assert.ErrorIs(t, policy.Authorize(ctx, 3, PermCreateTask, 1), ErrProjectArchived)
+		assert.ErrorIs(t, policy.Authorize(ctx, 3, PermCreateTask, 1), ErrForbidden)
+		assert.NoError(t, policy.Authorize(ctx, 3, PermViewTasks, 1))
+		_, err := svc.UpdateProject(ctx, 1, 1, "Renamed", "")
+		assert.ErrorIs(t, err, ErrProjectArchived)
+
+		assert.NoError(t, svc.UnarchiveProject(ctx, 1, 1))
+		assert.NoError(t, policy.Authorize(ctx, 3, PermCreateTask, 1))
+	})
+
+	t.Run("Deleted projects can be restored by the owner within the retention", func(t *testing.T) {
+		svc, _ := setup()
+
+		assert.NoError(t, svc.DeleteProject(ctx, 1, 1))
+		_, err := svc.GetProject(ctx, 1, 1)
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+
+		deleted, _ := svc.ListDeletedProjects(ctx, 1)
+		assert.Len(t, deleted, 1)
+		assert.NotNil(t, deleted[0].DeletedAt)
+
+		assert.ErrorIs(t, svc.RestoreProject(ctx, 2, 1), ErrNotRestorable)
+		assert.NoError(t, svc.RestoreProject(ctx, 1, 1))
+		_, err = svc.GetProject(ctx, 3, 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("The purge only takes projects past the retention", func(t *testing.T) {
+		svc, repo := setup()
+		repo.CreateProject(ctx, Project{Name: "Gemini", OwnerID: 1})
+		assert.NoError(t, svc.DeleteProject(ctx, 1, 1))
+
+		purged, err := svc.PurgeDeleted(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+
+		svc.UseRetention(0)
+		purged, _ = svc.PurgeDeleted(ctx)
+		assert.Equal(t, int64(1), purged)
+		assert.ErrorIs(t, svc.RestoreProject(ctx, 1, 1), ErrNotRestorable)
+
+		list, _ := svc.ListProjects(ctx, 1, 0, false)
+		assert.Len(t, list, 1)
+		assert.Equal(t, "Gemini", list[0].Name)
+	})
+}
+
+func TestCloneAndTemplates(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*Service, *FakeRepository, *FakeTemplateRepository) {
+		repo := NewFakeRepository()
+		repo.CreateProjectFrom(ctx, Project{Name: "Apollo", Description: "Moon", OwnerID: 1}, Blueprint{
+			Tasks: []TemplateTask{{Title: "Plan", Description: "Write it down"}, {Title: "Launch"}},
+			Members: []TemplateMember{
+				{UserID: 2, Role: RoleMaintainer},
+				{UserID: 3, Role: RoleViewer},
+			},
+		})
+		templates := NewFakeTemplateRepository()
+		svc := NewService(repo, nil)
+		svc.UseTemplates(templates)
+		return svc, repo, templates
+	}
+
+	t.Run("A clone copies what it is asked to", func(t *testing.T) {
+		svc, repo, _ := setup()
+
+		clone, err := svc.CloneProject(ctx, 2, 1, CloneOptions{Tasks: true, Members: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "Apollo (copy)", clone.Name)
+		assert.Equal(t, int64(2), clone.OwnerID)
+		assert.Empty(t, clone.Description)
+
+		tasks, _ := repo.ListTaskSkeleton(ctx, clone.ID)
+		assert.Equal(t, []TemplateTask{{Title: "Plan"}, {Title: "Launch"}}, tasks)
+
+		// the cloner owns the copy, the source's owner stays on as maintainer
+		policy := NewAuthorizer(repo)
+		role, _ := policy.Role(ctx, clone.ID, 2)
+		assert.Equal(t, RoleOwner, role)
+		role, _ = policy.Role(ctx, clone.ID, 1)
+		assert.Equal(t, RoleMaintainer, role)
+		role, _ = policy.Role(ctx, clone.ID, 3)
+		assert.Equal(t, RoleViewer, role)
+	})
+
+	t.Run("Copying members takes managing them", func(t *testing.T) {
+		svc, repo, _ := setup()
+
+		_, err := svc.CloneProject(ctx, 3, 1, CloneOptions{Members: true})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = svc.CloneProject(ctx, 9, 1, CloneOptions{})
+		assert.ErrorIs(t, err, ErrNotMember)
+
+		clone, err := svc.CloneProject(ctx, 3, 1, CloneOptions{Name: "Gemini", Tasks: true, Descriptions: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "Moon", clone.Description)
+		members, _ := repo.ListUsersInProject(ctx, clone.ID)
+		assert.Len(t, members, 1)
+		tasks, _ := repo.ListTaskSkeleton(ctx, clone.ID)
+		assert.Equal(t, "Write it down", tasks[0].Description)
+	})
+
+	t.Run("Templates are saved from a project and used by CreateProject", func(t *testing.T) {
+		svc, repo, templates := setup()
+
+		tmpl, err := svc.SaveTemplate(ctx, 1, 1, "", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "Apollo", tmpl.Name)
+		assert.Len(t, tmpl.Tasks, 2)
+		assert.Len(t, tmpl.Members, 2)
+
+		project, err := svc.CreateProject(ctx, 0, "Artemis", "", 1, tmpl.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Moon", project.Description)
+		members, _ := repo.ListUsersInProject(ctx, project.ID)
+		assert.Len(t, members, 3)
+		tasks, _ := repo.ListTaskSkeleton(ctx, project.ID)
+		assert.Len(t, tasks, 2)
+
+		// templates are personal
+		_, err = svc.CreateProject(ctx, 0, "Artemis", "", 2, tmpl.ID)
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+		assert.ErrorIs(t, svc.DeleteTemplate(ctx, 2, tmpl.ID), ErrTemplateNotFound)
+		assert.NoError(t, svc.DeleteTemplate(ctx, 1, tmpl.ID))
+		assert.Empty(t, templates.Templates)
+	})
+
+	t.Run("A failing blueprint leaves no project behind", func(t *testing.T) {
+		svc, repo, _ := setup()
+		before, _ := repo.GetAllByOwner(ctx, 1, true)
+
+		_, err := svc.createFrom(ctx, Project{Name: "Broken", OwnerID: 1}, Blueprint{
+			Members: []TemplateMember{{UserID: 2, Role: RoleOwner}},
+		})
+		assert.Error(t, err)
+		after, _ := repo.GetAllByOwner(ctx, 1, true)
+		assert.Equal(t, before, after)
+	})
+}
+
+// orgMembership stands in for the organizations service, user id -> organization ids
+type orgMembership map[int64][]int64
+
+func (o orgMembership) IsMember(ctx context.Context, orgID, userID int64) (bool, error) {
+	for _, id := range o[userID] {
+		if id == orgID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o orgMembership) DefaultOrganization(ctx context.Context, userID int64) (int64, error) {
+	if len(o[userID]) != 1 {
+		return 0, errors.New("no default organization")
+	}
+	return o[userID][0], nil
+}
+
+func TestOrganizationScope(t *testing.T) {
+	ctx := context.Background()
+
+	// 1 and 2 are in organization 10, 3 is only in 20, 4 is in both
+	setup := func() (*Service, *FakeRepository) {
+		repo := NewFakeRepository()
+		svc := NewService(repo, nil)
+		svc.UseOrganizations(orgMembership{1: {10}, 2: {10}, 3: {20}, 4: {10, 20}})
+		return svc, repo
+	}
+
+	t.Run("Projects go into the creator's organization", func(t *testing.T) {
+		svc, _ := setup()
+
+		project, err := svc.CreateProject(ctx, 0, "Apollo", "", 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), project.OrganizationID)
+
+		_, err = svc.CreateProject(ctx, 20, "Gemini", "", 1, 0)
+		assert.ErrorIs(t, err, ErrNotInOrganization)
+		_, err = svc.CreateProject(ctx, 0, "Gemini", "", 4, 0)
+		assert.Error(t, err)
+
+		project, err = svc.CreateProject(ctx, 20, "Gemini", "", 4, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), project.OrganizationID)
+	})
+
+	t.Run("Only people in the organization can be added", func(t *testing.T) {
+		svc, _ := setup()
+		project, _ := svc.CreateProject(ctx, 10, "Apollo", "", 1, 0)
+
+		assert.NoError(t, svc.AddUserToProject(ctx, 1, project.ID, 2, RoleMember))
+		err := svc.AddUserToProject(ctx, 1, project.ID, 3, RoleMember)
+		assert.ErrorIs(t, err, ErrNotInOrganization)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("The project list can be narrowed to one organization", func(t *testing.T) {
+		svc, _ := setup()
+		svc.CreateProject(ctx, 10, "Apollo", "", 4, 0)
+		svc.CreateProject(ctx, 20, "Gemini", "", 4, 0)
+
+		all, _ := svc.ListProjects(ctx, 4, 0, false)
+		assert.Len(t, all, 2)
+		only, _ := svc.ListProjects(ctx, 4, 20, false)
+		assert.Len(t, only, 1)
+		assert.Equal(t, "Gemini", only[0].Name)
+	})
+}
+
+// teamSource stands in for the teams repository
+type teamSource []TeamMembership
+
+func (s teamSource) ProjectTeamMembers(ctx context.Context, projectID int64) ([]TeamMembership, error) {
+	return s, nil
+}
+
+func TestTeamMembers(t *testing.T) {
+	ctx := context.Background()
+
+	// 2 is only on the project through the Design team, 3 is there directly
+	// as well
+	setup := func() (*Service, *FakeRepository) {
+		repo := NewFakeRepository()
+		repo.CreateProject(ctx, Project{Name: "Apollo", OwnerID: 1})
+		repo.AddUserToProject(ctx, 1, 1, RoleOwner)
+		repo.AddUserToProject(ctx, 1, 3, RoleViewer)
+		repo.UseTeams(teamSource{
+			{TeamID: 7, TeamName: "Design", Role: RoleMember, UserID: 2},
+			{TeamID: 7, TeamName: "Design", Role: RoleMember, UserID: 3},
+		})
+		return NewService(repo, nil), repo
+	}
+
+	t.Run("Team members count as members but are changed through the team", func(t *testing.T) {
+		svc, _ := setup()
+
+		role, _ := NewAuthorizer(svc.repo).Role(ctx, 1, 2)
+		assert.Equal(t, RoleMember, role)
+
+		assert.ErrorIs(t, svc.ChangeMemberRole(ctx, 1, 1, 2, RoleViewer), ErrThroughTeam)
+		assert.ErrorIs(t, svc.RemoveUserFromProject(1, 1, 2), ErrThroughTeam)
+		_, err := svc.TransferOwnership(ctx, 1, false, 1, 2)
+		assert.ErrorIs(t, err, ErrThroughTeam)
+	})
+
+	t.Run("Direct members can still be added, changed and removed", func(t *testing.T) {
+		svc, repo := setup()
+
+		assert.NoError(t, svc.AddUserToProject(ctx, 1, 1, 2, RoleViewer))
+		assert.Error(t, svc.AddUserToProject(ctx, 1, 1, 3, RoleViewer))
+		assert.NoError(t, svc.RemoveUserFromProject(1, 1, 3))
+
+		members, _ := repo.ListUsersInProject(ctx, 1)
+		for _, m := range members {
+			if m.ID == 3 {
+				assert.False(t, m.Direct)
+				assert.Equal(t, RoleMember, m.Role)
+			}
+		}
+	})
+}
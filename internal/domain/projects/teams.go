@@ -0,0 +1,57 @@
+package projects
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+)
+
+var ErrThroughTeam = errors.New("the user is only in the project through a team, change the team instead")
+
+// TeamRef names a team that brings a member into a project
+type TeamRef struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// TeamMembership is one person a team brings into a project, with the
+// role the team has there
+type TeamMembership struct {
+	TeamID   int64
+	TeamName string
+	Role     string
+	UserID   int64
+	Profile  profiles.Summary
+}
+
+// TeamSource lists who the teams on a project bring in, for repositories
+// that don't read team membership themselves
+type TeamSource interface {
+	ProjectTeamMembers(ctx context.Context, projectID int64) ([]TeamMembership, error)
+}
+
+// WithTeams merges the people teams bring in with the direct members.
+// Someone in several places gets the strongest of their roles.
+func WithTeams(direct []ProjectMember, teams []TeamMembership) []ProjectMember {
+	members := make([]ProjectMember, 0, len(direct))
+	index := make(map[int64]int, len(direct))
+	for _, m := range direct {
+		m.Direct = true
+		index[m.ID] = len(members)
+		members = append(members, m)
+	}
+
+	for _, t := range teams {
+		i, ok := index[t.UserID]
+		if !ok {
+			index[t.UserID] = len(members)
+			members = append(members, ProjectMember{ID: t.UserID, Profile: t.Profile, Role: t.Role})
+			i = len(members) - 1
+		} else if roleRank[t.Role] > roleRank[members[i].Role] && members[i].Role != RoleOwner {
+			members[i].Role = t.Role
+		}
+		members[i].Teams = append(members[i].Teams, TeamRef{ID: t.TeamID, Name: t.TeamName})
+	}
+	return members
+}
@@ -0,0 +1,62 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// InitialTaskStatus is what copied tasks start over with, the same as a
+// task created on the board
+const InitialTaskStatus = "TODO"
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateName     = errors.New("a template needs a name")
+)
+
+// TemplateTask is a task skeleton, without status, assignee or history
+type TemplateTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type TemplateMember struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// Blueprint is what a new project starts with besides its owner
+type Blueprint struct {
+	Tasks   []TemplateTask
+	Members []TemplateMember
+}
+
+// Template is a project skeleton saved by a user for their next projects
+type Template struct {
+	ID          int64            `json:"id"`
+	OwnerID     int64            `json:"owner_id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Tasks       []TemplateTask   `json:"tasks"`
+	Members     []TemplateMember `json:"members"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// CloneOptions picks what a clone copies from its source, the name
+// defaults to "<source> (copy)"
+type CloneOptions struct {
+	Name         string
+	Tasks        bool
+	Members      bool
+	Descriptions bool
+}
+
+// TemplateRepository only ever returns templates of the given owner
+type TemplateRepository interface {
+	CreateTemplate(ctx context.Context, t Template) (*Template, error)
+	// GetTemplate fails with ErrTemplateNotFound for someone else's template
+	GetTemplate(ctx context.Context, id, ownerID int64) (*Template, error)
+	ListTemplates(ctx context.Context, ownerID int64) ([]Template, error)
+	DeleteTemplate(ctx context.Context, id, ownerID int64) error
+}
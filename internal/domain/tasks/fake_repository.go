@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// FakeRepository implements Repository in memory for tests
+type FakeRepository struct {
+	mu         sync.RWMutex
+	tasks      map[int64]*Task
+	activities []*TaskActivity
+	nextID     int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		tasks:  make(map[int64]*Task),
+		nextID: 1,
+	}
+}
+
+func (f *FakeRepository) CreateTask(ctx context.Context, task *Task) (*Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := *task
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	f.tasks[t.ID] = &t
+	res := t
+	return &res, nil
+}
+
+func (f *FakeRepository) UpdateTask(ctx context.Context, task *Task) (*Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.tasks[task.ID]
+	if !ok {
+		return nil, errors.New("task not found")
+	}
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.Status = task.Status
+	existing.AssignedTo = task.AssignedTo
+	existing.UpdatedAt = time.Now()
+	res := *existing
+	return &res, nil
+}
+
+func (f *FakeRepository) DeleteTask(ctx context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.tasks, id)
+	return nil
+}
+
+func (f *FakeRepository) GetTaskByID(ctx context.Context, id int64) (*Task, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	t, ok := f.tasks[id]
+	if !ok {
+		return nil, errors.New("task not found")
+	}
+	res := *t
+	return &res, nil
+}
+
+func (f *FakeRepository) ListTaskByProject(ctx context.Context, projectID int64) ([]*Task, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var res []*Task
+	for _, t := range f.tasks {
+		if t.ProjectID == projectID {
+			copied := *t
+			res = append(res, &copied)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) RecordTaskActivity(ctx context.Context, activity *TaskActivity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a := *activity
+	a.ID = int64(len(f.activities) + 1)
+	a.CreatedAt = time.Now()
+	f.activities = append(f.activities, &a)
+	return nil
+}
+
+func (f *FakeRepository) GetTaskHistory(ctx context.Context, taskID int64) ([]*TaskActivity, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var res []*TaskActivity
+	for _, a := range f.activities {
+		if a.TaskID == taskID {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
@@ -3,6 +3,8 @@ package tasks
 import (
 	"context"
 	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 )
 
 // Task represents the domain model.
@@ -19,13 +21,13 @@ type Task struct {
 
 // TaskActivity represents a single history log entry.
 type TaskActivity struct {
-	ID        int64     `json:"id"`
-	TaskID    int64     `json:"task_id"`
-	UserID    int64     `json:"user_id"`
-	UserEmail string    `json:"user_email"`
-	Action    string    `json:"action"`
-	Details   string    `json:"details"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64            `json:"id"`
+	TaskID    int64            `json:"task_id"`
+	UserID    int64            `json:"user_id"`
+	User      profiles.Summary `json:"user"`
+	Action    string           `json:"action"`
+	Details   string           `json:"details"`
+	CreatedAt time.Time        `json:"created_at"`
 }
 
 type Repository interface {
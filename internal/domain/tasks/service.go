@@ -18,6 +18,7 @@ var (
 type Service struct {
 	repo        Repository
 	projectRepo projects.Repository
+	auth        *projects.Authorizer
 	hub         *ws.Hub
 }
 
@@ -25,20 +26,20 @@ func NewService(repo Repository, projectRepo projects.Repository, hub *ws.Hub) *
 	return &Service{
 		repo:        repo,
 		projectRepo: projectRepo,
+		auth:        projects.NewAuthorizer(projectRepo),
 		hub:         hub,
 	}
 }
 
-func (s *Service) CreateTask(ctx context.Context, requesterID int64, t Task) (*Task, error) {
-	//  Fetch the project to verify ownership.
-	project, err := s.projectRepo.GetByID(ctx, t.ProjectID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch project: %w", err)
-	}
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *Service) UseAuthorizer(a *projects.Authorizer) {
+	s.auth = a
+}
 
+func (s *Service) CreateTask(ctx context.Context, requesterID int64, t Task) (*Task, error) {
 	// Security Check.
-	if project.OwnerID != requesterID {
-		return nil, ErrUnauthorized
+	if err := s.auth.Authorize(ctx, requesterID, projects.PermCreateTask, t.ProjectID); err != nil {
+		return nil, err
 	}
 
 	// Save the task.
@@ -82,18 +83,18 @@ func (s *Service) UpdateTask(ctx context.Context, requesterID int64, t Task, com
 		return nil, fmt.Errorf("task not found: %w", err)
 	}
 
-	// Fetch the project to see who the owner is.
-	project, err := s.projectRepo.GetByID(ctx, existingTask.ProjectID)
+	// Authorization Check: any task for maintainers and up, members only their own.
+	role, err := s.auth.Role(ctx, existingTask.ProjectID, requesterID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify project ownership: %w", err)
+		return nil, err
+	}
+	if role == "" {
+		return nil, projects.ErrNotMember
 	}
-
-	// Authorization Check: Is requester the Owner OR the Assignee?
-	isOwner := project.OwnerID == requesterID
 	isAssignee := existingTask.AssignedTo != nil && *existingTask.AssignedTo == requesterID
-
-	if !isOwner && !isAssignee {
-		return nil, fmt.Errorf("unauthorized: you are not the owner or the assigned member")
+	if !projects.RoleAllows(role, projects.PermEditAnyTask) &&
+		!(isAssignee && projects.RoleAllows(role, projects.PermEditAssignedTask)) {
+		return nil, projects.ErrPermissionDenied
 	}
 
 	// Perform the update.
@@ -127,14 +128,9 @@ func (s *Service) DeleteTask(ctx context.Context, requesterID int64, taskID int6
 	if err != nil {
 		return fmt.Errorf("task not found: %w", err)
 	}
-	//  Fetch the project to verify ownership
-	project, err := s.projectRepo.GetByID(ctx, task.ProjectID)
-	if err != nil {
-		return fmt.Errorf("failed to verify project: %w", err)
-	}
-	// Security Check: Only the project owner can delete tasks
-	if project.OwnerID != requesterID {
-		return fmt.Errorf("unauthorized: only the project owner can delete tasks")
+	// Security Check: owners and maintainers delete tasks
+	if err := s.auth.Authorize(ctx, requesterID, projects.PermDeleteTask, task.ProjectID); err != nil {
+		return err
 	}
 	// Delete the task
 	err = s.repo.DeleteTask(ctx, taskID)
@@ -156,23 +152,9 @@ func (s *Service) GetTaskHistory(ctx context.Context, requesterID int64, taskID
 		return nil, fmt.Errorf("task not found: %w", err)
 	}
 
-	// 2. Authorization Check: Is the requester a member of this project?
-	// We use the projectRepo's membership check logic here
-	members, err := s.projectRepo.ListUsersInProject(ctx, task.ProjectID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify project membership: %w", err)
-	}
-
-	isMember := false
-	for _, m := range members {
-		if m.ID == requesterID {
-			isMember = true
-			break
-		}
-	}
-
-	if !isMember {
-		return nil, fmt.Errorf("unauthorized: you must be a project member to view history")
+	// Authorization Check: every project role can read tasks
+	if err := s.auth.Authorize(ctx, requesterID, projects.PermViewTasks, task.ProjectID); err != nil {
+		return nil, err
 	}
 
 	// Fetch and return history
@@ -182,21 +164,8 @@ func (s *Service) GetTaskHistory(ctx context.Context, requesterID int64, taskID
 // ListTasks returns all tasks for a project, but only if the requester is a member.
 func (s *Service) ListTasks(ctx context.Context, requesterID int64, projectID int64) ([]*Task, error) {
 	// Authorization: Is the user in this project?
-	members, err := s.projectRepo.ListUsersInProject(ctx, projectID)
-	if err != nil {
-		return nil, fmt.Errorf("could not verify project membership: %w", err)
-	}
-
-	isMember := false
-	for _, m := range members {
-		if m.ID == requesterID {
-			isMember = true
-			break
-		}
-	}
-
-	if !isMember {
-		return nil, fmt.Errorf("unauthorized: you are not a member of this project")
+	if err := s.auth.Authorize(ctx, requesterID, projects.PermViewTasks, projectID); err != nil {
+		return nil, err
 	}
 
 	//  Fetch the tasks
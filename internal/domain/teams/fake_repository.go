@@ -0,0 +1,196 @@
+package teams
+
+import (
+	"context"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+)
+
+type teamMemberEntry struct {
+	TeamID   int64
+	UserID   int64
+	JoinedAt time.Time
+}
+
+type projectTeamEntry struct {
+	ProjectID int64
+	TeamID    int64
+	Role      string
+	AddedAt   time.Time
+}
+
+// FakeRepository implements Repository in memory for tests. It is also the
+// projects.TeamSource of a projects.FakeRepository, see UseTeams there.
+type FakeRepository struct {
+	Teams        []Team
+	members      []teamMemberEntry
+	projectTeams []projectTeamEntry
+	nextID       int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Teams:  []Team{},
+		nextID: 1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, t Team) (*Team, error) {
+	for _, existing := range f.Teams {
+		if existing.OrganizationID == t.OrganizationID && existing.Name == t.Name {
+			return nil, ErrNameTaken
+		}
+	}
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+	f.Teams = append(f.Teams, t)
+	return &t, nil
+}
+
+func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*Team, error) {
+	for _, t := range f.Teams {
+		if t.ID == id {
+			c := t
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *FakeRepository) ListForOrganization(ctx context.Context, orgID int64) ([]Team, error) {
+	var res []Team
+	for _, t := range f.Teams {
+		if t.OrganizationID == orgID {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) Delete(ctx context.Context, id int64) error {
+	for i, t := range f.Teams {
+		if t.ID == id {
+			f.Teams = append(f.Teams[:i], f.Teams[i+1:]...)
+			break
+		}
+	}
+	// what ON DELETE CASCADE does in the database
+	var members []teamMemberEntry
+	for _, m := range f.members {
+		if m.TeamID != id {
+			members = append(members, m)
+		}
+	}
+	f.members = members
+	var links []projectTeamEntry
+	for _, pt := range f.projectTeams {
+		if pt.TeamID != id {
+			links = append(links, pt)
+		}
+	}
+	f.projectTeams = links
+	return nil
+}
+
+func (f *FakeRepository) ListMembers(ctx context.Context, teamID int64) ([]Member, error) {
+	var res []Member
+	for _, m := range f.members {
+		if m.TeamID == teamID {
+			res = append(res, Member{
+				UserID:   m.UserID,
+				Profile:  profiles.Summary{ID: m.UserID, DisplayName: "fake"},
+				JoinedAt: m.JoinedAt,
+			})
+		}
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) AddMember(ctx context.Context, teamID, userID int64) (bool, error) {
+	for _, m := range f.members {
+		if m.TeamID == teamID && m.UserID == userID {
+			return false, nil
+		}
+	}
+	f.members = append(f.members, teamMemberEntry{TeamID: teamID, UserID: userID, JoinedAt: time.Now()})
+	return true, nil
+}
+
+func (f *FakeRepository) RemoveMember(ctx context.Context, teamID, userID int64) (bool, error) {
+	for i, m := range f.members {
+		if m.TeamID == teamID && m.UserID == userID {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) ProjectIDs(ctx context.Context, teamID int64) ([]int64, error) {
+	var ids []int64
+	for _, pt := range f.projectTeams {
+		if pt.TeamID == teamID {
+			ids = append(ids, pt.ProjectID)
+		}
+	}
+	return ids, nil
+}
+
+func (f *FakeRepository) AddToProject(ctx context.Context, projectID, teamID int64, role string) (bool, error) {
+	for _, pt := range f.projectTeams {
+		if pt.ProjectID == projectID && pt.TeamID == teamID {
+			return false, nil
+		}
+	}
+	f.projectTeams = append(f.projectTeams, projectTeamEntry{ProjectID: projectID, TeamID: teamID, Role: role, AddedAt: time.Now()})
+	return true, nil
+}
+
+func (f *FakeRepository) RemoveFromProject(ctx context.Context, projectID, teamID int64) (bool, error) {
+	for i, pt := range f.projectTeams {
+		if pt.ProjectID == projectID && pt.TeamID == teamID {
+			f.projectTeams = append(f.projectTeams[:i], f.projectTeams[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) ListForProject(ctx context.Context, projectID int64) ([]ProjectTeam, error) {
+	var res []ProjectTeam
+	for _, pt := range f.projectTeams {
+		if pt.ProjectID != projectID {
+			continue
+		}
+		team, err := f.GetByID(ctx, pt.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ProjectTeam{TeamID: pt.TeamID, Name: team.Name, Role: pt.Role, AddedAt: pt.AddedAt})
+	}
+	return res, nil
+}
+
+func (f *FakeRepository) ProjectTeamMembers(ctx context.Context, projectID int64) ([]projects.TeamMembership, error) {
+	teams, err := f.ListForProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	var res []projects.TeamMembership
+	for _, t := range teams {
+		members, _ := f.ListMembers(ctx, t.TeamID)
+		for _, m := range members {
+			res = append(res, projects.TeamMembership{
+				TeamID:   t.TeamID,
+				TeamName: t.Name,
+				Role:     t.Role,
+				UserID:   m.UserID,
+				Profile:  m.Profile,
+			})
+		}
+	}
+	return res, nil
+}
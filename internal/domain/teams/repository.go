@@ -0,0 +1,53 @@
+package teams
+
+import (
+	"context"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+)
+
+// Team is a named group of an organization's members that can be added
+// to the organization's projects as a unit
+type Team struct {
+	ID             int64     `json:"id"`
+	OrganizationID int64     `json:"organization_id"`
+	Name           string    `json:"name"`
+	CreatedBy      *int64    `json:"created_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Members        []Member  `json:"members,omitempty"` // only filled in when a single team is fetched
+}
+
+type Member struct {
+	UserID   int64            `json:"user_id"`
+	Profile  profiles.Summary `json:"profile"`
+	JoinedAt time.Time        `json:"joined_at"`
+}
+
+// ProjectTeam is a team on a project, its members get Role there
+type ProjectTeam struct {
+	TeamID  int64     `json:"team_id"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type Repository interface {
+	// Create fails with ErrNameTaken if the organization has a team of that name
+	Create(ctx context.Context, t Team) (*Team, error)
+	GetByID(ctx context.Context, id int64) (*Team, error)
+	ListForOrganization(ctx context.Context, orgID int64) ([]Team, error)
+	Delete(ctx context.Context, id int64) error
+	ListMembers(ctx context.Context, teamID int64) ([]Member, error)
+	// AddMember reports false if the user already was a member
+	AddMember(ctx context.Context, teamID, userID int64) (bool, error)
+	// RemoveMember reports false if the user wasn't a member
+	RemoveMember(ctx context.Context, teamID, userID int64) (bool, error)
+	// ProjectIDs lists the projects the team is on
+	ProjectIDs(ctx context.Context, teamID int64) ([]int64, error)
+	// AddToProject reports false if the team already is on the project
+	AddToProject(ctx context.Context, projectID, teamID int64, role string) (bool, error)
+	// RemoveFromProject reports false if the team wasn't on the project
+	RemoveFromProject(ctx context.Context, projectID, teamID int64) (bool, error)
+	ListForProject(ctx context.Context, projectID int64) ([]ProjectTeam, error)
+}
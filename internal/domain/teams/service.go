@@ -0,0 +1,311 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+var (
+	ErrNotFound          = errors.New("team not found")
+	ErrInvalidName       = errors.New("team name is required")
+	ErrNameTaken         = errors.New("the organization already has a team with that name")
+	ErrNotInOrganization = errors.New("you are not a member of this team's organization")
+	ErrNotTeamManager    = errors.New("only organization admins and the team's creator can manage it")
+	ErrOutsider          = errors.New("only people in the organization can join its teams")
+	ErrAlreadyMember     = errors.New("user is already in this team")
+	ErrMemberNotFound    = errors.New("user is not in this team")
+	ErrOtherOrganization = errors.New("the team belongs to another organization than the project")
+	ErrAlreadyOnProject  = errors.New("the team is already on this project")
+	ErrNotOnProject      = errors.New("the team is not on this project")
+)
+
+// Organizations is what teams need to know about the organizations they
+// belong to
+type Organizations interface {
+	IsMember(ctx context.Context, orgID, userID int64) (bool, error)
+	IsAdmin(ctx context.Context, orgID, userID int64) (bool, error)
+}
+
+// Service manages teams and puts them on projects. Project membership
+// through a team is worked out when members are listed, so every change
+// here only has to drop the cached memberships of the team's projects.
+type Service struct {
+	repo     Repository
+	projects projects.Repository
+	policy   *projects.Authorizer
+	orgs     Organizations
+	hub      *ws.Hub
+}
+
+func NewService(repo Repository, projectRepo projects.Repository, orgs Organizations, hub *ws.Hub) *Service {
+	return &Service{
+		repo:     repo,
+		projects: projectRepo,
+		policy:   projects.NewAuthorizer(projectRepo),
+		orgs:     orgs,
+		hub:      hub,
+	}
+}
+
+// UseAuthorizer shares the policy (and its membership cache) with the project service
+func (s *Service) UseAuthorizer(a *projects.Authorizer) {
+	s.policy = a
+}
+
+// Create makes a team in the organization, any of its members can
+func (s *Service) Create(ctx context.Context, requesterID, orgID int64, name string) (*Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	if err := s.requireOrganization(ctx, orgID, requesterID); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, Team{OrganizationID: orgID, Name: name, CreatedBy: &requesterID})
+}
+
+func (s *Service) List(ctx context.Context, requesterID, orgID int64) ([]Team, error) {
+	if err := s.requireOrganization(ctx, orgID, requesterID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListForOrganization(ctx, orgID)
+}
+
+// Get returns the team with its members
+func (s *Service) Get(ctx context.Context, requesterID, teamID int64) (*Team, error) {
+	team, err := s.visibleTeam(ctx, requesterID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	team.Members, err = s.repo.ListMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// Delete removes the team, its members lose what it gave them
+func (s *Service) Delete(ctx context.Context, requesterID, teamID int64) error {
+	team, err := s.managedTeam(ctx, requesterID, teamID)
+	if err != nil {
+		return err
+	}
+	projectIDs, err := s.repo.ProjectIDs(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, team.ID); err != nil {
+		return err
+	}
+	s.forget(projectIDs)
+	s.notify(fmt.Sprintf("TEAM_DELETED:%d", team.ID))
+	return nil
+}
+
+// AddMember puts someone from the organization into the team, which gives
+// them access to all of the team's projects right away
+func (s *Service) AddMember(ctx context.Context, requesterID, teamID, userID int64) error {
+	team, err := s.managedTeam(ctx, requesterID, teamID)
+	if err != nil {
+		return err
+	}
+	inOrg, err := s.orgs.IsMember(ctx, team.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+	if !inOrg {
+		return ErrOutsider
+	}
+
+	added, err := s.repo.AddMember(ctx, team.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrAlreadyMember
+	}
+	if err := s.refresh(ctx, team.ID); err != nil {
+		return err
+	}
+	s.notify(fmt.Sprintf("TEAM_MEMBER_ADDED:%d:%d", team.ID, userID))
+	return nil
+}
+
+// RemoveMember takes someone out of the team and so out of the projects
+// they were only on through it. Members can leave on their own.
+func (s *Service) RemoveMember(ctx context.Context, requesterID, teamID, userID int64) error {
+	var team *Team
+	var err error
+	if requesterID == userID {
+		team, err = s.visibleTeam(ctx, requesterID, teamID)
+	} else {
+		team, err = s.managedTeam(ctx, requesterID, teamID)
+	}
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.repo.RemoveMember(ctx, team.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrMemberNotFound
+	}
+	if err := s.refresh(ctx, team.ID); err != nil {
+		return err
+	}
+	s.notify(fmt.Sprintf("TEAM_MEMBER_REMOVED:%d:%d", team.ID, userID))
+	return nil
+}
+
+// AddToProject gives every member of the team the role in the project.
+// Roles are limited like for single members, and the team has to belong
+// to the project's organization.
+func (s *Service) AddToProject(ctx context.Context, requesterID, projectID, teamID int64, role string) error {
+	if role == "" {
+		role = projects.RoleMember
+	}
+	if !projects.AssignableRole(role) {
+		return projects.ErrInvalidRole
+	}
+	requesterRole, err := s.policy.Require(ctx, projectID, requesterID, projects.PermManageMembers)
+	if err != nil {
+		return err
+	}
+	if !projects.CanManage(requesterRole, role) {
+		return projects.ErrPermissionDenied
+	}
+
+	project, err := s.projects.GetByID(ctx, projectID)
+	if err != nil || project == nil {
+		return fmt.Errorf("%w: %v", projects.ErrProjectNotFound, err)
+	}
+	team, err := s.repo.GetByID(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if team.OrganizationID != project.OrganizationID {
+		return ErrOtherOrganization
+	}
+
+	added, err := s.repo.AddToProject(ctx, projectID, team.ID, role)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrAlreadyOnProject
+	}
+	s.policy.Forget(projectID)
+	s.notify(fmt.Sprintf("TEAM_ADDED:%d:%d:%s", projectID, team.ID, role))
+	return nil
+}
+
+func (s *Service) RemoveFromProject(ctx context.Context, requesterID, projectID, teamID int64) error {
+	requesterRole, err := s.policy.Require(ctx, projectID, requesterID, projects.PermManageMembers)
+	if err != nil {
+		return err
+	}
+
+	onProject, err := s.repo.ListForProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	var current *ProjectTeam
+	for i := range onProject {
+		if onProject[i].TeamID == teamID {
+			current = &onProject[i]
+		}
+	}
+	if current == nil {
+		return ErrNotOnProject
+	}
+	if !projects.CanManage(requesterRole, current.Role) {
+		return projects.ErrPermissionDenied
+	}
+
+	if _, err := s.repo.RemoveFromProject(ctx, projectID, teamID); err != nil {
+		return err
+	}
+	s.policy.Forget(projectID)
+	s.notify(fmt.Sprintf("TEAM_REMOVED:%d:%d", projectID, teamID))
+	return nil
+}
+
+func (s *Service) ListForProject(ctx context.Context, requesterID, projectID int64) ([]ProjectTeam, error) {
+	if err := s.policy.Authorize(ctx, requesterID, projects.PermViewProject, projectID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListForProject(ctx, projectID)
+}
+
+// visibleTeam loads the team if the requester is in its organization
+func (s *Service) visibleTeam(ctx context.Context, requesterID, teamID int64) (*Team, error) {
+	team, err := s.repo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireOrganization(ctx, team.OrganizationID, requesterID); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// managedTeam loads the team if the requester is an admin of its
+// organization or created it
+func (s *Service) managedTeam(ctx context.Context, requesterID, teamID int64) (*Team, error) {
+	team, err := s.visibleTeam(ctx, requesterID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team.CreatedBy != nil && *team.CreatedBy == requesterID {
+		return team, nil
+	}
+	admin, err := s.orgs.IsAdmin(ctx, team.OrganizationID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		return nil, ErrNotTeamManager
+	}
+	return team, nil
+}
+
+func (s *Service) requireOrganization(ctx context.Context, orgID, userID int64) error {
+	ok, err := s.orgs.IsMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotInOrganization
+	}
+	return nil
+}
+
+// refresh drops the cached memberships of the team's projects, so the
+// change in the team counts on the next check
+func (s *Service) refresh(ctx context.Context, teamID int64) error {
+	projectIDs, err := s.repo.ProjectIDs(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	s.forget(projectIDs)
+	return nil
+}
+
+func (s *Service) forget(projectIDs []int64) {
+	for _, id := range projectIDs {
+		s.policy.Forget(id)
+	}
+}
+
+func (s *Service) notify(message string) {
+	if s.hub != nil {
+		s.hub.Broadcast <- []byte(message)
+	}
+}
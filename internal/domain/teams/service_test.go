@@ -0,0 +1,114 @@
+package teams
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeams(t *testing.T) {
+	ctx := context.Background()
+
+	// 1 admins organization 1 and owns project 1 in it, 2 and 3 are members
+	// of the organization, 4 is in another one
+	setup := func() (*Service, *projects.FakeRepository, *projects.Authorizer) {
+		orgRepo := organizations.NewFakeRepository()
+		orgRepo.Create(ctx, "Acme", 1)
+		orgRepo.AddMember(ctx, 1, 2, organizations.RoleMember)
+		orgRepo.AddMember(ctx, 1, 3, organizations.RoleMember)
+		orgRepo.Create(ctx, "Other", 4)
+		orgs := organizations.NewService(orgRepo, user.NewFakeRepository())
+
+		repo := NewFakeRepository()
+		projectRepo := projects.NewFakeRepository()
+		projectRepo.UseTeams(repo)
+		projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: 1})
+		projectRepo.AddUserToProject(ctx, 1, 1, projects.RoleOwner)
+
+		// a long cache, so a stale membership would show
+		policy := projects.NewCachedAuthorizer(projectRepo, time.Hour)
+		svc := NewService(repo, projectRepo, orgs, nil)
+		svc.UseAuthorizer(policy)
+		return svc, projectRepo, policy
+	}
+
+	t.Run("A team on a project gives its members access and stays in sync", func(t *testing.T) {
+		svc, projectRepo, policy := setup()
+
+		team, err := svc.Create(ctx, 2, 1, "Design")
+		assert.NoError(t, err)
+		assert.NoError(t, svc.AddMember(ctx, 2, team.ID, 2))
+		assert.NoError(t, svc.AddToProject(ctx, 1, 1, team.ID, projects.RoleViewer))
+
+		role, _ := policy.Role(ctx, 1, 2)
+		assert.Equal(t, projects.RoleViewer, role)
+		role, _ = policy.Role(ctx, 1, 3)
+		assert.Empty(t, role)
+
+		assert.NoError(t, svc.AddMember(ctx, 2, team.ID, 3))
+		role, _ = policy.Role(ctx, 1, 3)
+		assert.Equal(t, projects.RoleViewer, role)
+
+		members, _ := projectRepo.ListUsersInProject(ctx, 1)
+		assert.Len(t, members, 3)
+		for _, m := range members {
+			if m.ID == 3 {
+				assert.False(t, m.Direct)
+				assert.Equal(t, []projects.TeamRef{{ID: team.ID, Name: "Design"}}, m.Teams)
+			}
+		}
+
+		assert.NoError(t, svc.RemoveMember(ctx, 3, team.ID, 3))
+		role, _ = policy.Role(ctx, 1, 3)
+		assert.Empty(t, role)
+	})
+
+	t.Run("Direct members keep the stronger of their roles", func(t *testing.T) {
+		svc, projectRepo, policy := setup()
+		projectRepo.AddUserToProject(ctx, 1, 2, projects.RoleViewer)
+
+		team, _ := svc.Create(ctx, 1, 1, "Core")
+		svc.AddMember(ctx, 1, team.ID, 2)
+		assert.NoError(t, svc.AddToProject(ctx, 1, 1, team.ID, projects.RoleMaintainer))
+
+		role, _ := policy.Role(ctx, 1, 2)
+		assert.Equal(t, projects.RoleMaintainer, role)
+		direct, _ := policy.Direct(ctx, 1, 2)
+		assert.True(t, direct)
+
+		assert.NoError(t, svc.RemoveFromProject(ctx, 1, 1, team.ID))
+		role, _ = policy.Role(ctx, 1, 2)
+		assert.Equal(t, projects.RoleViewer, role)
+	})
+
+	t.Run("Teams stay inside their organization", func(t *testing.T) {
+		svc, _, _ := setup()
+		team, _ := svc.Create(ctx, 1, 1, "Core")
+
+		_, err := svc.Create(ctx, 1, 1, "Core")
+		assert.ErrorIs(t, err, ErrNameTaken)
+		_, err = svc.Create(ctx, 4, 1, "Intruders")
+		assert.ErrorIs(t, err, ErrNotInOrganization)
+		assert.ErrorIs(t, svc.AddMember(ctx, 1, team.ID, 4), ErrOutsider)
+		assert.ErrorIs(t, svc.AddMember(ctx, 2, team.ID, 3), ErrNotTeamManager)
+
+		other, _ := svc.Create(ctx, 4, 2, "Elsewhere")
+		assert.ErrorIs(t, svc.AddToProject(ctx, 1, 1, other.ID, ""), ErrOtherOrganization)
+	})
+
+	t.Run("Only member managers put teams on projects", func(t *testing.T) {
+		svc, projectRepo, _ := setup()
+		projectRepo.AddUserToProject(ctx, 1, 2, projects.RoleMember)
+		team, _ := svc.Create(ctx, 1, 1, "Core")
+
+		assert.ErrorIs(t, svc.AddToProject(ctx, 2, 1, team.ID, ""), projects.ErrForbidden)
+		assert.ErrorIs(t, svc.AddToProject(ctx, 1, 1, team.ID, projects.RoleOwner), projects.ErrInvalidRole)
+		assert.NoError(t, svc.AddToProject(ctx, 1, 1, team.ID, ""))
+		assert.ErrorIs(t, svc.AddToProject(ctx, 1, 1, team.ID, ""), ErrAlreadyOnProject)
+	})
+}
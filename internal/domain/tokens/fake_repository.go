@@ -0,0 +1,150 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeRepository implements Repository for testing without a real DB
+type FakeRepository struct {
+	mu       sync.Mutex
+	Tokens   []RefreshToken
+	Sessions []Session
+	nextID   int64
+}
+
+func NewFakeRepository() *FakeRepository {
+	return &FakeRepository{
+		Tokens:   []RefreshToken{},
+		Sessions: []Session{},
+		nextID:   1,
+	}
+}
+
+func (f *FakeRepository) Create(ctx context.Context, t RefreshToken) (*RefreshToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+
+	f.Tokens = append(f.Tokens, t)
+	return &t, nil
+}
+
+func (f *FakeRepository) GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.Tokens {
+		if t.TokenHash == tokenHash {
+			c := t
+			return &c, nil
+		}
+	}
+	return nil, errors.New("refresh token not found")
+}
+
+func (f *FakeRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.Tokens {
+		if f.Tokens[i].ID == id {
+			if f.Tokens[i].UsedAt != nil || f.Tokens[i].RevokedAt != nil {
+				return false, nil
+			}
+			now := time.Now()
+			f.Tokens[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakeRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for i := range f.Tokens {
+		if f.Tokens[i].FamilyID == familyID && f.Tokens[i].RevokedAt == nil {
+			f.Tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *FakeRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for i := range f.Tokens {
+		if f.Tokens[i].UserID == userID && f.Tokens[i].RevokedAt == nil {
+			f.Tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *FakeRepository) CreateSession(ctx context.Context, s Session) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s.ID = int64(len(f.Sessions) + 1)
+	s.CreatedAt = time.Now()
+	s.LastSeenAt = s.CreatedAt
+
+	f.Sessions = append(f.Sessions, s)
+	return &s, nil
+}
+
+func (f *FakeRepository) GetSession(ctx context.Context, id int64) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.Sessions {
+		if s.ID == id {
+			c := s
+			return &c, nil
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+func (f *FakeRepository) TouchSession(ctx context.Context, familyID string, device Device) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.Sessions {
+		if f.Sessions[i].FamilyID == familyID {
+			f.Sessions[i].LastSeenAt = time.Now()
+			if device.UserAgent != "" {
+				f.Sessions[i].UserAgent = device.UserAgent
+			}
+			if device.IPAddress != "" {
+				f.Sessions[i].IPAddress = device.IPAddress
+			}
+			c := f.Sessions[i]
+			return &c, nil
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+func (f *FakeRepository) ListActiveSessions(ctx context.Context, userID int64) ([]Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var res []Session
+	for _, s := range f.Sessions {
+		if s.UserID != userID {
+			continue
+		}
+		for _, t := range f.Tokens {
+			if t.FamilyID == s.FamilyID && t.UsedAt == nil && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt) {
+				res = append(res, s)
+				break
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].LastSeenAt.After(res[j].LastSeenAt) })
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package tokens
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken is one link in a rotation chain.
+// All tokens that descend from the same login share a FamilyID.
+type RefreshToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	FamilyID  string     `json:"family_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// not stored with the token, Issue and Rotate fill it in from the session
+	SessionID int64 `json:"-"`
+}
+
+// Session is one login on one device. It lives as long as its refresh
+// token family, so revoking the family ends the session.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	FamilyID   string    `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Device is what we know about the client at login and refresh
+type Device struct {
+	UserAgent string
+	IPAddress string
+}
+
+type Repository interface {
+	Create(ctx context.Context, t RefreshToken) (*RefreshToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkUsed returns false if the token was already used or revoked,
+	// the check and the update happen in one statement so two parallel
+	// refreshes can't both win
+	MarkUsed(ctx context.Context, id int64) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+
+	CreateSession(ctx context.Context, s Session) (*Session, error)
+	GetSession(ctx context.Context, id int64) (*Session, error)
+	// TouchSession records activity on the session of a token family
+	TouchSession(ctx context.Context, familyID string, device Device) (*Session, error)
+	// ListActiveSessions skips sessions whose family was revoked or expired
+	ListActiveSessions(ctx context.Context, userID int64) ([]Session, error)
+}
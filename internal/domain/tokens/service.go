@@ -0,0 +1,176 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// long enough for any real browser, short enough not to store junk
+const maxUserAgent = 512
+
+type Service struct {
+	repo Repository
+	ttl  time.Duration
+}
+
+func NewService(repo Repository, ttl time.Duration) *Service {
+	return &Service{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Issue starts a new token family and the session that goes with it,
+// called once per successful login.
+// The raw token is returned to the caller and never stored.
+func (s *Service) Issue(ctx context.Context, userID int64, device Device) (string, *RefreshToken, error) {
+	familyID, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	device = cleanDevice(device)
+	session, err := s.repo.CreateSession(ctx, Session{
+		UserID:    userID,
+		FamilyID:  familyID,
+		UserAgent: device.UserAgent,
+		IPAddress: device.IPAddress,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	raw, token, err := s.issueInFamily(ctx, userID, familyID)
+	if err != nil {
+		return "", nil, err
+	}
+	token.SessionID = session.ID
+	return raw, token, nil
+}
+
+// Rotate swaps a refresh token for a new one in the same family and
+// marks the session as seen from the given device.
+// Presenting a token that was already used means somebody else has a copy,
+// so the whole family is revoked and both parties have to log in again.
+func (s *Service) Rotate(ctx context.Context, raw string, device Device) (string, *RefreshToken, error) {
+	current, err := s.repo.GetByHash(ctx, auth.HashToken(raw))
+	if err != nil || current == nil {
+		return "", nil, ErrInvalidRefreshToken
+	}
+
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return "", nil, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		return "", nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return "", nil, ErrInvalidRefreshToken
+	}
+
+	ok, err := s.repo.MarkUsed(ctx, current.ID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	if !ok {
+		// lost the race against a parallel refresh with the same token
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return "", nil, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		return "", nil, ErrRefreshTokenReused
+	}
+
+	rawNext, next, err := s.issueInFamily(ctx, current.UserID, current.FamilyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	session, err := s.repo.TouchSession(ctx, current.FamilyID, cleanDevice(device))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	next.SessionID = session.ID
+	return rawNext, next, nil
+}
+
+// Revoke ends the session the given token belongs to (logout).
+// userID guards against revoking somebody else's family.
+func (s *Service) Revoke(ctx context.Context, userID int64, raw string) error {
+	current, err := s.repo.GetByHash(ctx, auth.HashToken(raw))
+	if err != nil || current == nil || current.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return s.repo.RevokeFamily(ctx, current.FamilyID)
+}
+
+// RevokeAll ends every session of a user ("log out everywhere")
+func (s *Service) RevokeAll(ctx context.Context, userID int64) error {
+	return s.repo.RevokeAllForUser(ctx, userID)
+}
+
+// Sessions lists where the user is logged in, most recently active first
+func (s *Service) Sessions(ctx context.Context, userID int64) ([]Session, error) {
+	return s.repo.ListActiveSessions(ctx, userID)
+}
+
+// Session looks up one of the user's sessions, for when a caller needs to
+// know when the login behind an access token happened
+func (s *Service) Session(ctx context.Context, userID, sessionID int64) (*Session, error) {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil || session == nil || session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// RevokeSession ends one session, e.g. of a lost laptop. Access tokens
+// already handed out to it stay valid until the caller revokes them too.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID int64) (*Session, error) {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil || session == nil || session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	if err := s.repo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return session, nil
+}
+
+func (s *Service) issueInFamily(ctx context.Context, userID int64, familyID string) (string, *RefreshToken, error) {
+	raw, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	saved, err := s.repo.Create(ctx, RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: auth.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return raw, saved, nil
+}
+
+func cleanDevice(d Device) Device {
+	if len(d.UserAgent) > maxUserAgent {
+		// don't leave half a rune at the end, postgres refuses invalid utf-8
+		d.UserAgent = strings.ToValidUTF8(d.UserAgent[:maxUserAgent], "")
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package tokens
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rotation issues a new token in the same family", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		raw, first, err := svc.Issue(ctx, 1, Device{})
+		assert.NoError(t, err)
+
+		rotated, second, err := svc.Rotate(ctx, raw, Device{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, raw, rotated)
+		assert.Equal(t, first.FamilyID, second.FamilyID)
+		assert.Equal(t, int64(1), second.UserID)
+	})
+
+	t.Run("Reusing a rotated token revokes the whole family", func(t *testing.T) {
+		repo := NewFakeRepository()
+		svc := NewService(repo, time.Hour)
+
+		raw, _, _ := svc.Issue(ctx, 1, Device{})
+		rotated, _, err := svc.Rotate(ctx, raw, Device{})
+		assert.NoError(t, err)
+
+		// an attacker replays the old token
+		_, _, err = svc.Rotate(ctx, raw, Device{})
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+		// the legitimate client's newer token is dead too
+		_, _, err = svc.Rotate(ctx, rotated, Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Expired tokens are rejected", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), -time.Minute)
+
+		raw, _, _ := svc.Issue(ctx, 1, Device{})
+		_, _, err := svc.Rotate(ctx, raw, Device{})
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+
+	t.Run("Logout only works on your own tokens", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		raw, _, _ := svc.Issue(ctx, 1, Device{})
+		assert.ErrorIs(t, svc.Revoke(ctx, 2, raw), ErrInvalidRefreshToken)
+		assert.NoError(t, svc.Revoke(ctx, 1, raw))
+
+		_, _, err := svc.Rotate(ctx, raw, Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("RevokeAll logs out every session", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		laptop, _, _ := svc.Issue(ctx, 1, Device{})
+		phone, _, _ := svc.Issue(ctx, 1, Device{})
+		other, _, _ := svc.Issue(ctx, 2, Device{})
+
+		assert.NoError(t, svc.RevokeAll(ctx, 1))
+
+		_, _, err := svc.Rotate(ctx, laptop, Device{})
+		assert.Error(t, err)
+		_, _, err = svc.Rotate(ctx, phone, Device{})
+		assert.Error(t, err)
+		_, _, err = svc.Rotate(ctx, other, Device{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Each login is a session that follows its rotations", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		raw, first, err := svc.Issue(ctx, 1, Device{UserAgent: "Firefox", IPAddress: "10.0.0.1"})
+		assert.NoError(t, err)
+		assert.NotZero(t, first.SessionID)
+
+		_, second, err := svc.Rotate(ctx, raw, Device{UserAgent: "Firefox", IPAddress: "10.0.0.2"})
+		assert.NoError(t, err)
+		assert.Equal(t, first.SessionID, second.SessionID)
+
+		sessions, err := svc.Sessions(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, "10.0.0.2", sessions[0].IPAddress)
+	})
+
+	t.Run("RevokeSession ends only that session and only for its owner", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		laptop, lost, _ := svc.Issue(ctx, 1, Device{UserAgent: "laptop"})
+		phone, _, _ := svc.Issue(ctx, 1, Device{UserAgent: "phone"})
+
+		_, err := svc.RevokeSession(ctx, 2, lost.SessionID)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		session, err := svc.RevokeSession(ctx, 1, lost.SessionID)
+		assert.NoError(t, err)
+		assert.Equal(t, "laptop", session.UserAgent)
+
+		_, _, err = svc.Rotate(ctx, laptop, Device{})
+		assert.Error(t, err)
+		_, _, err = svc.Rotate(ctx, phone, Device{})
+		assert.NoError(t, err)
+
+		sessions, _ := svc.Sessions(ctx, 1)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, "phone", sessions[0].UserAgent)
+	})
+
+	t.Run("Long user agents are cut without breaking utf-8", func(t *testing.T) {
+		svc := NewService(NewFakeRepository(), time.Hour)
+
+		_, _, err := svc.Issue(ctx, 1, Device{UserAgent: "a" + strings.Repeat("é", maxUserAgent)})
+		assert.NoError(t, err)
+
+		sessions, _ := svc.Sessions(ctx, 1)
+		assert.LessOrEqual(t, len(sessions[0].UserAgent), maxUserAgent)
+		assert.True(t, utf8.ValidString(sessions[0].UserAgent))
+	})
+}
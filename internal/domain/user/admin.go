@@ -0,0 +1,278 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+)
+
+var (
+	ErrInvalidStatus = errors.New("invalid account status")
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrSelfAction    = errors.New("admins can't change their own status or role")
+)
+
+// AdminService is everything behind RequireRole("admin").
+// Each change lands in the audit trail once it's made. A failed audit write
+// is only logged, the change is already in place and failing the call then
+// would only make the admin retry it.
+type AdminService struct {
+	users        Repository
+	audit        *audit.Service
+	sessions     *tokens.Service
+	jwt          *auth.JWTManager
+	resets       *PasswordResetService
+	verification *VerificationService
+	twoFactor    *TwoFactorService
+	hub          *ws.Hub
+	pats         *pats.Service
+}
+
+func NewAdminService(
+	users Repository,
+	audit *audit.Service,
+	sessions *tokens.Service,
+	jwt *auth.JWTManager,
+	resets *PasswordResetService,
+	verification *VerificationService,
+	twoFactor *TwoFactorService,
+	hub *ws.Hub,
+) *AdminService {
+	return &AdminService{
+		users:        users,
+		audit:        audit,
+		sessions:     sessions,
+		jwt:          jwt,
+		resets:       resets,
+		verification: verification,
+		twoFactor:    twoFactor,
+		hub:          hub,
+	}
+}
+
+// UsePersonalAccessTokens makes forced resets and revoking sessions end the
+// user's personal access tokens as well
+func (s *AdminService) UsePersonalAccessTokens(p *pats.Service) {
+	s.pats = p
+}
+
+func (s *AdminService) ListUsers(ctx context.Context, f UserFilter) ([]User, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return s.users.SearchUsers(ctx, f)
+}
+
+// SetStatus suspends, bans or reactivates an account.
+// Anything but active also ends the user's sessions.
+func (s *AdminService) SetStatus(ctx context.Context, actorID, userID int64, status string) (*User, error) {
+	switch status {
+	case StatusActive, StatusSuspended, StatusBanned:
+	default:
+		return nil, ErrInvalidStatus
+	}
+	if actorID == userID {
+		return nil, ErrSelfAction
+	}
+
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// there's nothing left of a deleted account to bring back
+	if u.Status == StatusDeleted {
+		return nil, ErrInvalidStatus
+	}
+	previous := u.Status
+
+	if err := s.users.UpdateStatus(ctx, userID, status); err != nil {
+		return nil, fmt.Errorf("failed to update status: %w", err)
+	}
+	u.Status = status
+	if s.jwt != nil {
+		s.jwt.ForgetAccount(userID)
+	}
+
+	if status != StatusActive {
+		if err := s.endSessions(ctx, userID); err != nil {
+			return nil, err
+		}
+		// open sockets don't go through the middleware again, kick them now
+		if s.hub != nil {
+			s.hub.DisconnectUser(userID, "account "+status)
+		}
+	}
+
+	details := fmt.Sprintf("status %s -> %s", previous, status)
+	s.record(ctx, actorID, "user.status_changed", "user", userID, details)
+	return u, nil
+}
+
+// SetRole promotes or demotes a user, takes effect on their next request
+func (s *AdminService) SetRole(ctx context.Context, actorID, userID int64, role string) (*User, error) {
+	if role != RoleUser && role != RoleAdmin {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID {
+		return nil, ErrSelfAction
+	}
+
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	previous := u.Role
+
+	if err := s.users.UpdateRole(ctx, userID, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	u.Role = role
+	if s.jwt != nil {
+		s.jwt.ForgetAccount(userID)
+	}
+
+	details := fmt.Sprintf("role %s -> %s", previous, role)
+	s.record(ctx, actorID, "user.role_changed", "user", userID, details)
+	return u, nil
+}
+
+// ForcePasswordReset locks the current password out, ends every session
+// and mails the user a reset link
+func (s *AdminService) ForcePasswordReset(ctx context.Context, actorID, userID int64) error {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// a random hash nobody knows the password for
+	junk, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(junk)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, userID, hash); err != nil {
+		return fmt.Errorf("failed to clear password: %w", err)
+	}
+
+	if err := s.endSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.revokePersonalAccessTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.resets.RequestReset(ctx, u.Email); err != nil {
+		return fmt.Errorf("password cleared but reset mail failed: %w", err)
+	}
+
+	s.record(ctx, actorID, "user.password_reset_forced", "user", userID, "")
+	return nil
+}
+
+// RevokeSessions logs the user out everywhere
+func (s *AdminService) RevokeSessions(ctx context.Context, actorID, userID int64) error {
+	if err := s.endSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.revokePersonalAccessTokens(ctx, userID); err != nil {
+		return err
+	}
+	s.record(ctx, actorID, "user.sessions_revoked", "user", userID, "")
+	return nil
+}
+
+// RevokeToken kills a single access token by its jti
+func (s *AdminService) RevokeToken(ctx context.Context, actorID int64, jti string, userID int64) error {
+	if err := s.jwt.RevokeTokenID(ctx, jti, userID); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.record(ctx, actorID, "token.revoked", "user", userID, "jti "+jti)
+	return nil
+}
+
+func (s *AdminService) ResendVerification(ctx context.Context, actorID, userID int64) error {
+	if err := s.verification.Resend(ctx, userID); err != nil {
+		return err
+	}
+	s.record(ctx, actorID, "user.verification_resent", "user", userID, "")
+	return nil
+}
+
+func (s *AdminService) ForceVerify(ctx context.Context, actorID, userID int64) (*User, error) {
+	u, err := s.verification.ForceVerify(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, actorID, "user.force_verified", "user", userID, "")
+	return u, nil
+}
+
+// SetTwoFactorPolicy makes 2fa mandatory (or optional again) for a role.
+// Users of that role without 2fa are walked through enrollment at their next login.
+func (s *AdminService) SetTwoFactorPolicy(ctx context.Context, actorID int64, role string, required bool) error {
+	if err := s.twoFactor.SetRequired(ctx, role, required); err != nil {
+		return err
+	}
+	details := fmt.Sprintf("role %s required %t", role, required)
+	s.record(ctx, actorID, "2fa.policy_changed", "role", 0, details)
+	return nil
+}
+
+func (s *AdminService) TwoFactorPolicies(ctx context.Context) ([]TwoFactorPolicy, error) {
+	return s.twoFactor.ListPolicies(ctx)
+}
+
+// record writes the audit entry of a change that's already made
+func (s *AdminService) record(ctx context.Context, actorID int64, action, targetType string, targetID int64, details string) {
+	if err := s.audit.Record(ctx, actorID, action, targetType, targetID, details); err != nil {
+		log.Printf("failed to audit %s by %d on %s %d: %v", action, actorID, targetType, targetID, err)
+	}
+}
+
+func (s *AdminService) getUser(ctx context.Context, userID int64) (*User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (s *AdminService) endSessions(ctx context.Context, userID int64) error {
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAll(ctx, userID); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+	if s.jwt != nil {
+		if err := s.jwt.RevokeUserTokens(ctx, userID); err != nil {
+			return fmt.Errorf("failed to revoke access tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+// revokePersonalAccessTokens is kept out of endSessions, suspending an
+// account already locks its tokens out and reactivating it shouldn't break
+// the user's scripts
+func (s *AdminService) revokePersonalAccessTokens(ctx context.Context, userID int64) error {
+	if s.pats == nil {
+		return nil
+	}
+	if err := s.pats.RevokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke personal access tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*AdminService, *FakeRepository, *audit.FakeRepository, *tokens.Service) {
+		users := NewFakeRepository()
+		users.Create(ctx, User{Email: "admin@example.com", Role: RoleAdmin, Status: StatusActive})
+		users.Create(ctx, User{Email: "player@example.com", Role: RoleUser, Status: StatusActive})
+
+		auditRepo := audit.NewFakeRepository()
+		sessions := tokens.NewService(tokens.NewFakeRepository(), time.Hour)
+		mailer := mail.NewLogMailer(&bytes.Buffer{})
+		resets := NewPasswordResetService(users, NewFakePasswordResetRepository(), mailer, sessions, nil, time.Hour, "")
+		verification := NewVerificationService(users, mailer, auth.NewJWTManager("test", time.Hour), time.Hour, "")
+
+		svc := NewAdminService(users, audit.NewService(auditRepo), sessions, nil, resets, verification, nil, nil)
+		return svc, users, auditRepo, sessions
+	}
+
+	t.Run("Suspending a user ends their sessions and is audited", func(t *testing.T) {
+		svc, users, auditRepo, sessions := setup()
+		refresh, _, _ := sessions.Issue(ctx, 2, tokens.Device{})
+
+		u, err := svc.SetStatus(ctx, 1, 2, StatusSuspended)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusSuspended, u.Status)
+
+		stored, _ := users.GetByID(ctx, 2)
+		assert.Equal(t, StatusSuspended, stored.Status)
+
+		_, _, err = sessions.Rotate(ctx, refresh, tokens.Device{})
+		assert.Error(t, err)
+
+		if assert.Len(t, auditRepo.Entries, 1) {
+			e := auditRepo.Entries[0]
+			assert.Equal(t, int64(1), e.ActorID)
+			assert.Equal(t, "user.status_changed", e.Action)
+			assert.Equal(t, int64(2), e.TargetID)
+		}
+	})
+
+	t.Run("Role changes are validated and audited", func(t *testing.T) {
+		svc, _, auditRepo, _ := setup()
+
+		_, err := svc.SetRole(ctx, 1, 2, "owner")
+		assert.ErrorIs(t, err, ErrInvalidRole)
+
+		u, err := svc.SetRole(ctx, 1, 2, RoleAdmin)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleAdmin, u.Role)
+		assert.Len(t, auditRepo.Entries, 1)
+	})
+
+	t.Run("Admins can't act on themselves", func(t *testing.T) {
+		svc, _, auditRepo, _ := setup()
+
+		_, err := svc.SetStatus(ctx, 1, 1, StatusBanned)
+		assert.ErrorIs(t, err, ErrSelfAction)
+		_, err = svc.SetRole(ctx, 1, 1, RoleUser)
+		assert.ErrorIs(t, err, ErrSelfAction)
+		assert.Empty(t, auditRepo.Entries)
+	})
+
+	t.Run("Unknown users are reported", func(t *testing.T) {
+		svc, _, _, _ := setup()
+
+		_, err := svc.SetStatus(ctx, 1, 99, StatusBanned)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("ListUsers filters by status", func(t *testing.T) {
+		svc, _, _, _ := setup()
+		svc.SetStatus(ctx, 1, 2, StatusBanned)
+
+		banned, err := svc.ListUsers(ctx, UserFilter{Status: StatusBanned})
+		assert.NoError(t, err)
+		if assert.Len(t, banned, 1) {
+			assert.Equal(t, "player@example.com", banned[0].Email)
+		}
+	})
+
+	t.Run("A failed audit write doesn't fail a change that's already made", func(t *testing.T) {
+		svc, users, _, _ := setup()
+		svc.audit = audit.NewService(failingAuditRepo{})
+
+		u, err := svc.SetStatus(ctx, 1, 2, StatusSuspended)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusSuspended, u.Status)
+		stored, _ := users.GetByID(ctx, 2)
+		assert.Equal(t, StatusSuspended, stored.Status)
+
+		assert.NoError(t, svc.RevokeSessions(ctx, 1, 2))
+	})
+}
+
+type failingAuditRepo struct{}
+
+func (failingAuditRepo) Create(ctx context.Context, e audit.Entry) (*audit.Entry, error) {
+	return nil, errors.New("audit log unavailable")
+}
+
+func (failingAuditRepo) List(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	return nil, errors.New("audit log unavailable")
+}
@@ -2,12 +2,16 @@ package user
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 )
 
 // FakeRepository implements Repository for testing without a real DB
 type FakeRepository struct {
 	Users []User
+	// Organizations holds each user's organization ids, for ListUsersVisibleTo
+	Organizations map[int64][]int64
 }
 
 func NewFakeRepository() *FakeRepository {
@@ -51,9 +55,33 @@ func (f *FakeRepository) GetByEmail(ctx context.Context, email string) (*User, e
 	return nil, ErrInvalidCredentials
 }
 
-func (f *FakeRepository) ListUsers(ctx context.Context) ([]UserListRow, error) {
+func (f *FakeRepository) GetByID(ctx context.Context, id int64) (*User, error) {
+	for _, u := range f.Users {
+		if u.ID == id {
+			c := u
+			return &c, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (f *FakeRepository) ListUsersVisibleTo(ctx context.Context, viewerID int64) ([]UserListRow, error) {
+	shared := func(userID int64) bool {
+		for _, a := range f.Organizations[viewerID] {
+			for _, b := range f.Organizations[userID] {
+				if a == b {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
 	var result []UserListRow
 	for _, u := range f.Users {
+		if !shared(u.ID) {
+			continue
+		}
 		result = append(result, UserListRow{
 			ID:    u.ID,
 			Email: u.Email,
@@ -61,3 +89,243 @@ func (f *FakeRepository) ListUsers(ctx context.Context) ([]UserListRow, error) {
 	}
 	return result, nil
 }
+
+func (f *FakeRepository) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	for i := range f.Users {
+		if f.Users[i].ID == userID {
+			f.Users[i].PasswordHash = passwordHash
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (f *FakeRepository) UpdateStatus(ctx context.Context, userID int64, status string) error {
+	for i := range f.Users {
+		if f.Users[i].ID == userID {
+			f.Users[i].Status = status
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (f *FakeRepository) UpdateRole(ctx context.Context, userID int64, role string) error {
+	for i := range f.Users {
+		if f.Users[i].ID == userID {
+			f.Users[i].Role = role
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (f *FakeRepository) SearchUsers(ctx context.Context, filter UserFilter) ([]User, error) {
+	var res []User
+	for _, u := range f.Users {
+		if filter.Status != "" && u.Status != filter.Status {
+			continue
+		}
+		if filter.Role != "" && u.Role != filter.Role {
+			continue
+		}
+		if filter.Email != "" && !strings.Contains(strings.ToLower(u.Email), strings.ToLower(filter.Email)) {
+			continue
+		}
+		res = append(res, u)
+	}
+
+	if filter.Offset >= len(res) {
+		return []User{}, nil
+	}
+	res = res[filter.Offset:]
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+	return res, nil
+}
+
+// FakePasswordResetRepository implements PasswordResetRepository in memory
+type FakePasswordResetRepository struct {
+	Tokens []PasswordResetToken
+	nextID int64
+}
+
+func NewFakePasswordResetRepository() *FakePasswordResetRepository {
+	return &FakePasswordResetRepository{
+		Tokens: []PasswordResetToken{},
+		nextID: 1,
+	}
+}
+
+func (f *FakePasswordResetRepository) Create(ctx context.Context, t PasswordResetToken) (*PasswordResetToken, error) {
+	t.ID = f.nextID
+	f.nextID++
+	t.CreatedAt = time.Now()
+	f.Tokens = append(f.Tokens, t)
+	return &t, nil
+}
+
+func (f *FakePasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	for _, t := range f.Tokens {
+		if t.TokenHash == tokenHash {
+			c := t
+			return &c, nil
+		}
+	}
+	return nil, errors.New("reset token not found")
+}
+
+func (f *FakePasswordResetRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	for i := range f.Tokens {
+		if f.Tokens[i].ID == id {
+			if f.Tokens[i].UsedAt != nil {
+				return false, nil
+			}
+			now := time.Now()
+			f.Tokens[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *FakePasswordResetRepository) InvalidateForUser(ctx context.Context, userID int64) error {
+	now := time.Now()
+	for i := range f.Tokens {
+		if f.Tokens[i].UserID == userID && f.Tokens[i].UsedAt == nil {
+			f.Tokens[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// FakeTwoFactorRepository implements TwoFactorRepository in memory
+type FakeTwoFactorRepository struct {
+	Secrets       map[int64]*TOTPSecret
+	RecoveryCodes map[int64]map[string]bool // user id -> hash -> used
+	Policies      map[string]bool
+}
+
+func NewFakeTwoFactorRepository() *FakeTwoFactorRepository {
+	return &FakeTwoFactorRepository{
+		Secrets:       map[int64]*TOTPSecret{},
+		RecoveryCodes: map[int64]map[string]bool{},
+		Policies:      map[string]bool{},
+	}
+}
+
+func (f *FakeTwoFactorRepository) SaveSecret(ctx context.Context, userID int64, secret string) (*TOTPSecret, error) {
+	s := &TOTPSecret{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	f.Secrets[userID] = s
+	c := *s
+	return &c, nil
+}
+
+func (f *FakeTwoFactorRepository) GetSecret(ctx context.Context, userID int64) (*TOTPSecret, error) {
+	s, ok := f.Secrets[userID]
+	if !ok {
+		return nil, errors.New("totp secret not found")
+	}
+	c := *s
+	return &c, nil
+}
+
+func (f *FakeTwoFactorRepository) EnableSecret(ctx context.Context, userID int64) error {
+	if s, ok := f.Secrets[userID]; ok {
+		now := time.Now()
+		s.EnabledAt = &now
+	}
+	return nil
+}
+
+func (f *FakeTwoFactorRepository) DeleteSecret(ctx context.Context, userID int64) error {
+	delete(f.Secrets, userID)
+	return nil
+}
+
+func (f *FakeTwoFactorRepository) UseStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	s, ok := f.Secrets[userID]
+	if !ok || s.LastUsedStep >= step {
+		return false, nil
+	}
+	s.LastUsedStep = step
+	return true, nil
+}
+
+func (f *FakeTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	codes := map[string]bool{}
+	for _, h := range codeHashes {
+		codes[h] = false
+	}
+	f.RecoveryCodes[userID] = codes
+	return nil
+}
+
+func (f *FakeTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	used, ok := f.RecoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	f.RecoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (f *FakeTwoFactorRepository) IsRequired(ctx context.Context, role string) (bool, error) {
+	return f.Policies[role], nil
+}
+
+func (f *FakeTwoFactorRepository) SetRequired(ctx context.Context, role string, required bool) error {
+	f.Policies[role] = required
+	return nil
+}
+
+func (f *FakeTwoFactorRepository) ListPolicies(ctx context.Context) ([]TwoFactorPolicy, error) {
+	policies := []TwoFactorPolicy{}
+	for role, required := range f.Policies {
+		policies = append(policies, TwoFactorPolicy{Role: role, Required: required})
+	}
+	return policies, nil
+}
+
+// FakeIdentityRepository implements IdentityRepository for testing without a real DB
+type FakeIdentityRepository struct {
+	Identities []ExternalIdentity
+}
+
+func NewFakeIdentityRepository() *FakeIdentityRepository {
+	return &FakeIdentityRepository{Identities: []ExternalIdentity{}}
+}
+
+func (f *FakeIdentityRepository) GetIdentity(ctx context.Context, issuer, subject string) (*ExternalIdentity, error) {
+	for _, i := range f.Identities {
+		if i.Issuer == issuer && i.Subject == subject {
+			c := i
+			return &c, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+func (f *FakeIdentityRepository) CreateIdentity(ctx context.Context, identity ExternalIdentity) (*ExternalIdentity, error) {
+	for _, i := range f.Identities {
+		if i.Issuer == identity.Issuer && i.Subject == identity.Subject {
+			return nil, errors.New("identity already linked")
+		}
+	}
+	identity.ID = int64(len(f.Identities) + 1)
+	identity.CreatedAt = time.Now()
+	f.Identities = append(f.Identities, identity)
+	return &identity, nil
+}
+
+func (f *FakeIdentityRepository) TouchIdentity(ctx context.Context, id int64, email string) error {
+	for i := range f.Identities {
+		if f.Identities[i].ID == id {
+			now := time.Now()
+			f.Identities[i].LastLoginAt = &now
+			f.Identities[i].Email = email
+		}
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/oidc"
+)
+
+const actionOIDCLogin = "oidc_login"
+
+var (
+	ErrInvalidOIDCState  = errors.New("invalid or expired sign-in attempt")
+	ErrOIDCLoginFailed   = errors.New("identity provider sign-in failed")
+	ErrOIDCEmailRequired = errors.New("identity provider did not return an email address")
+	// the provider doesn't vouch for the address, so it can't be linked or signed up with
+	ErrOIDCEmailNotVerified = errors.New("email address is not verified by the identity provider")
+)
+
+// OIDCProvider is the part of oidc.Client the login flow needs
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(state, nonce, codeChallenge string) string
+	Exchange(ctx context.Context, code, verifier string) (*oidc.IDToken, error)
+}
+
+// RoleMapping turns a claim of the id_token (e.g. "groups") into a system
+// role. Without a claim nobody's role is touched, new users get RoleUser.
+type RoleMapping struct {
+	Claim   string
+	Values  map[string]string // claim value -> role
+	Default string            // when no value matches, empty means RoleUser
+}
+
+// ParseRoleMap reads "value=role,value=role" as used in OIDC_ROLE_MAP
+func ParseRoleMap(spec string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		value, role, ok := strings.Cut(pair, "=")
+		role = strings.TrimSpace(role)
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q", pair)
+		}
+		if role != RoleUser && role != RoleAdmin {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, role)
+		}
+		values[strings.TrimSpace(value)] = role
+	}
+	return values, nil
+}
+
+// OIDCLogin is what the browser carries between Begin and Complete
+type OIDCLogin struct {
+	URL   string // send the browser here
+	State string // signed, keep it in a cookie until the callback
+}
+
+// OIDCService signs users in through an external identity provider with
+// the authorization code flow + PKCE. Identities are linked by issuer and
+// subject, unknown users get an account on their first sign-in.
+type OIDCService struct {
+	users      Repository
+	identities IdentityRepository
+	provider   OIDCProvider
+	jwt        *auth.JWTManager
+	audit      *audit.Service
+	roles      RoleMapping
+	stateTTL   time.Duration
+}
+
+func NewOIDCService(users Repository, identities IdentityRepository, provider OIDCProvider, jwt *auth.JWTManager, audit *audit.Service, roles RoleMapping, stateTTL time.Duration) *OIDCService {
+	return &OIDCService{
+		users:      users,
+		identities: identities,
+		provider:   provider,
+		jwt:        jwt,
+		audit:      audit,
+		roles:      roles,
+		stateTTL:   stateTTL,
+	}
+}
+
+// Begin starts a sign-in. State, nonce and PKCE verifier travel in a signed
+// token, so any instance can finish the flow without shared storage.
+func (s *OIDCService) Begin() (*OIDCLogin, error) {
+	state, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	// all three are base64url, so "." can't show up inside them
+	signed, err := s.jwt.GenerateActionToken(actionOIDCLogin, 0, state+"."+nonce+"."+verifier, s.stateTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCLogin{
+		URL:   s.provider.AuthCodeURL(state, nonce, oidc.Challenge(verifier)),
+		State: signed,
+	}, nil
+}
+
+// Complete finishes the sign-in from the provider's callback and returns
+// the local user, ready for a session
+func (s *OIDCService) Complete(ctx context.Context, signedState, state, code string) (*User, error) {
+	claims, err := s.jwt.VerifyActionToken(actionOIDCLogin, signedState)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	parts := strings.Split(claims.Subject, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	id, err := s.provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(id.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+
+	u, err := s.resolveUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Status == StatusPendingVerification && id.EmailVerified {
+		// the provider just proved the address, same as clicking our link
+		if err := s.users.UpdateStatus(ctx, u.ID, StatusActive); err != nil {
+			return nil, fmt.Errorf("failed to activate user: %w", err)
+		}
+		u.Status = StatusActive
+	}
+	if u.Status == StatusPendingVerification {
+		return nil, ErrEmailNotVerified
+	}
+	if u.Status != StatusActive {
+		return nil, ErrInactiveAccount
+	}
+
+	if err := s.syncRole(ctx, u, id); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// resolveUser finds the linked user, links an existing account by its
+// verified email, or creates a new one
+func (s *OIDCService) resolveUser(ctx context.Context, id *oidc.IDToken) (*User, error) {
+	issuer := s.provider.Issuer()
+
+	if identity, err := s.identities.GetIdentity(ctx, issuer, id.Subject); err == nil && identity != nil {
+		u, err := s.users.GetByID(ctx, identity.UserID)
+		if err != nil || u == nil {
+			return nil, ErrUserNotFound
+		}
+		if err := s.identities.TouchIdentity(ctx, identity.ID, id.Email); err != nil {
+			log.Printf("failed to record oidc login for user %d: %v", u.ID, err)
+		}
+		return u, nil
+	}
+
+	email := strings.ToLower(strings.TrimSpace(id.Email))
+	if email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	// otherwise anyone who can register that address at the provider gets
+	// the account, or for a new one the invitations waiting for the address
+	if !id.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil || u == nil {
+		u, err = s.createUser(ctx, email, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identity, err := s.identities.CreateIdentity(ctx, ExternalIdentity{
+		UserID:  u.ID,
+		Issuer:  issuer,
+		Subject: id.Subject,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	if err := s.identities.TouchIdentity(ctx, identity.ID, email); err != nil {
+		log.Printf("failed to record oidc login for user %d: %v", u.ID, err)
+	}
+	s.record(ctx, u.ID, "user.identity_linked", issuer)
+	return u, nil
+}
+
+func (s *OIDCService) createUser(ctx context.Context, email string, id *oidc.IDToken) (*User, error) {
+	// no usable password, the account signs in through the provider
+	// (or sets one with the reset flow)
+	placeholder, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	role := s.mappedRole(id)
+	if role == "" {
+		role = RoleUser
+	}
+
+	u, err := s.users.Create(ctx, User{
+		Email:        email,
+		PasswordHash: "oidc:" + placeholder,
+		Role:         role,
+		Status:       StatusActive, // the provider already vouched for the address
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	s.record(ctx, u.ID, "user.created_via_oidc", "role "+role)
+	return u, nil
+}
+
+// syncRole keeps the role in line with the provider on every sign-in
+func (s *OIDCService) syncRole(ctx context.Context, u *User, id *oidc.IDToken) error {
+	role := s.mappedRole(id)
+	if role == "" || role == u.Role {
+		return nil
+	}
+
+	previous := u.Role
+	if err := s.users.UpdateRole(ctx, u.ID, role); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	u.Role = role
+	if s.jwt != nil {
+		s.jwt.ForgetAccount(u.ID)
+	}
+	s.record(ctx, u.ID, "user.role_changed", fmt.Sprintf("role %s -> %s (identity provider)", previous, role))
+	return nil
+}
+
+// mappedRole returns "" when no mapping is configured. If several values
+// match, admin wins, so listing order at the provider doesn't matter.
+func (s *OIDCService) mappedRole(id *oidc.IDToken) string {
+	if s.roles.Claim == "" {
+		return ""
+	}
+
+	var values []string
+	switch v := id.Claims[s.roles.Claim].(type) {
+	case string:
+		values = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	role := ""
+	for _, v := range values {
+		mapped, ok := s.roles.Values[v]
+		if !ok {
+			continue
+		}
+		if mapped == RoleAdmin {
+			return RoleAdmin
+		}
+		role = mapped
+	}
+	if role == "" {
+		role = s.roles.Default
+	}
+	if role == "" {
+		role = RoleUser
+	}
+	return role
+}
+
+func (s *OIDCService) record(ctx context.Context, userID int64, action, details string) {
+	if s.audit == nil {
+		return
+	}
+	// actor 0 is the system, the provider made this call
+	if err := s.audit.Record(ctx, 0, action, "user", userID, details); err != nil {
+		log.Printf("failed to audit %s for user %d: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/oidc"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider hands back id whatever the code, with the nonce it was last sent
+type fakeProvider struct {
+	id    oidc.IDToken
+	nonce string
+}
+
+func (f *fakeProvider) Issuer() string { return "https://idp.example" }
+
+func (f *fakeProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	f.nonce = nonce
+	return "https://idp.example/authorize?" + url.Values{"state": {state}}.Encode()
+}
+
+func (f *fakeProvider) Exchange(ctx context.Context, code, verifier string) (*oidc.IDToken, error) {
+	id := f.id
+	if id.Nonce == "" {
+		id.Nonce = f.nonce
+	}
+	return &id, nil
+}
+
+func TestOIDCService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(id oidc.IDToken) (*OIDCService, *FakeRepository, *fakeProvider) {
+		users := NewFakeRepository()
+		provider := &fakeProvider{id: id}
+		roles := RoleMapping{Claim: "groups", Values: map[string]string{"admins": RoleAdmin}}
+		svc := NewOIDCService(users, NewFakeIdentityRepository(), provider, auth.NewJWTManager("test", time.Hour), nil, roles, time.Minute)
+		return svc, users, provider
+	}
+
+	// begin runs the redirect half and returns what the callback would get
+	begin := func(t *testing.T, svc *OIDCService) (string, string) {
+		login, err := svc.Begin()
+		assert.NoError(t, err)
+		u, _ := url.Parse(login.URL)
+		return login.State, u.Query().Get("state")
+	}
+
+	t.Run("Suspended users can't sign in through the provider", func(t *testing.T) {
+		svc, users, _ := setup(oidc.IDToken{Subject: "1", Email: "s@example.com", EmailVerified: true})
+		users.Create(ctx, User{Email: "s@example.com", Status: StatusSuspended})
+
+		signed, state := begin(t, svc)
+		_, err := svc.Complete(ctx, signed, state, "code")
+		assert.ErrorIs(t, err, ErrInactiveAccount)
+	})
+
+	t.Run("A replayed id token with another nonce is refused", func(t *testing.T) {
+		svc, _, _ := setup(oidc.IDToken{Subject: "1", Email: "n@example.com", Nonce: "from-another-login"})
+
+		signed, state := begin(t, svc)
+		_, err := svc.Complete(ctx, signed, state, "code")
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+	})
+
+	t.Run("Verified email activates a pending local account", func(t *testing.T) {
+		svc, users, _ := setup(oidc.IDToken{Subject: "1", Email: "p@example.com", EmailVerified: true})
+		users.Create(ctx, User{Email: "p@example.com", Status: StatusPendingVerification})
+
+		signed, state := begin(t, svc)
+		u, err := svc.Complete(ctx, signed, state, "code")
+		assert.NoError(t, err)
+		assert.Equal(t, StatusActive, u.Status)
+	})
+
+	t.Run("Unverified email doesn't create an account", func(t *testing.T) {
+		svc, users, _ := setup(oidc.IDToken{Subject: "1", Email: "u@example.com"})
+
+		signed, state := begin(t, svc)
+		_, err := svc.Complete(ctx, signed, state, "code")
+		assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+		_, err = users.GetByEmail(ctx, "u@example.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("Role claim as a space separated string", func(t *testing.T) {
+		svc, _, _ := setup(oidc.IDToken{Subject: "1", Email: "r@example.com", EmailVerified: true, Claims: map[string]interface{}{"groups": "staff admins"}})
+
+		signed, state := begin(t, svc)
+		u, err := svc.Complete(ctx, signed, state, "code")
+		assert.NoError(t, err)
+		assert.Equal(t, RoleAdmin, u.Role)
+	})
+
+	t.Run("ParseRoleMap", func(t *testing.T) {
+		m, err := ParseRoleMap("admins=admin, staff=user")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"admins": RoleAdmin, "staff": RoleUser}, m)
+
+		_, err = ParseRoleMap("admins=root")
+		assert.ErrorIs(t, err, ErrInvalidRole)
+		_, err = ParseRoleMap("admins")
+		assert.Error(t, err)
+	})
+}
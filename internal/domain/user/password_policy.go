@@ -0,0 +1,115 @@
+package user
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// common_passwords.txt is the built-in breached list, one lowercase password per line
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Codes a PasswordViolation can have, stable for clients to switch on
+const (
+	ViolationTooShort     = "too_short"
+	ViolationTooLong      = "too_long"
+	ViolationBreached     = "breached"
+	ViolationContainsMail = "contains_email"
+)
+
+// maxPasswordLength keeps hashing cost bounded, nobody types more than this
+const maxPasswordLength = 256
+
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists everything wrong with a password at once,
+// so the user doesn't have to fix one rule at a time
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return "password rejected: " + strings.Join(msgs, ", ")
+}
+
+// PasswordPolicy follows NIST 800-63B: a minimum length and a breached
+// password list, no composition rules
+type PasswordPolicy struct {
+	MinLength int
+	breached  map[string]bool
+}
+
+// NewPasswordPolicy starts with the built-in breached list
+func NewPasswordPolicy(minLength int) *PasswordPolicy {
+	p := &PasswordPolicy{MinLength: minLength, breached: map[string]bool{}}
+	p.AddBreached(strings.NewReader(commonPasswords))
+	return p
+}
+
+// DefaultPasswordPolicy is used wherever nothing else was configured
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return NewPasswordPolicy(8)
+}
+
+// AddBreached adds one password per line, e.g. from a local copy of a breach corpus
+func (p *PasswordPolicy) AddBreached(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if pw := strings.TrimSpace(scanner.Text()); pw != "" {
+			p.breached[strings.ToLower(pw)] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// Validate returns a *PasswordPolicyError, or nil if the password is fine.
+// email may be empty.
+func (p *PasswordPolicy) Validate(password, email string) error {
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("must be at least %d characters", p.MinLength),
+		})
+	}
+	if length > maxPasswordLength {
+		violations = append(violations, PasswordViolation{
+			Code:    ViolationTooLong,
+			Message: fmt.Sprintf("must be at most %d characters", maxPasswordLength),
+		})
+	}
+
+	lower := strings.ToLower(password)
+	if p.breached[lower] {
+		violations = append(violations, PasswordViolation{
+			Code:    ViolationBreached,
+			Message: "is too common, it appears in known password breaches",
+		})
+	}
+
+	if local, _, _ := strings.Cut(strings.ToLower(email), "@"); len(local) >= 3 && strings.Contains(lower, local) {
+		violations = append(violations, PasswordViolation{
+			Code:    ViolationContainsMail,
+			Message: "must not contain your email address",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(10)
+
+	codes := func(err error) []string {
+		var list []string
+		if policyErr, ok := err.(*PasswordPolicyError); ok {
+			for _, v := range policyErr.Violations {
+				list = append(list, v.Code)
+			}
+		}
+		return list
+	}
+
+	t.Run("Long uncommon passwords pass", func(t *testing.T) {
+		assert.NoError(t, policy.Validate("correct horse battery", "alice@example.com"))
+	})
+
+	t.Run("Every violation is reported at once", func(t *testing.T) {
+		err := policy.Validate("", "alice@example.com")
+		assert.Equal(t, []string{ViolationTooShort}, codes(err))
+
+		err = policy.Validate("Password", "alice@example.com")
+		assert.Equal(t, []string{ViolationTooShort, ViolationBreached}, codes(err))
+
+		err = policy.Validate(strings.Repeat("x", 300), "")
+		assert.Equal(t, []string{ViolationTooLong}, codes(err))
+
+		err = policy.Validate("alice-in-wonderland", "Alice@example.com")
+		assert.Equal(t, []string{ViolationContainsMail}, codes(err))
+	})
+
+	t.Run("Extra breached lists are case insensitive", func(t *testing.T) {
+		assert.NoError(t, policy.Validate("Tr0ub4dor&3xyz", ""))
+		assert.NoError(t, policy.AddBreached(strings.NewReader("tr0ub4dor&3xyz\n")))
+		assert.Equal(t, []string{ViolationBreached}, codes(policy.Validate("Tr0ub4dor&3xyz", "")))
+	})
+}
+
+func TestPasswordRehashOnLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("bcrypt hashes are upgraded to argon2id", func(t *testing.T) {
+		repo := NewFakeRepository()
+		legacy, _ := bcrypt.GenerateFromPassword([]byte("supersecret"), bcrypt.MinCost)
+		repo.Create(ctx, User{Email: "old@example.com", PasswordHash: string(legacy), Status: StatusActive})
+		svc := NewService(repo)
+
+		_, err := svc.Login(ctx, "old@example.com", "wrong password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Equal(t, string(legacy), repo.Users[0].PasswordHash)
+
+		_, err = svc.Login(ctx, "old@example.com", "supersecret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(repo.Users[0].PasswordHash, "$argon2id$v=19$"))
+
+		// and the upgraded hash keeps working
+		_, err = svc.Login(ctx, "old@example.com", "supersecret")
+		assert.NoError(t, err)
+	})
+
+	t.Run("argon2id hashes with old parameters are upgraded", func(t *testing.T) {
+		cheap := auth.DefaultArgon2Params
+		cheap.Memory = 8 * 1024
+		auth.SetArgon2Params(cheap)
+		hash, _ := auth.HashPassword("supersecret")
+		auth.SetArgon2Params(auth.DefaultArgon2Params)
+
+		ok, rehash := auth.VerifyPassword("supersecret", hash)
+		assert.True(t, ok)
+		assert.True(t, rehash)
+
+		repo := NewFakeRepository()
+		repo.Create(ctx, User{Email: "cheap@example.com", PasswordHash: hash, Status: StatusActive})
+		_, err := NewService(repo).Login(ctx, "cheap@example.com", "supersecret")
+		assert.NoError(t, err)
+
+		ok, rehash = auth.VerifyPassword("supersecret", repo.Users[0].PasswordHash)
+		assert.True(t, ok)
+		assert.False(t, rehash)
+	})
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordResetService struct {
+	users    Repository
+	resets   PasswordResetRepository
+	mailer   mail.Mailer
+	sessions *tokens.Service
+	jwt      *auth.JWTManager
+	pats     *pats.Service
+	ttl      time.Duration
+	resetURL string // the token gets appended to this
+}
+
+func NewPasswordResetService(
+	users Repository,
+	resets PasswordResetRepository,
+	mailer mail.Mailer,
+	sessions *tokens.Service,
+	jwt *auth.JWTManager,
+	ttl time.Duration,
+	resetURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		users:    users,
+		resets:   resets,
+		mailer:   mailer,
+		sessions: sessions,
+		jwt:      jwt,
+		ttl:      ttl,
+		resetURL: resetURL,
+	}
+}
+
+// UsePersonalAccessTokens makes a reset revoke the user's personal access
+// tokens too, they'd otherwise keep working for whoever took the account
+func (s *PasswordResetService) UsePersonalAccessTokens(p *pats.Service) {
+	s.pats = p
+}
+
+// RequestReset mails a reset link if the account exists.
+// Unknown emails are not an error, the caller must not be able to tell
+// which addresses are registered.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil || u == nil {
+		return nil
+	}
+
+	// only the newest link should work
+	if err := s.resets.InvalidateForUser(ctx, u.ID); err != nil {
+		return fmt.Errorf("failed to invalidate old reset tokens: %w", err)
+	}
+
+	raw, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.resets.Create(ctx, PasswordResetToken{
+		UserID:    u.ID,
+		TokenHash: auth.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Reset your Playingfield password",
+		Body: fmt.Sprintf("Someone asked to reset the password for this account.\n\n"+
+			"Open this link within %s to choose a new one:\n%s%s\n\n"+
+			"If that wasn't you, just ignore this mail.", s.ttl, s.resetURL, raw),
+	})
+}
+
+// ResetEmail is the email of the account a reset token belongs to, so the
+// new password can be checked against it before the token is used
+func (s *PasswordResetService) ResetEmail(ctx context.Context, raw string) (string, error) {
+	t, err := s.resets.GetByHash(ctx, auth.HashToken(raw))
+	if err != nil || t == nil || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return "", ErrInvalidResetToken
+	}
+	u, err := s.users.GetByID(ctx, t.UserID)
+	if err != nil || u == nil {
+		return "", ErrInvalidResetToken
+	}
+	return u.Email, nil
+}
+
+// ResetPassword burns the token, stores the new hash and logs the user out
+// everywhere, so whoever knew the old password loses access too
+func (s *PasswordResetService) ResetPassword(ctx context.Context, raw, newPasswordHash string) error {
+	t, err := s.resets.GetByHash(ctx, auth.HashToken(raw))
+	if err != nil || t == nil || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	ok, err := s.resets.MarkUsed(ctx, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark reset token used: %w", err)
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.users.UpdatePassword(ctx, t.UserID, newPasswordHash); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAll(ctx, t.UserID); err != nil {
+			return fmt.Errorf("password changed but failed to revoke sessions: %w", err)
+		}
+	}
+	if s.jwt != nil {
+		if err := s.jwt.RevokeUserTokens(ctx, t.UserID); err != nil {
+			return fmt.Errorf("password changed but failed to revoke access tokens: %w", err)
+		}
+	}
+	if s.pats != nil {
+		if err := s.pats.RevokeAll(ctx, t.UserID); err != nil {
+			return fmt.Errorf("password changed but failed to revoke personal access tokens: %w", err)
+		}
+	}
+
+	return nil
+}
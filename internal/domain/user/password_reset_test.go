@@ -0,0 +1,91 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+var resetLink = regexp.MustCompile(`token=(\S+)`)
+
+func TestPasswordResetService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*PasswordResetService, *FakeRepository, *tokens.Service, *bytes.Buffer) {
+		users := NewFakeRepository()
+		hash, _ := auth.HashPassword("old-password")
+		users.Create(ctx, User{Email: "reset@example.com", PasswordHash: hash})
+
+		outbox := &bytes.Buffer{}
+		sessions := tokens.NewService(tokens.NewFakeRepository(), time.Hour)
+		svc := NewPasswordResetService(users, NewFakePasswordResetRepository(), mail.NewLogMailer(outbox),
+			sessions, nil, time.Hour, "http://localhost/reset?token=")
+		return svc, users, sessions, outbox
+	}
+
+	t.Run("Reset with the mailed token changes the password and ends sessions", func(t *testing.T) {
+		svc, users, sessions, outbox := setup()
+		refresh, _, _ := sessions.Issue(ctx, 1, tokens.Device{})
+
+		assert.NoError(t, svc.RequestReset(ctx, "reset@example.com"))
+		m := resetLink.FindStringSubmatch(outbox.String())
+		if !assert.Len(t, m, 2, "mail should contain the reset link") {
+			return
+		}
+
+		newHash, _ := auth.HashPassword("new-password")
+		assert.NoError(t, svc.ResetPassword(ctx, m[1], newHash))
+
+		u, _ := users.GetByID(ctx, 1)
+		assert.True(t, auth.CheckPasswordHash("new-password", u.PasswordHash))
+
+		// old session is gone
+		_, _, err := sessions.Rotate(ctx, refresh, tokens.Device{})
+		assert.Error(t, err)
+
+		// and the token only works once
+		assert.ErrorIs(t, svc.ResetPassword(ctx, m[1], newHash), ErrInvalidResetToken)
+	})
+
+	t.Run("Requesting a new link invalidates the previous one", func(t *testing.T) {
+		svc, _, _, outbox := setup()
+
+		svc.RequestReset(ctx, "reset@example.com")
+		first := resetLink.FindStringSubmatch(outbox.String())[1]
+		outbox.Reset()
+		svc.RequestReset(ctx, "reset@example.com")
+		second := resetLink.FindStringSubmatch(outbox.String())[1]
+
+		hash, _ := auth.HashPassword("whatever")
+		assert.ErrorIs(t, svc.ResetPassword(ctx, first, hash), ErrInvalidResetToken)
+		assert.NoError(t, svc.ResetPassword(ctx, second, hash))
+	})
+
+	t.Run("The token tells whose password is being reset", func(t *testing.T) {
+		svc, _, _, outbox := setup()
+
+		svc.RequestReset(ctx, "reset@example.com")
+		token := resetLink.FindStringSubmatch(outbox.String())[1]
+
+		email, err := svc.ResetEmail(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, "reset@example.com", email)
+
+		_, err = svc.ResetEmail(ctx, "made-up")
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("Unknown emails don't error and send nothing", func(t *testing.T) {
+		svc, _, _, outbox := setup()
+
+		assert.NoError(t, svc.RequestReset(ctx, "nobody@example.com"))
+		assert.Empty(t, outbox.String())
+	})
+}
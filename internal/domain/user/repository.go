@@ -5,6 +5,31 @@ import (
 	"time"
 )
 
+// Account statuses, anything but active is refused at login
+const (
+	StatusActive              = "active"
+	StatusPendingVerification = "pending_verification"
+	StatusSuspended           = "suspended"
+	StatusBanned              = "banned"
+	// anonymized through DELETE /me, the row only keeps history consistent
+	StatusDeleted = "deleted"
+)
+
+// System-wide roles (not to be confused with project roles)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// UserFilter narrows SearchUsers, empty fields are ignored
+type UserFilter struct {
+	Status string
+	Role   string
+	Email  string // substring match
+	Limit  int
+	Offset int
+}
+
 type User struct {
 	ID           int64     `json:"id"`
 	Email        string    `json:"email"`
@@ -17,5 +42,83 @@ type User struct {
 type Repository interface {
 	Create(ctx context.Context, user User) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
-	ListUsers(ctx context.Context) ([]UserListRow, error)
+	GetByID(ctx context.Context, id int64) (*User, error)
+	// ListUsersVisibleTo returns the people who share an organization with the viewer
+	ListUsersVisibleTo(ctx context.Context, viewerID int64) ([]UserListRow, error)
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+	UpdateStatus(ctx context.Context, userID int64, status string) error
+	UpdateRole(ctx context.Context, userID int64, role string) error
+	SearchUsers(ctx context.Context, f UserFilter) ([]User, error)
+}
+
+// PasswordResetToken is a single-use reset link, only its hash is stored
+type PasswordResetToken struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, t PasswordResetToken) (*PasswordResetToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	// MarkUsed returns false if the token was already used
+	MarkUsed(ctx context.Context, id int64) (bool, error)
+	// InvalidateForUser burns every open token, so only the newest link works
+	InvalidateForUser(ctx context.Context, userID int64) error
+}
+
+// TOTPSecret is the shared secret behind a user's authenticator app
+type TOTPSecret struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    *time.Time // nil until the first code is confirmed
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// TwoFactorPolicy says whether a role has to use a second factor
+type TwoFactorPolicy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TwoFactorRepository interface {
+	// SaveSecret starts a (new) enrollment, disabled until confirmed
+	SaveSecret(ctx context.Context, userID int64, secret string) (*TOTPSecret, error)
+	GetSecret(ctx context.Context, userID int64) (*TOTPSecret, error)
+	EnableSecret(ctx context.Context, userID int64) error
+	DeleteSecret(ctx context.Context, userID int64) error
+	// UseStep returns false if this step (or a later one) was already used
+	UseStep(ctx context.Context, userID int64, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes drops the old codes, only hashes are stored
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	// UseRecoveryCode returns false if the code doesn't exist or was used
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+
+	IsRequired(ctx context.Context, role string) (bool, error)
+	SetRequired(ctx context.Context, role string, required bool) error
+	ListPolicies(ctx context.Context) ([]TwoFactorPolicy, error)
+}
+
+// ExternalIdentity links an account at an OIDC provider to a local user
+type ExternalIdentity struct {
+	ID          int64
+	UserID      int64
+	Issuer      string
+	Subject     string // the provider's stable user id, emails can change
+	Email       string // as last reported by the provider
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+type IdentityRepository interface {
+	GetIdentity(ctx context.Context, issuer, subject string) (*ExternalIdentity, error)
+	CreateIdentity(ctx context.Context, identity ExternalIdentity) (*ExternalIdentity, error)
+	// TouchIdentity records a login and the email the provider reported with it
+	TouchIdentity(ctx context.Context, id int64, email string) error
 }
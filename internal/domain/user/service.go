@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"errors"
+	"log"
 
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 )
@@ -11,6 +12,8 @@ var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInactiveAccount    = errors.New("account is inactive or banned")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailNotVerified   = errors.New("email address is not verified")
 )
 
 type service struct {
@@ -25,7 +28,9 @@ type UserListRow struct {
 type Service interface {
 	RegisterUser(ctx context.Context, email, hashedPassword string) (*User, error)
 	Login(ctx context.Context, email, password string) (*User, error)
-	ListAllUsers(ctx context.Context) ([]UserListRow, error)
+	// ListUsers returns who the viewer can see: the people in their organizations
+	ListUsers(ctx context.Context, viewerID int64) ([]UserListRow, error)
+	GetUser(ctx context.Context, id int64) (*User, error)
 }
 
 func NewService(repo Repository) Service {
@@ -41,7 +46,7 @@ func (s *service) RegisterUser(ctx context.Context, email, hashedPassword string
 		Email:        email,
 		PasswordHash: hashedPassword,
 		Role:         "user",
-		Status:       "active",
+		Status:       StatusPendingVerification, // until the emailed link is opened
 	}
 	return s.repo.Create(ctx, u)
 }
@@ -52,17 +57,43 @@ func (s *service) Login(ctx context.Context, email, password string) (*User, err
 		return nil, ErrInvalidCredentials
 	}
 
-	if !auth.CheckPasswordHash(password, u.PasswordHash) {
+	ok, needsRehash := auth.VerifyPassword(password, u.PasswordHash)
+	if !ok {
 		return nil, ErrInvalidCredentials
 	}
 
-	if u.Status != "active" {
+	if u.Status == StatusPendingVerification {
+		return nil, ErrEmailNotVerified
+	}
+
+	if u.Status != StatusActive {
 		return nil, ErrInactiveAccount
 	}
 
+	// bcrypt (or argon2id with old settings) gets upgraded while we have the
+	// plain password, a failure just means trying again next login
+	if needsRehash {
+		if hash, err := auth.HashPassword(password); err == nil {
+			if err := s.repo.UpdatePassword(ctx, u.ID, hash); err != nil {
+				log.Printf("failed to upgrade password hash for user %d: %v", u.ID, err)
+			} else {
+				u.PasswordHash = hash
+			}
+		}
+	}
+
 	return u, nil
 }
 
-func (s *service) ListAllUsers(ctx context.Context) ([]UserListRow, error) {
-	return s.repo.ListUsers(ctx)
+func (s *service) ListUsers(ctx context.Context, viewerID int64) ([]UserListRow, error) {
+	return s.repo.ListUsersVisibleTo(ctx, viewerID)
+}
+
+// GetUser looks a user up by id, e.g. to re-issue tokens on refresh
+func (s *service) GetUser(ctx context.Context, id int64) (*User, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil || u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
 }
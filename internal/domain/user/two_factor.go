@@ -0,0 +1,334 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+)
+
+const (
+	actionLogin2FA    = "login_2fa"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge   = errors.New("invalid or expired login challenge")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this role")
+)
+
+// Enrollment is shown to the user exactly once, nothing here can be fetched again
+type Enrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginChallenge is handed out instead of a JWT when a second factor is needed
+type LoginChallenge struct {
+	Token              string
+	EnrollmentRequired bool // the role requires 2fa but the user hasn't set it up yet
+}
+
+// TwoFactorService handles opt-in TOTP (RFC 6238) plus recovery codes.
+// Login becomes two steps: the password check yields a short-lived
+// challenge token, CompleteLogin trades it plus a code for the user.
+type TwoFactorService struct {
+	users        Repository
+	repo         TwoFactorRepository
+	jwt          *auth.JWTManager
+	issuer       string // shown as the account name in authenticator apps
+	challengeTTL time.Duration
+}
+
+func NewTwoFactorService(users Repository, repo TwoFactorRepository, jwt *auth.JWTManager, issuer string, challengeTTL time.Duration) *TwoFactorService {
+	return &TwoFactorService{
+		users:        users,
+		repo:         repo,
+		jwt:          jwt,
+		issuer:       issuer,
+		challengeTTL: challengeTTL,
+	}
+}
+
+// Enroll creates a new secret and recovery codes for a logged in user.
+// Nothing changes at login until Confirm gets a valid code.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID int64) (*Enrollment, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, ErrUserNotFound
+	}
+	if s.enabled(ctx, userID) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return s.startEnrollment(ctx, u)
+}
+
+// Confirm switches 2fa on once the authenticator app produces a valid code
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int64, code string) error {
+	secret, err := s.repo.GetSecret(ctx, userID)
+	if err != nil || secret == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if secret.EnabledAt != nil {
+		return ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := s.checkTOTP(ctx, secret, code); err != nil {
+		return err
+	}
+	return s.repo.EnableSecret(ctx, userID)
+}
+
+// Disable needs a current code (or a recovery code), a stolen JWT alone isn't enough
+func (s *TwoFactorService) Disable(ctx context.Context, userID int64, code string) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return ErrUserNotFound
+	}
+
+	required, err := s.repo.IsRequired(ctx, u.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := s.verifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSecret(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor: %w", err)
+	}
+	return s.repo.ReplaceRecoveryCodes(ctx, userID, nil)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if err := s.verifyCode(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// BeginLogin is called after the password check. A nil challenge means no
+// second factor is needed and the caller can issue tokens right away.
+func (s *TwoFactorService) BeginLogin(ctx context.Context, u *User) (*LoginChallenge, error) {
+	required, err := s.repo.IsRequired(ctx, u.Role)
+	if err != nil {
+		return nil, err
+	}
+	enabled := s.enabled(ctx, u.ID)
+	if !enabled && !required {
+		return nil, nil
+	}
+
+	token, err := s.jwt.GenerateActionToken(actionLogin2FA, u.ID, passwordFingerprint(u), s.challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginChallenge{Token: token, EnrollmentRequired: !enabled}, nil
+}
+
+// EnrollWithChallenge lets a user whose role requires 2fa set it up before
+// they ever get an access token
+func (s *TwoFactorService) EnrollWithChallenge(ctx context.Context, challenge string) (*Enrollment, error) {
+	u, err := s.challengeUser(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if s.enabled(ctx, u.ID) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return s.startEnrollment(ctx, u)
+}
+
+// ChallengeUserID reads who a challenge was issued to, so callers can
+// throttle code guesses per account before checking anything else
+func (s *TwoFactorService) ChallengeUserID(challenge string) (int64, error) {
+	claims, err := s.jwt.VerifyActionToken(actionLogin2FA, challenge)
+	if err != nil {
+		return 0, ErrInvalidLoginChallenge
+	}
+	return claims.UserID, nil
+}
+
+// CompleteLogin checks the second factor for a challenge and returns the
+// user to issue tokens for. A pending enrollment is confirmed by its first code.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, challenge, code string) (*User, error) {
+	u, err := s.challengeUser(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.repo.GetSecret(ctx, u.ID)
+	if err != nil || secret == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if secret.EnabledAt == nil {
+		if err := s.checkTOTP(ctx, secret, code); err != nil {
+			return nil, err
+		}
+		if err := s.repo.EnableSecret(ctx, u.ID); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+
+	if err := s.verifyCode(ctx, u.ID, code); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *TwoFactorService) IsRequired(ctx context.Context, role string) (bool, error) {
+	return s.repo.IsRequired(ctx, role)
+}
+
+func (s *TwoFactorService) SetRequired(ctx context.Context, role string, required bool) error {
+	if role != RoleUser && role != RoleAdmin {
+		return ErrInvalidRole
+	}
+	return s.repo.SetRequired(ctx, role, required)
+}
+
+func (s *TwoFactorService) ListPolicies(ctx context.Context) ([]TwoFactorPolicy, error) {
+	return s.repo.ListPolicies(ctx)
+}
+
+func (s *TwoFactorService) enabled(ctx context.Context, userID int64) bool {
+	secret, err := s.repo.GetSecret(ctx, userID)
+	return err == nil && secret != nil && secret.EnabledAt != nil
+}
+
+func (s *TwoFactorService) startEnrollment(ctx context.Context, u *User) (*Enrollment, error) {
+	raw, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.SaveSecret(ctx, u.ID, raw); err != nil {
+		return nil, fmt.Errorf("failed to store totp secret: %w", err)
+	}
+
+	codes, err := s.newRecoveryCodes(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret:        raw,
+		URI:           auth.TOTPURI(s.issuer, u.Email, raw),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *TwoFactorService) challengeUser(ctx context.Context, challenge string) (*User, error) {
+	claims, err := s.jwt.VerifyActionToken(actionLogin2FA, challenge)
+	if err != nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	u, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil || u == nil || claims.Subject != passwordFingerprint(u) {
+		return nil, ErrInvalidLoginChallenge
+	}
+	// the account may have been suspended between the two steps
+	if u.Status != StatusActive {
+		return nil, ErrInactiveAccount
+	}
+	return u, nil
+}
+
+// checkTOTP only accepts authenticator codes, each step works once
+func (s *TwoFactorService) checkTOTP(ctx context.Context, secret *TOTPSecret, code string) error {
+	step, ok := auth.ValidateTOTP(secret.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	fresh, err := s.repo.UseStep(ctx, secret.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyCode accepts an authenticator code or an unused recovery code
+func (s *TwoFactorService) verifyCode(ctx context.Context, userID int64, code string) error {
+	secret, err := s.repo.GetSecret(ctx, userID)
+	if err != nil || secret == nil || secret.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if isDigits(code) {
+		return s.checkTOTP(ctx, secret, code)
+	}
+
+	ok, err := s.repo.UseRecoveryCode(ctx, userID, auth.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		code := raw[:8] + "-" + raw[8:]
+		codes = append(codes, code)
+		hashes = append(hashes, auth.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// passwordFingerprint ties a challenge to the current password,
+// changing the password kills challenges that are still in flight
+func passwordFingerprint(u *User) string {
+	return auth.HashToken(u.PasswordHash)[:16]
+}
+
+// recovery codes are accepted with or without the dash, in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 seed "12345678901234567890", last 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range cases {
+		code, err := auth.TOTPCode(secret, time.Unix(ts, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code)
+	}
+
+	// one step of drift either way is fine, two is not
+	now := time.Unix(1234567890, 0)
+	code, _ := auth.TOTPCode(secret, now.Add(-30*time.Second))
+	_, ok := auth.ValidateTOTP(secret, code, now)
+	assert.True(t, ok)
+	code, _ = auth.TOTPCode(secret, now.Add(-90*time.Second))
+	_, ok = auth.ValidateTOTP(secret, code, now)
+	assert.False(t, ok)
+}
+
+func TestTwoFactorService(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*TwoFactorService, *FakeTwoFactorRepository, *User) {
+		users := NewFakeRepository()
+		hash, _ := auth.HashPassword("password")
+		u, _ := users.Create(ctx, User{Email: "2fa@example.com", PasswordHash: hash, Role: RoleUser, Status: StatusActive})
+		repo := NewFakeTwoFactorRepository()
+		svc := NewTwoFactorService(users, repo, auth.NewJWTManager("test", time.Hour), "Playingfield", time.Minute)
+		return svc, repo, u
+	}
+
+	// enrolls and confirms, returns the recovery codes
+	enable := func(t *testing.T, svc *TwoFactorService, u *User) (*Enrollment, string) {
+		enrollment, err := svc.Enroll(ctx, u.ID)
+		assert.NoError(t, err)
+		code, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+		assert.NoError(t, svc.Confirm(ctx, u.ID, code))
+		return enrollment, code
+	}
+
+	t.Run("No challenge until 2fa is confirmed", func(t *testing.T) {
+		svc, _, u := setup()
+
+		_, err := svc.Enroll(ctx, u.ID)
+		assert.NoError(t, err)
+
+		challenge, err := svc.BeginLogin(ctx, u)
+		assert.NoError(t, err)
+		assert.Nil(t, challenge, "an unconfirmed enrollment must not lock the user out")
+	})
+
+	t.Run("Recovery codes work exactly once", func(t *testing.T) {
+		svc, _, u := setup()
+		enrollment, _ := enable(t, svc, u)
+
+		challenge, err := svc.BeginLogin(ctx, u)
+		assert.NoError(t, err)
+		if !assert.NotNil(t, challenge) {
+			return
+		}
+		assert.False(t, challenge.EnrollmentRequired)
+
+		recovery := enrollment.RecoveryCodes[0]
+		_, err = svc.CompleteLogin(ctx, challenge.Token, recovery)
+		assert.NoError(t, err)
+		_, err = svc.CompleteLogin(ctx, challenge.Token, recovery)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Changing the password kills open challenges", func(t *testing.T) {
+		svc, _, u := setup()
+		enrollment, _ := enable(t, svc, u)
+
+		challenge, _ := svc.BeginLogin(ctx, u)
+		newHash, _ := auth.HashPassword("changed")
+		svc.users.UpdatePassword(ctx, u.ID, newHash)
+
+		_, err := svc.CompleteLogin(ctx, challenge.Token, enrollment.RecoveryCodes[0])
+		assert.ErrorIs(t, err, ErrInvalidLoginChallenge)
+	})
+
+	t.Run("A required role can't switch 2fa off", func(t *testing.T) {
+		svc, repo, u := setup()
+		enrollment, _ := enable(t, svc, u)
+		repo.SetRequired(ctx, RoleUser, true)
+
+		err := svc.Disable(ctx, u.ID, enrollment.RecoveryCodes[0])
+		assert.ErrorIs(t, err, ErrTwoFactorRequired)
+
+		repo.SetRequired(ctx, RoleUser, false)
+		assert.NoError(t, svc.Disable(ctx, u.ID, enrollment.RecoveryCodes[1]))
+		challenge, _ := svc.BeginLogin(ctx, u)
+		assert.Nil(t, challenge)
+	})
+}
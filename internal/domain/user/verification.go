@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+)
+
+const actionVerifyEmail = "verify_email"
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification link")
+	ErrAlreadyVerified          = errors.New("account is already verified")
+)
+
+// VerificationService mails signed verification links and activates
+// accounts once they are opened. Nothing is stored, the link is a
+// signed token bound to the user id and email.
+type VerificationService struct {
+	users     Repository
+	mailer    mail.Mailer
+	jwt       *auth.JWTManager
+	ttl       time.Duration
+	verifyURL string // the token gets appended to this
+}
+
+func NewVerificationService(users Repository, mailer mail.Mailer, jwt *auth.JWTManager, ttl time.Duration, verifyURL string) *VerificationService {
+	return &VerificationService{
+		users:     users,
+		mailer:    mailer,
+		jwt:       jwt,
+		ttl:       ttl,
+		verifyURL: verifyURL,
+	}
+}
+
+// SendVerification mails a fresh link to a pending account
+func (s *VerificationService) SendVerification(ctx context.Context, u *User) error {
+	if u.Status != StatusPendingVerification {
+		return ErrAlreadyVerified
+	}
+
+	token, err := s.jwt.GenerateActionToken(actionVerifyEmail, u.ID, u.Email, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Confirm your Playingfield account",
+		Body: fmt.Sprintf("Welcome to Playingfield!\n\n"+
+			"Open this link within %s to activate your account:\n%s%s", s.ttl, s.verifyURL, token),
+	})
+}
+
+// Resend is what admins use when the first mail got lost
+func (s *VerificationService) Resend(ctx context.Context, userID int64) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return ErrUserNotFound
+	}
+	return s.SendVerification(ctx, u)
+}
+
+// Verify activates the account the link was issued for
+func (s *VerificationService) Verify(ctx context.Context, token string) (*User, error) {
+	claims, err := s.jwt.VerifyActionToken(actionVerifyEmail, token)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	u, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil || u == nil || u.Email != claims.Subject {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	return s.activate(ctx, u)
+}
+
+// ForceVerify lets an admin skip the mail round trip
+func (s *VerificationService) ForceVerify(ctx context.Context, userID int64) (*User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.activate(ctx, u)
+}
+
+func (s *VerificationService) activate(ctx context.Context, u *User) (*User, error) {
+	// only pending accounts move, a banned user can't un-ban themselves with an old link
+	if u.Status != StatusPendingVerification {
+		return nil, ErrAlreadyVerified
+	}
+
+	if err := s.users.UpdateStatus(ctx, u.ID, StatusActive); err != nil {
+		return nil, fmt.Errorf("failed to activate account: %w", err)
+	}
+	u.Status = StatusActive
+	return u, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// same value as user.StatusActive, auth can't import the user domain
+const accountActive = "active"
+
+var ErrAccountInactive = errors.New("account is not active")
+
+// AccountLookup returns the current role and status of a user
+type AccountLookup func(ctx context.Context, userID int64) (role, status string, err error)
+
+type cachedAccount struct {
+	role      string
+	status    string
+	fetchedAt time.Time
+}
+
+// AccountCache remembers role/status for a short while, so checking them
+// on every request costs one query per user per ttl instead of one per request
+type AccountCache struct {
+	lookup  AccountLookup
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[int64]cachedAccount
+}
+
+func NewAccountCache(lookup AccountLookup, ttl time.Duration) *AccountCache {
+	return &AccountCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[int64]cachedAccount),
+	}
+}
+
+// Get returns the cached role/status, going to the lookup when it's stale
+func (a *AccountCache) Get(ctx context.Context, userID int64) (role, status string, err error) {
+	a.mu.RLock()
+	entry, ok := a.entries[userID]
+	a.mu.RUnlock()
+	if ok && time.Since(entry.fetchedAt) < a.ttl {
+		return entry.role, entry.status, nil
+	}
+
+	role, status, err = a.lookup(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	a.mu.Lock()
+	a.entries[userID] = cachedAccount{role: role, status: status, fetchedAt: time.Now()}
+	a.mu.Unlock()
+	return role, status, nil
+}
+
+// Forget drops a user so the next request sees changes made on this instance right away
+func (a *AccountCache) Forget(userID int64) {
+	a.mu.Lock()
+	delete(a.entries, userID)
+	a.mu.Unlock()
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ActionClaims back short-lived, single-purpose links (email verification etc).
+// The action goes in the audience so they can never pass as access tokens.
+// They stay HS256, nothing outside this service ever has to verify them.
+type ActionClaims struct {
+	UserID  int64  `json:"user_id"`
+	Subject string `json:"subject"` // whatever the action is about, e.g. the email being verified
+	jwt.RegisteredClaims
+}
+
+// GenerateActionToken signs a token that is only valid for the given action
+func (j *JWTManager) GenerateActionToken(action string, userID int64, subject string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ActionClaims{
+		UserID:  userID,
+		Subject: subject,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{action},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+// VerifyActionToken checks signature, expiry and that the token was made for this action
+func (j *JWTManager) VerifyActionToken(action, tokenStr string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &ActionClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return j.secretKey, nil
+	}, jwt.WithAudience(action), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
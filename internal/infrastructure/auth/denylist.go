@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DenylistStore persists revoked token ids so they survive restarts
+// and are shared between instances
+type DenylistStore interface {
+	Add(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	// ListActive returns every revoked jti that hasn't expired yet
+	ListActive(ctx context.Context) (map[string]time.Time, error)
+	// AddUserCutoff rejects every token of the user issued before notBefore
+	AddUserCutoff(ctx context.Context, userID int64, notBefore, expiresAt time.Time) error
+	ListActiveCutoffs(ctx context.Context) (map[int64]time.Time, error)
+}
+
+// Denylist keeps revoked token ids in memory so VerifyToken never has to
+// hit the db. Entries only need to live until the token would have
+// expired anyway, after that the expiry check rejects it on its own.
+type Denylist struct {
+	store   DenylistStore
+	mu      sync.RWMutex
+	entries map[string]time.Time // jti -> token expiry
+	cutoffs map[int64]time.Time  // user id -> tokens issued before this are dead
+	stop    chan struct{}
+}
+
+func NewDenylist(store DenylistStore) *Denylist {
+	return &Denylist{
+		store:   store,
+		entries: make(map[string]time.Time),
+		cutoffs: make(map[int64]time.Time),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Load replaces the cache with what is in the store (drops expired ids too)
+func (d *Denylist) Load(ctx context.Context) error {
+	active, err := d.store.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	cutoffs, err := d.store.ListActiveCutoffs(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.entries = active
+	d.cutoffs = cutoffs
+	d.mu.Unlock()
+	return nil
+}
+
+// Revoke writes through to the store first, so a failed write never
+// leaves an id that only this instance knows about
+func (d *Denylist) Revoke(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	if err := d.store.Add(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.entries[jti] = expiresAt
+	d.mu.Unlock()
+	return nil
+}
+
+// RevokeUser kills every token the user holds right now, used when all
+// sessions have to end at once (password reset, logout everywhere).
+// The cutoff is only needed until the newest of those tokens expires.
+func (d *Denylist) RevokeUser(ctx context.Context, userID int64, tokenDuration time.Duration) error {
+	// iat is only precise to the second, so the whole second of the cutoff
+	// counts as before it, a login in that same second has to be repeated
+	notBefore := time.Now().Truncate(time.Second)
+	if err := d.store.AddUserCutoff(ctx, userID, notBefore, notBefore.Add(tokenDuration)); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.cutoffs[userID] = notBefore
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *Denylist) IsRevoked(jti string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	expiresAt, ok := d.entries[jti]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// IssuedBeforeCutoff reports whether the token predates a RevokeUser call.
+// A token from the same second as the cutoff can't be told apart from one
+// issued just before it, so it's rejected too.
+func (d *Denylist) IssuedBeforeCutoff(userID int64, issuedAt time.Time) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	notBefore, ok := d.cutoffs[userID]
+	return ok && !issuedAt.After(notBefore.Truncate(time.Second))
+}
+
+// Run reloads the cache on every tick, which picks up revocations made
+// by other instances. Call it in a goroutine, Stop ends it.
+func (d *Denylist) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := d.Load(ctx); err != nil {
+				log.Printf("denylist sync failed: %v", err)
+			}
+			cancel()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *Denylist) Stop() {
+	close(d.stop)
+}
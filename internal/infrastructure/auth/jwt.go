@@ -1,15 +1,37 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	ErrTokenRevoked   = errors.New("token has been revoked")
+	ErrMissingJTI     = errors.New("token has no id")
+	ErrNoSigningKey   = errors.New("no signing key loaded")
+	ErrUnexpectedAlgo = errors.New("unexpected signing method")
+)
+
+// PATPrefix marks personal access tokens, so VerifyToken can tell them
+// from JWTs without trying to parse them
+const PATPrefix = "pf_pat_"
+
+// PATLookup resolves a raw personal access token to the claims of its owner
+type PATLookup func(ctx context.Context, raw string) (*Claims, error)
+
 type JWTManager struct {
-	secretKey     []byte
+	secretKey     []byte // HS256, still signs action tokens that never leave this service
 	tokenDuration time.Duration
+	denylist      *Denylist
+	accounts      *AccountCache
+	keys          *KeySet   // when set, access tokens are EdDSA with a kid
+	legacyUntil   time.Time // HS256 access tokens are accepted until then
+	pats          PATLookup
 }
 
 type Claims struct {
@@ -17,9 +39,27 @@ type Claims struct {
 	Email  string `json:"email"`
 	Role   string `json:"role"`
 	Status string `json:"status"`
+	// the login session the token was issued to, 0 for tokens from before sessions
+	SessionID int64 `json:"sid,omitempty"`
+	// set for personal access tokens only, JWTs are never scoped
+	Scopes        []string `json:"scopes,omitempty"`
+	PersonalToken bool     `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// HasScope is always true for a JWT, a personal access token needs the scope granted
+func (c *Claims) HasScope(scope string) bool {
+	if !c.PersonalToken {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 func NewJWTManager(secret string, duration time.Duration) *JWTManager {
 	return &JWTManager{
 		secretKey:     []byte(secret), // ✅ convert to []byte here
@@ -27,26 +67,103 @@ func NewJWTManager(secret string, duration time.Duration) *JWTManager {
 	}
 }
 
+// UseDenylist makes VerifyToken reject revoked token ids.
+// Every entry point (middleware, RequireRole, ws handshake) goes through
+// VerifyToken so they all pick it up.
+func (j *JWTManager) UseDenylist(d *Denylist) {
+	j.denylist = d
+}
+
+// UseKeySet switches access tokens to EdDSA signed with rotating keys, so
+// other services can verify them from the JWKS without knowing any secret.
+// HS256 tokens issued before the switch work until they'd have expired anyway.
+func (j *JWTManager) UseKeySet(k *KeySet) {
+	j.keys = k
+	j.legacyUntil = time.Now().Add(j.tokenDuration)
+}
+
+// JWKS is the public key set for /.well-known/jwks.json, empty without a key set
+func (j *JWTManager) JWKS() JWKS {
+	if j.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return j.keys.JWKS()
+}
+
+// UsePersonalAccessTokens makes VerifyToken accept "pf_pat_..." bearer
+// tokens next to JWTs, resolved through lookup
+func (j *JWTManager) UsePersonalAccessTokens(lookup PATLookup) {
+	j.pats = lookup
+}
+
+// UseAccountCache makes VerifyToken check the user's current status and
+// role instead of trusting whatever was true when the token was issued
+func (j *JWTManager) UseAccountCache(a *AccountCache) {
+	j.accounts = a
+}
+
+// ForgetAccount makes the next verification re-read the user's role/status
+func (j *JWTManager) ForgetAccount(userID int64) {
+	if j.accounts != nil {
+		j.accounts.Forget(userID)
+	}
+}
+
+// TokenDuration is the longest an access token can stay valid
+func (j *JWTManager) TokenDuration() time.Duration {
+	return j.tokenDuration
+}
+
 // Generate a JWT token for a user
 func (j *JWTManager) GenerateToken(userID int64, email, role string) (string, error) {
+	return j.GenerateSessionToken(userID, 0, email, role, accountActive)
+}
+
+// GenerateSessionToken ties the token to a login session, so revoking the
+// session kills it along with the refresh token
+func (j *JWTManager) GenerateSessionToken(userID, sessionID int64, email, role, status string) (string, error) {
+	// every token gets its own id so it can be revoked on its own
+	jti, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		Status:    status,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.tokenDuration)),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.tokenDuration)),
 		},
 	}
 
+	if j.keys != nil {
+		key, ok := j.keys.Current()
+		if !ok {
+			return "", ErrNoSigningKey
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.PrivateKey)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(j.secretKey)
 }
 
 // Verify a JWT token and return claims
 func (j *JWTManager) VerifyToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return j.secretKey, nil // ✅ must be []byte
-	})
+	if strings.HasPrefix(tokenStr, PATPrefix) {
+		return j.verifyPAT(tokenStr)
+	}
+
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, j.accessKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
@@ -56,5 +173,122 @@ func (j *JWTManager) VerifyToken(tokenStr string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	if claims.ID == "" {
+		return nil, ErrMissingJTI
+	}
+
+	// action tokens (verification links...) carry an audience, access tokens never do
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("invalid token")
+	}
+
+	if j.denylist != nil {
+		if j.denylist.IsRevoked(claims.ID) {
+			return nil, ErrTokenRevoked
+		}
+		if claims.SessionID != 0 && j.denylist.IsRevoked(sessionKey(claims.SessionID)) {
+			return nil, ErrTokenRevoked
+		}
+		if claims.IssuedAt != nil && j.denylist.IssuedBeforeCutoff(claims.UserID, claims.IssuedAt.Time) {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return j.checkAccount(claims)
+}
+
+// verifyPAT skips the denylist and user cutoffs, personal access tokens
+// are revoked one by one and survive "log out everywhere"
+func (j *JWTManager) verifyPAT(raw string) (*Claims, error) {
+	if j.pats == nil {
+		return nil, errors.New("invalid token")
+	}
+	claims, err := j.pats(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	claims.PersonalToken = true
+	if claims.Scopes == nil {
+		claims.Scopes = []string{}
+	}
+	return j.checkAccount(claims)
+}
+
+// checkAccount swaps in the current role/status, a suspension or demotion
+// shouldn't wait for the token to expire
+func (j *JWTManager) checkAccount(claims *Claims) (*Claims, error) {
+	if j.accounts != nil {
+		role, status, err := j.accounts.Get(context.Background(), claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		claims.Role = role
+		claims.Status = status
+	}
+	if claims.Status != "" && claims.Status != accountActive {
+		return nil, ErrAccountInactive
+	}
 	return claims, nil
 }
+
+// accessKey picks the verification key from the token header
+func (j *JWTManager) accessKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodEd25519:
+		if j.keys == nil {
+			return nil, ErrUnexpectedAlgo
+		}
+		kid, _ := token.Header["kid"].(string)
+		return j.keys.PublicKey(kid)
+	case *jwt.SigningMethodHMAC:
+		if j.keys != nil && time.Now().After(j.legacyUntil) {
+			return nil, ErrUnexpectedAlgo
+		}
+		return j.secretKey, nil // ✅ must be []byte
+	}
+	return nil, ErrUnexpectedAlgo
+}
+
+// RevokeToken puts the token's id on the denylist until it expires
+func (j *JWTManager) RevokeToken(ctx context.Context, claims *Claims) error {
+	if j.denylist == nil || claims == nil || claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(j.tokenDuration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return j.denylist.Revoke(ctx, claims.ID, claims.UserID, expiresAt)
+}
+
+// RevokeTokenID is for admins who only have the jti (e.g. from logs).
+// We don't know when that token expires, but it can't outlive tokenDuration.
+func (j *JWTManager) RevokeTokenID(ctx context.Context, jti string, userID int64) error {
+	if j.denylist == nil {
+		return errors.New("token denylist is not configured")
+	}
+	return j.denylist.Revoke(ctx, jti, userID, time.Now().Add(j.tokenDuration))
+}
+
+// RevokeUserTokens rejects every access token the user currently holds
+func (j *JWTManager) RevokeUserTokens(ctx context.Context, userID int64) error {
+	if j.denylist == nil {
+		return nil
+	}
+	return j.denylist.RevokeUser(ctx, userID, j.tokenDuration)
+}
+
+// RevokeSession rejects every access token issued to the session. Tokens
+// can't outlive tokenDuration, so neither does the entry.
+func (j *JWTManager) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	if j.denylist == nil {
+		return nil
+	}
+	return j.denylist.Revoke(ctx, sessionKey(sessionID), userID, time.Now().Add(j.tokenDuration))
+}
+
+// session entries share the denylist with jtis, the prefix keeps them apart
+func sessionKey(sessionID int64) string {
+	return "sid:" + strconv.FormatInt(sessionID, 10)
+}
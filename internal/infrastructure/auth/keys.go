@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+const (
+	// JWKSMaxAge is how long verifiers may cache /.well-known/jwks.json
+	JWKSMaxAge = 5 * time.Minute
+	// KeyReloadInterval is how often every instance re-reads the keys
+	KeyReloadInterval = time.Hour
+)
+
+// JWKSCacheControl is the Cache-Control header for the published keys
+var JWKSCacheControl = fmt.Sprintf("public, max-age=%d", int(JWKSMaxAge.Seconds()))
+
+// SigningKey is one Ed25519 key pair, identified by its kid in the token header
+type SigningKey struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // no token signed with it can be alive after this
+}
+
+// KeyStore persists signing keys so every instance signs and verifies with the same set
+type KeyStore interface {
+	Create(ctx context.Context, key SigningKey) error
+	// ListActive returns every key that hasn't expired, newest first
+	ListActive(ctx context.Context) ([]SigningKey, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// JWK is the public half of a key as published in /.well-known/jwks.json
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	X   string `json:"x"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet signs with the newest published key and verifies with any key
+// that is still active. A new key is published in the JWKS a while before
+// it signs anything, so cached key sets and other instances know it by
+// then. A rotated out key stays around until the last token it signed has
+// expired, so rotation never logs anybody out.
+type KeySet struct {
+	store         KeyStore
+	rotateEvery   time.Duration
+	tokenLifetime time.Duration // longest a token signed with a key may live
+	publishAhead  time.Duration // how long a new key is published before it signs
+
+	mu         sync.RWMutex
+	keys       []SigningKey // newest first
+	lastReload time.Time
+	stop       chan struct{}
+}
+
+func NewKeySet(store KeyStore, rotateEvery, tokenLifetime time.Duration) *KeySet {
+	// long enough for every cached JWKS to expire and every instance to
+	// reload, but a key still has to sign for most of its rotation
+	publishAhead := JWKSMaxAge + KeyReloadInterval
+	if publishAhead > rotateEvery/2 {
+		publishAhead = rotateEvery / 2
+	}
+	return &KeySet{
+		store:         store,
+		rotateEvery:   rotateEvery,
+		tokenLifetime: tokenLifetime,
+		publishAhead:  publishAhead,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Load replaces the in-memory keys with the active ones from the store
+func (k *KeySet) Load(ctx context.Context) error {
+	keys, err := k.store.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.lastReload = time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+// Rotate reloads the keys and creates the next one when the newest key is
+// publishAhead away from being due, or couldn't outlive a token issued
+// once its successor is ready
+func (k *KeySet) Rotate(ctx context.Context) error {
+	if err := k.Load(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	k.mu.RLock()
+	due := len(k.keys) == 0 ||
+		now.Sub(k.keys[0].CreatedAt) >= k.rotateEvery-k.publishAhead ||
+		k.keys[0].ExpiresAt.Before(now.Add(k.publishAhead+k.tokenLifetime))
+	k.mu.RUnlock()
+	if !due {
+		return nil
+	}
+
+	// a key waits publishAhead, signs for rotateEvery, then has to outlive
+	// its last token. The extra rotateEvery is slack for a late tick or an
+	// instance that hasn't picked up the successor yet.
+	key, err := newSigningKey(now, k.publishAhead+2*k.rotateEvery+k.tokenLifetime)
+	if err != nil {
+		return err
+	}
+	if err := k.store.Create(ctx, key); err != nil {
+		return err
+	}
+	if err := k.store.DeleteExpired(ctx); err != nil {
+		log.Printf("failed to delete expired signing keys: %v", err)
+	}
+
+	k.mu.Lock()
+	k.keys = append([]SigningKey{key}, k.keys...)
+	k.mu.Unlock()
+	return nil
+}
+
+// Current is the key new tokens get signed with: the newest one that has
+// been published for publishAhead. Before any has, say on a fresh install
+// where nobody has cached the keys yet, the oldest one signs.
+func (k *KeySet) Current() (SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.keys) == 0 {
+		return SigningKey{}, false
+	}
+	ready := time.Now().Add(-k.publishAhead)
+	for _, key := range k.keys {
+		if !key.CreatedAt.After(ready) {
+			return key, true
+		}
+	}
+	return k.keys[len(k.keys)-1], true
+}
+
+// PublicKey finds the key a token was signed with. An unknown kid may
+// come from a key another instance just created, so the store is asked
+// again, but at most every few seconds so junk kids can't hammer the db.
+func (k *KeySet) PublicKey(kid string) (ed25519.PublicKey, error) {
+	if key, ok := k.find(kid); ok {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	recent := time.Since(k.lastReload) < 10*time.Second
+	k.mu.RUnlock()
+	if !recent {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := k.Load(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := k.find(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// JWKS lists the public keys of every active key, including the next one
+// that doesn't sign yet
+func (k *KeySet) JWKS() JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+	now := time.Now()
+	for _, key := range k.keys {
+		if now.After(key.ExpiresAt) {
+			continue
+		}
+		set.Keys = append(set.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			Alg: "EdDSA",
+			Use: "sig",
+			Kid: key.ID,
+			X:   base64.RawURLEncoding.EncodeToString(key.PublicKey),
+		})
+	}
+	return set
+}
+
+// Run rotates on every tick, call it in a goroutine, Stop ends it
+func (k *KeySet) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := k.Rotate(ctx); err != nil {
+				log.Printf("signing key rotation failed: %v", err)
+			}
+			cancel()
+		case <-k.stop:
+			return
+		}
+	}
+}
+
+func (k *KeySet) Stop() {
+	close(k.stop)
+}
+
+func (k *KeySet) find(kid string) (ed25519.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	now := time.Now()
+	for _, key := range k.keys {
+		if key.ID == kid && now.Before(key.ExpiresAt) {
+			return key.PublicKey, true
+		}
+	}
+	return nil, false
+}
+
+func newSigningKey(now time.Time, lifetime time.Duration) (SigningKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	// kid is derived from the public key, so it's stable and never collides
+	sum := sha256.Sum256(pub)
+	return SigningKey{
+		ID:         base64.RawURLEncoding.EncodeToString(sum[:12]),
+		PrivateKey: priv,
+		PublicKey:  pub,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(lifetime),
+	}, nil
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+type ThrottleConfig struct {
+	PerEmail    int           // failed attempts allowed per account before lockouts start
+	PerIP       int           // same per client ip, higher since NAT puts many users behind one
+	BaseLockout time.Duration // first lockout, doubles with every failure after it
+	MaxLockout  time.Duration
+	Window      time.Duration // failures older than this are forgotten
+}
+
+// ThrottleEvent is reported whenever a key gets locked or unlocked
+type ThrottleEvent struct {
+	Locked   bool   // false means the lockout ended
+	Key      string // "email:..." or "ip:..."
+	Failures int
+	Until    time.Time
+}
+
+type attemptState struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottle counts failed logins per email and per ip with an
+// exponential lockout. State is kept in memory, it only has to slow
+// guessing down, not survive a restart.
+type LoginThrottle struct {
+	cfg     ThrottleConfig
+	notify  func(ThrottleEvent)
+	mu      sync.Mutex
+	entries map[string]*attemptState
+	stop    chan struct{}
+}
+
+// NewLoginThrottle calls notify (outside the lock) for every lock and unlock, notify may be nil
+func NewLoginThrottle(cfg ThrottleConfig, notify func(ThrottleEvent)) *LoginThrottle {
+	return &LoginThrottle{
+		cfg:     cfg,
+		notify:  notify,
+		entries: make(map[string]*attemptState),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Check returns how long the caller has to wait, 0 means go ahead.
+// An empty email only checks the ip.
+func (t *LoginThrottle) Check(email, ip string) time.Duration {
+	now := time.Now()
+	var wait time.Duration
+	var events []ThrottleEvent
+
+	t.mu.Lock()
+	for _, key := range t.keys(email, ip) {
+		st, ok := t.entries[key]
+		if !ok || st.lockedUntil.IsZero() {
+			continue
+		}
+		if now.Before(st.lockedUntil) {
+			if d := st.lockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+			continue
+		}
+		st.lockedUntil = time.Time{}
+		events = append(events, ThrottleEvent{Key: key, Failures: st.failures})
+	}
+	t.mu.Unlock()
+
+	t.emit(events)
+	return wait
+}
+
+// Fail records a failed attempt, locking the email and/or ip once they're over their limit
+func (t *LoginThrottle) Fail(email, ip string) {
+	now := time.Now()
+	var events []ThrottleEvent
+
+	t.mu.Lock()
+	for _, key := range t.keys(email, ip) {
+		limit := t.cfg.PerIP
+		if strings.HasPrefix(key, "email:") {
+			limit = t.cfg.PerEmail
+		}
+
+		st, ok := t.entries[key]
+		if !ok {
+			st = &attemptState{}
+			t.entries[key] = st
+		}
+		if st.lockedUntil.IsZero() && now.Sub(st.lastFailure) > t.cfg.Window {
+			st.failures = 0
+		}
+		st.failures++
+		st.lastFailure = now
+
+		if st.failures > limit {
+			st.lockedUntil = now.Add(t.lockout(st.failures - limit))
+			events = append(events, ThrottleEvent{Locked: true, Key: key, Failures: st.failures, Until: st.lockedUntil})
+		}
+	}
+	t.mu.Unlock()
+
+	t.emit(events)
+}
+
+// Succeed clears the account's failures. The ip keeps its count, otherwise
+// logging into your own account would reset a stuffing run from that ip.
+func (t *LoginThrottle) Succeed(email string) {
+	if email == "" {
+		return
+	}
+	t.mu.Lock()
+	delete(t.entries, "email:"+normalizeEmail(email))
+	t.mu.Unlock()
+}
+
+// Run reports expired lockouts and drops stale entries on every tick.
+// Call it in a goroutine, Stop ends it.
+func (t *LoginThrottle) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.sweep()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+func (t *LoginThrottle) Stop() {
+	close(t.stop)
+}
+
+func (t *LoginThrottle) sweep() {
+	now := time.Now()
+	var events []ThrottleEvent
+
+	t.mu.Lock()
+	for key, st := range t.entries {
+		if !st.lockedUntil.IsZero() && !now.Before(st.lockedUntil) {
+			st.lockedUntil = time.Time{}
+			events = append(events, ThrottleEvent{Key: key, Failures: st.failures})
+		}
+		if st.lockedUntil.IsZero() && now.Sub(st.lastFailure) > t.cfg.Window {
+			delete(t.entries, key)
+		}
+	}
+	t.mu.Unlock()
+
+	t.emit(events)
+}
+
+// lockout doubles from BaseLockout for every failure over the limit
+func (t *LoginThrottle) lockout(over int) time.Duration {
+	d := t.cfg.BaseLockout
+	for i := 1; i < over && d < t.cfg.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > t.cfg.MaxLockout {
+		d = t.cfg.MaxLockout
+	}
+	return d
+}
+
+func (t *LoginThrottle) keys(email, ip string) []string {
+	var keys []string
+	if email != "" {
+		keys = append(keys, "email:"+normalizeEmail(email))
+	}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func (t *LoginThrottle) emit(events []ThrottleEvent) {
+	if t.notify == nil {
+		return
+	}
+	for _, ev := range events {
+		t.notify(ev)
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
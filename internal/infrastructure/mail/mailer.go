@@ -0,0 +1,16 @@
+package mail
+
+import "context"
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails. SMTPMailer is for real deployments,
+// LogMailer just writes the mail out so links can be clicked locally.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
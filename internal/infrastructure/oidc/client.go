@@ -0,0 +1,220 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrIssuerMismatch = errors.New("discovery document is for a different issuer")
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for a public client, PKCE alone protects the code then
+	RedirectURL  string
+	Scopes       []string // "openid" is always sent
+}
+
+// IDToken is the verified content of an id_token
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Nonce         string
+	Claims        map[string]interface{} // everything, for role mapping
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client runs the authorization code flow with PKCE against one issuer
+type Client struct {
+	cfg  Config
+	http *http.Client
+	meta discovery
+	keys *remoteKeys
+}
+
+// Discover reads the issuer's /.well-known/openid-configuration and its keys.
+// httpClient may be nil.
+func Discover(ctx context.Context, cfg Config, httpClient *http.Client) (*Client, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	var meta discovery
+	wellKnown := strings.TrimSuffix(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, httpClient, wellKnown, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// the spec requires an exact match, anything else could be a mix-up attack
+	if meta.Issuer != cfg.IssuerURL {
+		return nil, ErrIssuerMismatch
+	}
+
+	keys := &remoteKeys{url: meta.JWKSURI, http: httpClient}
+	if err := keys.refresh(ctx); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	return &Client{cfg: cfg, http: httpClient, meta: meta, keys: keys}, nil
+}
+
+// Issuer is the issuer identifier, stored with every linked identity
+func (c *Client) Issuer() string {
+	return c.meta.Issuer
+}
+
+// AuthCodeURL is where the browser gets sent to sign in
+func (c *Client) AuthCodeURL(state, nonce, codeChallenge string) string {
+	scopes := append([]string{"openid"}, c.cfg.Scopes...)
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(dedupe(scopes), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(c.meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.meta.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange trades the code for tokens and returns the verified id_token.
+// Checking the nonce is up to the caller, it's the one that remembers it.
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (*IDToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if c.cfg.ClientSecret == "" {
+		form.Set("client_id", c.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+	return c.verify(tokens.IDToken)
+}
+
+// verify checks signature, issuer, audience and expiry of an id_token
+func (c *Client) verify(raw string) (*IDToken, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.keys.get(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(c.meta.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	id := &IDToken{
+		Issuer:  c.meta.Issuer,
+		Subject: sub,
+		Claims:  claims,
+	}
+	id.Email, _ = claims["email"].(string)
+	id.Nonce, _ = claims["nonce"].(string)
+	// some providers send "true" as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+	return id, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+func dedupe(list []string) []string {
+	seen := map[string]bool{}
+	out := list[:0:0]
+	for _, s := range list {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
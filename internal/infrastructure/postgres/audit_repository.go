@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type AuditRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewAuditRepository(db *DBAdapter) *AuditRepository {
+	return &AuditRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, e audit.Entry) (*audit.Entry, error) {
+	res, err := r.queries.CreateAuditLog(ctx, sqlc.CreateAuditLogParams{
+		ActorID:    pgtype.Int8{Int64: e.ActorID, Valid: e.ActorID != 0},
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   pgtype.Int8{Int64: e.TargetID, Valid: e.TargetID != 0},
+		Details:    pgtype.Text{String: e.Details, Valid: e.Details != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCAuditLogToDomain(res), nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	rows, err := r.queries.ListAuditLogs(ctx, sqlc.ListAuditLogsParams{
+		ActorID:    pgtype.Int8{Int64: f.ActorID, Valid: f.ActorID != 0},
+		Action:     pgtype.Text{String: f.Action, Valid: f.Action != ""},
+		TargetType: pgtype.Text{String: f.TargetType, Valid: f.TargetType != ""},
+		TargetID:   pgtype.Int8{Int64: f.TargetID, Valid: f.TargetID != 0},
+		Lim:        int32(f.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []audit.Entry
+	for _, row := range rows {
+		list = append(list, *mapSQLCAuditLogToDomain(row))
+	}
+	return list, nil
+}
+
+func mapSQLCAuditLogToDomain(row sqlc.AuditLog) *audit.Entry {
+	return &audit.Entry{
+		ID:         row.ID,
+		ActorID:    row.ActorID.Int64,
+		Action:     row.Action,
+		TargetType: row.TargetType,
+		TargetID:   row.TargetID.Int64,
+		Details:    row.Details.String,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+}
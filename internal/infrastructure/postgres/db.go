@@ -33,6 +33,22 @@ func (d *DBAdapter) Query(ctx context.Context, sql string, args ...any) (pgx.Row
 	return d.pool.Query(ctx, sql, args...)
 }
 
+// InTx runs fn in a transaction, any error from fn rolls everything back.
+// Repositories hand the tx to their queries with sqlc's WithTx.
+func (d *DBAdapter) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// a no-op once Commit went through
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // Helper to create a new pool
 func NewPool(databaseURL string) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+// DenylistRepository is the Postgres backing store for auth.Denylist
+type DenylistRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewDenylistRepository(db *DBAdapter) *DenylistRepository {
+	return &DenylistRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *DenylistRepository) Add(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	return r.queries.RevokeToken(ctx, sqlc.RevokeTokenParams{
+		Jti:       jti,
+		UserID:    pgtype.Int8{Int64: userID, Valid: userID != 0},
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+}
+
+// ListActive also clears out rows for tokens that expired on their own,
+// it runs on every sync so the table never grows past the live window
+func (r *DenylistRepository) ListActive(ctx context.Context) (map[string]time.Time, error) {
+	if err := r.queries.DeleteExpiredRevokedTokens(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries.ListActiveRevokedTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		active[row.Jti] = row.ExpiresAt.Time
+	}
+	return active, nil
+}
+
+func (r *DenylistRepository) AddUserCutoff(ctx context.Context, userID int64, notBefore, expiresAt time.Time) error {
+	return r.queries.UpsertUserTokenCutoff(ctx, sqlc.UpsertUserTokenCutoffParams{
+		UserID:    userID,
+		NotBefore: pgtype.Timestamptz{Time: notBefore, Valid: true},
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+}
+
+func (r *DenylistRepository) ListActiveCutoffs(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := r.queries.ListActiveUserTokenCutoffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoffs := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		cutoffs[row.UserID] = row.NotBefore.Time
+	}
+	return cutoffs, nil
+}
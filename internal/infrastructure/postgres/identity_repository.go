@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type IdentityRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewIdentityRepository(db *DBAdapter) *IdentityRepository {
+	return &IdentityRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *IdentityRepository) GetIdentity(ctx context.Context, issuer, subject string) (*user.ExternalIdentity, error) {
+	row, err := r.queries.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{
+		Issuer:  issuer,
+		Subject: subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCIdentityToDomain(row), nil
+}
+
+func (r *IdentityRepository) CreateIdentity(ctx context.Context, identity user.ExternalIdentity) (*user.ExternalIdentity, error) {
+	row, err := r.queries.CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:  identity.UserID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCIdentityToDomain(row), nil
+}
+
+func (r *IdentityRepository) TouchIdentity(ctx context.Context, id int64, email string) error {
+	return r.queries.TouchUserIdentity(ctx, sqlc.TouchUserIdentityParams{
+		ID:    id,
+		Email: email,
+	})
+}
+
+func mapSQLCIdentityToDomain(row sqlc.UserIdentity) *user.ExternalIdentity {
+	return &user.ExternalIdentity{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		Issuer:      row.Issuer,
+		Subject:     row.Subject,
+		Email:       row.Email,
+		LastLoginAt: nullableTime(row.LastLoginAt),
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
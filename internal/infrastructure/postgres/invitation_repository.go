@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type InvitationRepository struct {
+	db       *DBAdapter
+	queries  *sqlc.Queries
+	projects *ProjectRepository
+}
+
+func NewInvitationRepository(db *DBAdapter, projects *ProjectRepository) *InvitationRepository {
+	return &InvitationRepository{
+		db:       db,
+		queries:  sqlc.New(db),
+		projects: projects,
+	}
+}
+
+func (r *InvitationRepository) Create(ctx context.Context, inv invitations.Invitation) (*invitations.Invitation, error) {
+	res, err := r.queries.CreateInvitation(ctx, sqlc.CreateInvitationParams{
+		ProjectID: inv.ProjectID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		InvitedBy: pgtype.Int8{Int64: inv.InvitedBy, Valid: inv.InvitedBy != 0},
+		ExpiresAt: pgtype.Timestamptz{Time: inv.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationToDomain(res), nil
+}
+
+func (r *InvitationRepository) GetByID(ctx context.Context, id int64) (*invitations.Invitation, error) {
+	res, err := r.queries.GetInvitation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationToDomain(res), nil
+}
+
+func (r *InvitationRepository) GetPending(ctx context.Context, projectID int64, email string) (*invitations.Invitation, error) {
+	res, err := r.queries.GetPendingInvitation(ctx, sqlc.GetPendingInvitationParams{
+		ProjectID: projectID,
+		Email:     email,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationToDomain(res), nil
+}
+
+func (r *InvitationRepository) ListPending(ctx context.Context, projectID int64) ([]invitations.Invitation, error) {
+	rows, err := r.queries.ListPendingInvitations(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationsToDomain(rows), nil
+}
+
+func (r *InvitationRepository) ListPendingForEmail(ctx context.Context, email string) ([]invitations.Invitation, error) {
+	rows, err := r.queries.ListPendingInvitationsForEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationsToDomain(rows), nil
+}
+
+func (r *InvitationRepository) Renew(ctx context.Context, id, projectID int64, expiresAt time.Time) (*invitations.Invitation, error) {
+	res, err := r.queries.RenewInvitation(ctx, sqlc.RenewInvitationParams{
+		ID:        id,
+		ProjectID: projectID,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInvitationToDomain(res), nil
+}
+
+func (r *InvitationRepository) Revoke(ctx context.Context, id, projectID int64) (bool, error) {
+	affected, err := r.queries.RevokeInvitation(ctx, sqlc.RevokeInvitationParams{
+		ID:        id,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *InvitationRepository) Accept(ctx context.Context, id, userID int64, join *invitations.Membership) (bool, error) {
+	accepted := false
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		affected, err := q.AcceptInvitation(ctx, sqlc.AcceptInvitationParams{
+			ID:         id,
+			AcceptedBy: pgtype.Int8{Int64: userID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return nil
+		}
+
+		if join != nil {
+			if err := addMembership(ctx, tx, q, r.projects, *join); err != nil {
+				return err
+			}
+		}
+		accepted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return accepted, nil
+}
+
+func mapSQLCInvitationsToDomain(rows []sqlc.ProjectInvitation) []invitations.Invitation {
+	list := make([]invitations.Invitation, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *mapSQLCInvitationToDomain(row))
+	}
+	return list
+}
+
+func mapSQLCInvitationToDomain(row sqlc.ProjectInvitation) *invitations.Invitation {
+	inv := &invitations.Invitation{
+		ID:         row.ID,
+		ProjectID:  row.ProjectID,
+		Email:      row.Email,
+		Role:       row.Role,
+		InvitedBy:  row.InvitedBy.Int64,
+		CreatedAt:  row.CreatedAt.Time,
+		SentAt:     row.SentAt.Time,
+		ExpiresAt:  row.ExpiresAt.Time,
+		AcceptedAt: nullableTime(row.AcceptedAt),
+		RevokedAt:  nullableTime(row.RevokedAt),
+	}
+	if row.AcceptedBy.Valid {
+		acceptedBy := row.AcceptedBy.Int64
+		inv.AcceptedBy = &acceptedBy
+	}
+	return inv
+}
+
+// addMembership writes the project_users row of an accepted invitation, link
+// or join request, and the organization membership that comes with it,
+// inside the caller's transaction. The project row goes through the projects
+// repository so there is a single membership write path
+func addMembership(ctx context.Context, tx pgx.Tx, q *sqlc.Queries, projects *ProjectRepository, join invitations.Membership) error {
+	if err := projects.AddUserToProjectTx(ctx, tx, join.ProjectID, join.UserID, join.Role); err != nil {
+		return err
+	}
+	if join.OrganizationID == 0 {
+		return nil
+	}
+	return q.AddOrganizationMember(ctx, sqlc.AddOrganizationMemberParams{
+		OrganizationID: join.OrganizationID,
+		UserID:         join.UserID,
+		Role:           organizations.RoleMember,
+	})
+}
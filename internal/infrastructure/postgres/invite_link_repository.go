@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type InviteLinkRepository struct {
+	db       *DBAdapter
+	queries  *sqlc.Queries
+	projects *ProjectRepository
+}
+
+func NewInviteLinkRepository(db *DBAdapter, projects *ProjectRepository) *InviteLinkRepository {
+	return &InviteLinkRepository{
+		db:       db,
+		queries:  sqlc.New(db),
+		projects: projects,
+	}
+}
+
+func (r *InviteLinkRepository) Create(ctx context.Context, l invitations.InviteLink) (*invitations.InviteLink, error) {
+	expiresAt := pgtype.Timestamptz{}
+	if l.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *l.ExpiresAt, Valid: true}
+	}
+	maxUses := pgtype.Int4{}
+	if l.MaxUses != nil {
+		maxUses = pgtype.Int4{Int32: int32(*l.MaxUses), Valid: true}
+	}
+
+	res, err := r.queries.CreateInviteLink(ctx, sqlc.CreateInviteLinkParams{
+		ProjectID:  l.ProjectID,
+		CodeHash:   l.CodeHash,
+		CodePrefix: l.Prefix,
+		Role:       l.Role,
+		CreatedBy:  pgtype.Int8{Int64: l.CreatedBy, Valid: l.CreatedBy != 0},
+		ExpiresAt:  expiresAt,
+		MaxUses:    maxUses,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInviteLinkToDomain(res), nil
+}
+
+func (r *InviteLinkRepository) GetByHash(ctx context.Context, codeHash string) (*invitations.InviteLink, error) {
+	res, err := r.queries.GetInviteLinkByHash(ctx, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCInviteLinkToDomain(res), nil
+}
+
+func (r *InviteLinkRepository) List(ctx context.Context, projectID int64) ([]invitations.InviteLink, error) {
+	rows, err := r.queries.ListInviteLinks(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]invitations.InviteLink, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *mapSQLCInviteLinkToDomain(row))
+	}
+	return list, nil
+}
+
+func (r *InviteLinkRepository) Revoke(ctx context.Context, id, projectID int64) (bool, error) {
+	affected, err := r.queries.RevokeInviteLink(ctx, sqlc.RevokeInviteLinkParams{
+		ID:        id,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *InviteLinkRepository) Use(ctx context.Context, id int64, join invitations.Membership) (*invitations.InviteLink, error) {
+	var link *invitations.InviteLink
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		res, err := q.UseInviteLink(ctx, id)
+		// no row means the link ran out between the lookup and now
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := addMembership(ctx, tx, q, r.projects, join); err != nil {
+			return err
+		}
+		link = mapSQLCInviteLinkToDomain(res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func mapSQLCInviteLinkToDomain(row sqlc.ProjectInviteLink) *invitations.InviteLink {
+	l := &invitations.InviteLink{
+		ID:        row.ID,
+		ProjectID: row.ProjectID,
+		CodeHash:  row.CodeHash,
+		Prefix:    row.CodePrefix,
+		Role:      row.Role,
+		CreatedBy: row.CreatedBy.Int64,
+		CreatedAt: row.CreatedAt.Time,
+		ExpiresAt: nullableTime(row.ExpiresAt),
+		UseCount:  int(row.UseCount),
+		RevokedAt: nullableTime(row.RevokedAt),
+	}
+	if row.MaxUses.Valid {
+		maxUses := int(row.MaxUses.Int32)
+		l.MaxUses = &maxUses
+	}
+	return l
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type JoinRequestRepository struct {
+	db       *DBAdapter
+	queries  *sqlc.Queries
+	projects *ProjectRepository
+}
+
+func NewJoinRequestRepository(db *DBAdapter, projects *ProjectRepository) *JoinRequestRepository {
+	return &JoinRequestRepository{
+		db:       db,
+		queries:  sqlc.New(db),
+		projects: projects,
+	}
+}
+
+func (r *JoinRequestRepository) Create(ctx context.Context, jr invitations.JoinRequest) (*invitations.JoinRequest, error) {
+	res, err := r.queries.CreateJoinRequest(ctx, sqlc.CreateJoinRequestParams{
+		ProjectID: jr.ProjectID,
+		UserID:    jr.UserID,
+		Message:   jr.Message,
+	})
+	// the partial unique index allows one open request per user and project
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, invitations.ErrAlreadyRequested
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCJoinRequestToDomain(res), nil
+}
+
+func (r *JoinRequestRepository) ListPending(ctx context.Context, projectID int64) ([]invitations.JoinRequest, error) {
+	rows, err := r.queries.ListPendingJoinRequests(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]invitations.JoinRequest, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, invitations.JoinRequest{
+			ID:        row.ID,
+			ProjectID: row.ProjectID,
+			UserID:    row.UserID,
+			Profile:   profiles.Summary{ID: row.UserID, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl},
+			Message:   row.Message,
+			Status:    invitations.JoinPending,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return list, nil
+}
+
+func (r *JoinRequestRepository) GetPending(ctx context.Context, id, projectID int64) (*invitations.JoinRequest, error) {
+	res, err := r.queries.GetPendingJoinRequest(ctx, sqlc.GetPendingJoinRequestParams{
+		ID:        id,
+		ProjectID: projectID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCJoinRequestToDomain(res), nil
+}
+
+func (r *JoinRequestRepository) Decide(ctx context.Context, id, projectID int64, status string, deciderID int64) (*invitations.JoinRequest, error) {
+	res, err := r.queries.DecideJoinRequest(ctx, sqlc.DecideJoinRequestParams{
+		ID:        id,
+		ProjectID: projectID,
+		Status:    status,
+		DecidedBy: pgtype.Int8{Int64: deciderID, Valid: true},
+	})
+	// no row means another manager answered it first
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCJoinRequestToDomain(res), nil
+}
+
+func (r *JoinRequestRepository) Approve(ctx context.Context, id, projectID, deciderID int64, join *invitations.Membership) (*invitations.JoinRequest, error) {
+	var req *invitations.JoinRequest
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		res, err := q.DecideJoinRequest(ctx, sqlc.DecideJoinRequestParams{
+			ID:        id,
+			ProjectID: projectID,
+			Status:    invitations.JoinApproved,
+			DecidedBy: pgtype.Int8{Int64: deciderID, Valid: true},
+		})
+		// no row means another manager answered it first
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if join != nil {
+			if err := addMembership(ctx, tx, q, r.projects, *join); err != nil {
+				return err
+			}
+		}
+		req = mapSQLCJoinRequestToDomain(res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func mapSQLCJoinRequestToDomain(row sqlc.ProjectJoinRequest) *invitations.JoinRequest {
+	jr := &invitations.JoinRequest{
+		ID:        row.ID,
+		ProjectID: row.ProjectID,
+		UserID:    row.UserID,
+		Message:   row.Message,
+		Status:    row.Status,
+		CreatedAt: row.CreatedAt.Time,
+		DecidedAt: nullableTime(row.DecidedAt),
+	}
+	if row.DecidedBy.Valid {
+		jr.DecidedBy = &row.DecidedBy.Int64
+	}
+	return jr
+}
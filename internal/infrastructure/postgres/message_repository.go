@@ -5,6 +5,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
 )
 
@@ -52,13 +53,13 @@ func (r *MessageRepository) Create(ctx context.Context, m messages.Message) (*me
 
 	// Map back to domain model
 	return &messages.Message{
-		ID:          res.ID,
-		SenderID:    res.SenderID,
-		Content:     res.Content,
-		CreatedAt:   res.CreatedAt.Time,
-		SenderEmail: res.SenderEmail,
-		ProjectID:   m.ProjectID,
-		ReceiverID:  m.ReceiverID,
+		ID:         res.ID,
+		SenderID:   res.SenderID,
+		Content:    res.Content,
+		CreatedAt:  res.CreatedAt.Time,
+		Sender:     profiles.Summary{ID: res.SenderID, DisplayName: res.SenderDisplayName, AvatarURL: res.SenderAvatarUrl},
+		ProjectID:  m.ProjectID,
+		ReceiverID: m.ReceiverID,
 	}, nil
 }
 
@@ -71,11 +72,11 @@ func (r *MessageRepository) GetByProject(ctx context.Context, projectID int64) (
 	var list []messages.Message
 	for _, row := range rows {
 		msg := messages.Message{
-			ID:          row.ID,
-			SenderID:    row.SenderID,
-			Content:     row.Content,
-			CreatedAt:   row.CreatedAt.Time,
-			SenderEmail: row.SenderEmail,
+			ID:        row.ID,
+			SenderID:  row.SenderID,
+			Content:   row.Content,
+			CreatedAt: row.CreatedAt.Time,
+			Sender:    profiles.Summary{ID: row.SenderID, DisplayName: row.SenderDisplayName, AvatarURL: row.SenderAvatarUrl},
 		}
 		if row.ProjectID.Valid {
 			val := row.ProjectID.Int64
@@ -100,11 +101,11 @@ func (r *MessageRepository) GetDirectMessages(ctx context.Context, userA, userB
 	var list []messages.Message
 	for _, row := range rows {
 		msg := messages.Message{
-			ID:          row.ID,
-			SenderID:    row.SenderID,
-			Content:     row.Content,
-			CreatedAt:   row.CreatedAt.Time,
-			SenderEmail: row.SenderEmail,
+			ID:        row.ID,
+			SenderID:  row.SenderID,
+			Content:   row.Content,
+			CreatedAt: row.CreatedAt.Time,
+			Sender:    profiles.Summary{ID: row.SenderID, DisplayName: row.SenderDisplayName, AvatarURL: row.SenderAvatarUrl},
 		}
 		if row.ReceiverID.Valid {
 			val := row.ReceiverID.Int64
@@ -0,0 +1,14 @@
+-- name: create_revoked_tokens_table
+CREATE TABLE revoked_tokens (
+    -- the jti claim of the revoked access token
+    jti TEXT PRIMARY KEY,
+
+    -- NULL when an admin revoked a bare jti without knowing the owner
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+
+    -- once the token itself has expired the row is no longer needed
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
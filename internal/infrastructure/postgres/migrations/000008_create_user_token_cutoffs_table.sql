@@ -0,0 +1,10 @@
+-- name: create_user_token_cutoffs_table
+CREATE TABLE user_token_cutoffs (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+
+    -- access tokens with an iat before this are rejected
+    not_before TIMESTAMPTZ NOT NULL,
+
+    -- after this every token issued before not_before has expired anyway
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,16 @@
+-- name: create_audit_logs_table
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- who did it, NULL for the system itself (lockouts, purge jobs...)
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+
+    action TEXT NOT NULL,      -- e.g. 'user.suspended', 'user.role_changed'
+    target_type TEXT NOT NULL, -- e.g. 'user', 'project'
+    target_id BIGINT,
+    details TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
@@ -0,0 +1,24 @@
+-- name: create_two_factor_tables
+CREATE TABLE user_totp_secrets (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,      -- base32, needed in clear to compute codes
+    enabled_at TIMESTAMPTZ,    -- NULL until the first code is confirmed
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- a code can't be replayed in its window
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, code_hash)
+);
+
+-- roles that can't log in without a second factor
+CREATE TABLE two_factor_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
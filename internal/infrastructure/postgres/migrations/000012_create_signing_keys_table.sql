@@ -0,0 +1,11 @@
+-- name: create_signing_keys_table
+CREATE TABLE signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL DEFAULT 'EdDSA',
+    private_key BYTEA NOT NULL, -- whoever can read this table can mint tokens, same as JWT_SECRET before
+    public_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL -- after this no token signed with the key can still be valid
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys(expires_at);
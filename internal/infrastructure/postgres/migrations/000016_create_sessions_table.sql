@@ -0,0 +1,19 @@
+-- name: create_sessions_table
+-- one row per login, the refresh token family carries it from rotation to rotation
+CREATE TABLE sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id TEXT NOT NULL UNIQUE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+-- logins from before this table still show up, just without device details
+INSERT INTO sessions (user_id, family_id, created_at, last_seen_at)
+SELECT user_id, family_id, MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY user_id, family_id;
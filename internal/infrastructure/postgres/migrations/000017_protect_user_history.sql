@@ -0,0 +1,22 @@
+-- name: protect_user_history
+-- accounts are anonymized instead of deleted (DELETE /me). A hard delete
+-- of a user row must fail rather than take other people's history with it.
+
+-- chat history: sent and received messages used to vanish with the user
+ALTER TABLE messages DROP CONSTRAINT messages_sender_id_fkey;
+ALTER TABLE messages ADD CONSTRAINT messages_sender_id_fkey
+    FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE messages DROP CONSTRAINT messages_receiver_id_fkey;
+ALTER TABLE messages ADD CONSTRAINT messages_receiver_id_fkey
+    FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- task history: same behaviour as before, now on purpose
+ALTER TABLE task_activities DROP CONSTRAINT task_activities_user_id_fkey;
+ALTER TABLE task_activities ADD CONSTRAINT task_activities_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- a task outlives whoever it was assigned to
+ALTER TABLE tasks DROP CONSTRAINT tasks_assigned_to_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_assigned_to_fkey
+    FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,22 @@
+-- name: create_project_invitations_table
+-- invitations go to an email address, the person may not have an account yet
+CREATE TABLE project_invitations (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+-- one open invitation per address and project, resending reuses it
+CREATE UNIQUE INDEX idx_project_invitations_pending
+    ON project_invitations(project_id, lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+CREATE INDEX idx_project_invitations_email ON project_invitations(lower(email));
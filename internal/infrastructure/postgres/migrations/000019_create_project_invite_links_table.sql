@@ -0,0 +1,17 @@
+-- name: create_project_invite_links_table
+-- join links owners can paste into a chat, only the hash of the code is stored
+CREATE TABLE project_invite_links (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL UNIQUE,
+    code_prefix TEXT NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    max_uses INTEGER CHECK (max_uses > 0),
+    use_count INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_project_invite_links_project_id ON project_invite_links(project_id);
@@ -0,0 +1,26 @@
+-- name: project_member_roles
+-- project roles are now owner, maintainer, member and viewer. The old
+-- 'admin' is what maintainer replaces, anything else becomes a member.
+UPDATE project_users SET role = 'maintainer' WHERE role = 'admin';
+UPDATE project_invitations SET role = 'maintainer' WHERE role = 'admin';
+UPDATE project_invite_links SET role = 'maintainer' WHERE role = 'admin';
+
+UPDATE project_users pu
+SET role = CASE WHEN pu.user_id = p.owner_id THEN 'owner' ELSE 'member' END
+FROM projects p
+WHERE p.id = pu.project_id
+  AND (pu.user_id = p.owner_id
+       OR pu.role IS NULL
+       OR pu.role NOT IN ('maintainer', 'member', 'viewer'));
+
+-- owners who were never added as members
+INSERT INTO project_users (project_id, user_id, role)
+SELECT p.id, p.owner_id, 'owner' FROM projects p
+WHERE NOT EXISTS (
+    SELECT 1 FROM project_users pu
+    WHERE pu.project_id = p.id AND pu.user_id = p.owner_id
+);
+
+ALTER TABLE project_users
+    ADD CONSTRAINT project_users_role_check
+    CHECK (role IN ('owner', 'maintainer', 'member', 'viewer'));
@@ -0,0 +1,8 @@
+-- name: archive_and_soft_delete_projects
+-- archived projects are read-only, deleted ones wait out the retention
+-- period (PROJECT_RETENTION) before the purge job removes them for good,
+-- taking their tasks and chat with them
+ALTER TABLE projects ADD COLUMN archived_at TIMESTAMPTZ;
+ALTER TABLE projects ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_projects_deleted_at ON projects(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,14 @@
+-- name: create_project_templates_table
+-- reusable project skeletons. Tasks and members are snapshots, so later
+-- changes to the source project don't leak into projects made from them.
+CREATE TABLE project_templates (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    tasks JSONB NOT NULL DEFAULT '[]',
+    members JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_project_templates_owner_id ON project_templates(owner_id);
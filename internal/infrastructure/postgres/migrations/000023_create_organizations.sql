@@ -0,0 +1,32 @@
+-- name: create_organizations
+-- organizations own projects and decide who can see whom. Everything
+-- before them lived in one space, which becomes the first organization.
+CREATE TABLE organizations (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE organization_members (
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+INSERT INTO organizations (name) VALUES ('Default');
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT o.id, u.id, CASE WHEN u.role = 'admin' THEN 'admin' ELSE 'member' END
+FROM organizations o, users u
+WHERE u.status <> 'deleted';
+
+ALTER TABLE projects ADD COLUMN organization_id BIGINT REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE projects SET organization_id = (SELECT MIN(id) FROM organizations);
+ALTER TABLE projects ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_projects_organization_id ON projects(organization_id);
@@ -0,0 +1,17 @@
+-- name: create_project_join_requests_table
+-- people asking to be let into a project, owners and maintainers answer them
+CREATE TABLE project_join_requests (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ,
+    decided_by BIGINT REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- one open request per user and project, asking again after a decision is fine
+CREATE UNIQUE INDEX idx_project_join_requests_pending
+    ON project_join_requests(project_id, user_id)
+    WHERE status = 'pending';
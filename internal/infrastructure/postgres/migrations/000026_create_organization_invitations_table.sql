@@ -0,0 +1,19 @@
+-- name: create_organization_invitations_table
+-- people join an organization by accepting an invitation mailed to them,
+-- admins can't pull accounts in on their own
+CREATE TABLE organization_invitations (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by BIGINT REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- one open invitation per address and organization, inviting again renews it
+CREATE UNIQUE INDEX idx_organization_invitations_pending
+    ON organization_invitations(organization_id, lower(email))
+    WHERE accepted_at IS NULL;
@@ -0,0 +1,275 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type OrganizationRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewOrganizationRepository(db *DBAdapter) *OrganizationRepository {
+	return &OrganizationRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, name string, creatorID int64) (*organizations.Organization, error) {
+	var org *organizations.Organization
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		row, err := q.CreateOrganization(ctx, sqlc.CreateOrganizationParams{
+			Name:      name,
+			CreatedBy: pgtype.Int8{Int64: creatorID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.AddOrganizationMember(ctx, sqlc.AddOrganizationMemberParams{
+			OrganizationID: row.ID,
+			UserID:         creatorID,
+			Role:           organizations.RoleAdmin,
+		}); err != nil {
+			return err
+		}
+		org = organizationFromRow(row.ID, row.Name, row.CreatedBy, row.CreatedAt)
+		return nil
+	})
+	return org, err
+}
+
+func (r *OrganizationRepository) GetByID(ctx context.Context, id int64) (*organizations.Organization, error) {
+	row, err := r.queries.GetOrganization(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, organizations.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return organizationFromRow(row.ID, row.Name, row.CreatedBy, row.CreatedAt), nil
+}
+
+func (r *OrganizationRepository) ListForUser(ctx context.Context, userID int64) ([]organizations.Organization, error) {
+	rows, err := r.queries.ListOrganizationsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Organization, 0, len(rows))
+	for _, row := range rows {
+		org := organizationFromRow(row.ID, row.Name, row.CreatedBy, row.CreatedAt)
+		org.Role = row.Role
+		res = append(res, *org)
+	}
+	return res, nil
+}
+
+func (r *OrganizationRepository) ListMembers(ctx context.Context, orgID int64) ([]organizations.Member, error) {
+	rows, err := r.queries.ListOrganizationMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Member, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, organizations.Member{
+			UserID:   row.UserID,
+			Email:    row.Email,
+			Role:     row.Role,
+			JoinedAt: row.CreatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+func (r *OrganizationRepository) MemberRole(ctx context.Context, orgID, userID int64) (string, error) {
+	role, err := r.queries.GetOrganizationMemberRole(ctx, sqlc.GetOrganizationMemberRoleParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+func (r *OrganizationRepository) AddMember(ctx context.Context, orgID, userID int64, role string) error {
+	return r.queries.AddOrganizationMember(ctx, sqlc.AddOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	})
+}
+
+func (r *OrganizationRepository) SetMemberRole(ctx context.Context, orgID, userID int64, role string) error {
+	n, err := r.queries.SetOrganizationMemberRole(ctx, sqlc.SetOrganizationMemberRoleParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return organizations.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) ([]int64, error) {
+	var projectIDs []int64
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		ids, err := q.ListUserOrganizationProjectIDs(ctx, sqlc.ListUserOrganizationProjectIDsParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.RemoveUserFromOrganizationProjects(ctx, sqlc.RemoveUserFromOrganizationProjectsParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		}); err != nil {
+			return err
+		}
+		if err := q.RemoveUserFromOrganizationTeams(ctx, sqlc.RemoveUserFromOrganizationTeamsParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		}); err != nil {
+			return err
+		}
+		n, err := q.RemoveOrganizationMember(ctx, sqlc.RemoveOrganizationMemberParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return organizations.ErrMemberNotFound
+		}
+		projectIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return projectIDs, nil
+}
+
+func (r *OrganizationRepository) CountAdmins(ctx context.Context, orgID int64) (int64, error) {
+	return r.queries.CountOrganizationAdmins(ctx, orgID)
+}
+
+func (r *OrganizationRepository) CountOwnedProjects(ctx context.Context, orgID, userID int64) (int64, error) {
+	return r.queries.CountOrganizationProjectsOwnedBy(ctx, sqlc.CountOrganizationProjectsOwnedByParams{
+		OrganizationID: orgID,
+		OwnerID:        userID,
+	})
+}
+
+func (r *OrganizationRepository) ShareOrganization(ctx context.Context, viewerID, userID int64) (bool, error) {
+	return r.queries.CheckSharedOrganization(ctx, sqlc.CheckSharedOrganizationParams{
+		ViewerID: viewerID,
+		UserID:   userID,
+	})
+}
+
+func organizationFromRow(id int64, name string, createdBy pgtype.Int8, createdAt pgtype.Timestamptz) *organizations.Organization {
+	org := &organizations.Organization{
+		ID:        id,
+		Name:      name,
+		CreatedAt: createdAt.Time,
+	}
+	if createdBy.Valid {
+		org.CreatedBy = &createdBy.Int64
+	}
+	return org
+}
+
+func (r *OrganizationRepository) CreateInvitation(ctx context.Context, inv organizations.Invitation) (*organizations.Invitation, error) {
+	row, err := r.queries.UpsertOrganizationInvitation(ctx, sqlc.UpsertOrganizationInvitationParams{
+		OrganizationID: inv.OrganizationID,
+		Email:          inv.Email,
+		Role:           inv.Role,
+		InvitedBy:      pgtype.Int8{Int64: inv.InvitedBy, Valid: inv.InvitedBy != 0},
+		ExpiresAt:      pgtype.Timestamptz{Time: inv.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return organizationInvitationFromRow(row), nil
+}
+
+func (r *OrganizationRepository) GetInvitation(ctx context.Context, id int64) (*organizations.Invitation, error) {
+	row, err := r.queries.GetOrganizationInvitation(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return organizationInvitationFromRow(row), nil
+}
+
+func (r *OrganizationRepository) AcceptInvitation(ctx context.Context, id, userID int64) (bool, error) {
+	accepted := false
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		inv, err := q.GetOrganizationInvitation(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		affected, err := q.AcceptOrganizationInvitation(ctx, sqlc.AcceptOrganizationInvitationParams{
+			ID:         id,
+			AcceptedBy: pgtype.Int8{Int64: userID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return nil
+		}
+		if err := q.AddOrganizationMember(ctx, sqlc.AddOrganizationMemberParams{
+			OrganizationID: inv.OrganizationID,
+			UserID:         userID,
+			Role:           inv.Role,
+		}); err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return accepted, nil
+}
+
+func organizationInvitationFromRow(row sqlc.OrganizationInvitation) *organizations.Invitation {
+	return &organizations.Invitation{
+		ID:             row.ID,
+		OrganizationID: row.OrganizationID,
+		Email:          row.Email,
+		Role:           row.Role,
+		InvitedBy:      row.InvitedBy.Int64,
+		CreatedAt:      row.CreatedAt.Time,
+		ExpiresAt:      row.ExpiresAt.Time,
+		AcceptedAt:     nullableTime(row.AcceptedAt),
+	}
+}
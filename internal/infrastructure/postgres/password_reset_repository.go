@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type PasswordResetRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewPasswordResetRepository(db *DBAdapter) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, t user.PasswordResetToken) (*user.PasswordResetToken, error) {
+	res, err := r.queries.CreatePasswordResetToken(ctx, sqlc.CreatePasswordResetTokenParams{
+		UserID:    t.UserID,
+		TokenHash: t.TokenHash,
+		ExpiresAt: pgtype.Timestamptz{Time: t.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCPasswordResetToDomain(res), nil
+}
+
+func (r *PasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*user.PasswordResetToken, error) {
+	res, err := r.queries.GetPasswordResetTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCPasswordResetToDomain(res), nil
+}
+
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	affected, err := r.queries.MarkPasswordResetTokenUsed(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *PasswordResetRepository) InvalidateForUser(ctx context.Context, userID int64) error {
+	return r.queries.InvalidatePasswordResetTokensForUser(ctx, userID)
+}
+
+func mapSQLCPasswordResetToDomain(row sqlc.PasswordResetToken) *user.PasswordResetToken {
+	return &user.PasswordResetToken{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		TokenHash: row.TokenHash,
+		ExpiresAt: row.ExpiresAt.Time,
+		UsedAt:    nullableTime(row.UsedAt),
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
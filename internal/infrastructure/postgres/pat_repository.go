@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type PATRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewPATRepository(db *DBAdapter) *PATRepository {
+	return &PATRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *PATRepository) Create(ctx context.Context, t pats.Token) (*pats.Token, error) {
+	expiresAt := pgtype.Timestamptz{}
+	if t.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *t.ExpiresAt, Valid: true}
+	}
+
+	res, err := r.queries.CreatePersonalAccessToken(ctx, sqlc.CreatePersonalAccessTokenParams{
+		UserID:      t.UserID,
+		Name:        t.Name,
+		TokenHash:   t.TokenHash,
+		TokenPrefix: t.Prefix,
+		Scopes:      t.Scopes,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCPATToDomain(res), nil
+}
+
+func (r *PATRepository) GetByHash(ctx context.Context, tokenHash string) (*pats.Token, error) {
+	res, err := r.queries.GetPersonalAccessTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCPATToDomain(res), nil
+}
+
+func (r *PATRepository) ListByUser(ctx context.Context, userID int64) ([]pats.Token, error) {
+	rows, err := r.queries.ListPersonalAccessTokensByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]pats.Token, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *mapSQLCPATToDomain(row))
+	}
+	return list, nil
+}
+
+func (r *PATRepository) Revoke(ctx context.Context, id, userID int64) (bool, error) {
+	affected, err := r.queries.RevokePersonalAccessToken(ctx, sqlc.RevokePersonalAccessTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *PATRepository) RevokeAll(ctx context.Context, userID int64) error {
+	return r.queries.RevokeAllPersonalAccessTokens(ctx, userID)
+}
+
+func (r *PATRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	return r.queries.TouchPersonalAccessToken(ctx, id)
+}
+
+func mapSQLCPATToDomain(row sqlc.PersonalAccessToken) *pats.Token {
+	return &pats.Token{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		TokenHash:  row.TokenHash,
+		Prefix:     row.TokenPrefix,
+		Scopes:     row.Scopes,
+		ExpiresAt:  nullableTime(row.ExpiresAt),
+		LastUsedAt: nullableTime(row.LastUsedAt),
+		RevokedAt:  nullableTime(row.RevokedAt),
+		CreatedAt:  row.CreatedAt.Time,
+	}
+}
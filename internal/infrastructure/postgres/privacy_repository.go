@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/privacy"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/tasks"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type PrivacyRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewPrivacyRepository(db *DBAdapter) *PrivacyRepository {
+	return &PrivacyRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *PrivacyRepository) PersonalData(ctx context.Context, userID int64) (*privacy.Export, error) {
+	export := &privacy.Export{
+		OwnedProjects: []projects.Project{},
+		AssignedTasks: []tasks.Task{},
+		TaskActivity:  []tasks.TaskActivity{},
+		Messages:      []messages.Message{},
+	}
+
+	owned, err := r.queries.ListProjectsOwnedByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range owned {
+		export.OwnedProjects = append(export.OwnedProjects, projects.Project{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description.String,
+			OwnerID:     row.OwnerID,
+			CreatedAt:   row.CreatedAt.Time,
+		})
+	}
+
+	assigned, err := r.queries.ListTasksAssignedToUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range assigned {
+		export.AssignedTasks = append(export.AssignedTasks, *mapSQLCTaskToDomain(row))
+	}
+
+	activity, err := r.queries.ListTaskActivitiesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range activity {
+		export.TaskActivity = append(export.TaskActivity, tasks.TaskActivity{
+			ID:        row.ID,
+			TaskID:    row.TaskID,
+			UserID:    row.UserID,
+			Action:    row.Action,
+			Details:   row.Details.String,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+
+	msgs, err := r.queries.ListMessagesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range msgs {
+		msg := messages.Message{
+			ID:        row.ID,
+			SenderID:  row.SenderID,
+			Sender:    profiles.Summary{ID: row.SenderID},
+			Content:   row.Content,
+			CreatedAt: row.CreatedAt.Time,
+		}
+		if row.ProjectID.Valid {
+			val := row.ProjectID.Int64
+			msg.ProjectID = &val
+		}
+		if row.ReceiverID.Valid {
+			val := row.ReceiverID.Int64
+			msg.ReceiverID = &val
+		}
+		export.Messages = append(export.Messages, msg)
+	}
+
+	return export, nil
+}
+
+func (r *PrivacyRepository) Anonymize(ctx context.Context, a privacy.Anonymization) (*privacy.Erasure, error) {
+	erasure := &privacy.Erasure{TransferredProjects: map[int64]int64{}}
+
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		owned, err := q.ListOwnedProjectsWithSuccessor(ctx, a.UserID)
+		if err != nil {
+			return err
+		}
+		for _, p := range owned {
+			if p.SuccessorID == 0 {
+				// same soft delete as DELETE /projects/:id, the purge job
+				// removes it with its tasks and chat after the retention period
+				if _, err := q.SoftDeleteProject(ctx, p.ID); err != nil {
+					return err
+				}
+				erasure.DeletedProjects = append(erasure.DeletedProjects, p.ID)
+				continue
+			}
+			if err := q.SetProjectOwner(ctx, sqlc.SetProjectOwnerParams{ID: p.ID, OwnerID: p.SuccessorID}); err != nil {
+				return err
+			}
+			if err := q.UpsertProjectOwnerMember(ctx, sqlc.UpsertProjectOwnerMemberParams{
+				ProjectID: p.ID,
+				UserID:    p.SuccessorID,
+			}); err != nil {
+				return err
+			}
+			erasure.TransferredProjects[p.ID] = p.SuccessorID
+		}
+
+		if err := q.RemoveUserFromAllProjects(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.UnassignUserTasks(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteOrganizationMembershipsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteTeamMembershipsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteJoinRequestsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+
+		// credentials and everything that could still sign the user in
+		if err := q.RevokeRefreshTokensForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteSessionsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteProjectTemplatesForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.InvalidatePasswordResetTokensForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeletePersonalAccessTokensForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserIdentities(ctx, a.UserID); err != nil {
+			return err
+		}
+		// before AnonymizeUser, it matches on the real address
+		if err := q.DeleteInvitationsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteOrganizationInvitationsForUser(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteTOTPSecret(ctx, a.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteRecoveryCodes(ctx, a.UserID); err != nil {
+			return err
+		}
+
+		// the profile stays, it's what old messages and task history show
+		if err := q.DeleteUserAvatar(ctx, a.UserID); err != nil {
+			return err
+		}
+		if _, err := q.UpsertUserProfile(ctx, sqlc.UpsertUserProfileParams{
+			UserID:      a.UserID,
+			DisplayName: a.DisplayName,
+			Timezone:    "UTC",
+			Locale:      "en",
+		}); err != nil {
+			return err
+		}
+
+		return q.AnonymizeUser(ctx, sqlc.AnonymizeUserParams{
+			ID:           a.UserID,
+			Email:        a.Email,
+			PasswordHash: a.PasswordHash,
+			Status:       user.StatusDeleted,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
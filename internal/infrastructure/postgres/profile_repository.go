@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type ProfileRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewProfileRepository(db *DBAdapter) *ProfileRepository {
+	return &ProfileRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *ProfileRepository) Get(ctx context.Context, userID int64) (*profiles.Profile, error) {
+	row, err := r.queries.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &profiles.Profile{
+		UserID:      row.UserID,
+		DisplayName: row.DisplayName,
+		AvatarURL:   row.AvatarUrl,
+		Timezone:    row.Timezone,
+		Locale:      row.Locale,
+		Bio:         row.Bio,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
+
+func (r *ProfileRepository) Upsert(ctx context.Context, p profiles.Profile) (*profiles.Profile, error) {
+	row, err := r.queries.UpsertUserProfile(ctx, sqlc.UpsertUserProfileParams{
+		UserID:      p.UserID,
+		DisplayName: p.DisplayName,
+		AvatarUrl:   p.AvatarURL,
+		Timezone:    p.Timezone,
+		Locale:      p.Locale,
+		Bio:         p.Bio,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profiles.Profile{
+		UserID:      row.UserID,
+		DisplayName: row.DisplayName,
+		AvatarURL:   row.AvatarUrl,
+		Timezone:    row.Timezone,
+		Locale:      row.Locale,
+		Bio:         row.Bio,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
+
+func (r *ProfileRepository) SaveAvatar(ctx context.Context, a profiles.Avatar) error {
+	return r.queries.UpsertUserAvatar(ctx, sqlc.UpsertUserAvatarParams{
+		UserID:      a.UserID,
+		ContentType: a.ContentType,
+		Data:        a.Data,
+	})
+}
+
+func (r *ProfileRepository) GetAvatar(ctx context.Context, userID int64) (*profiles.Avatar, error) {
+	row, err := r.queries.GetUserAvatar(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &profiles.Avatar{
+		UserID:      row.UserID,
+		ContentType: row.ContentType,
+		Data:        row.Data,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
+
+func (r *ProfileRepository) DeleteAvatar(ctx context.Context, userID int64) error {
+	return r.queries.DeleteUserAvatar(ctx, userID)
+}
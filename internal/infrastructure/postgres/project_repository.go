@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 	"github.com/nelfander/Playingfield/internal/domain/projects"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
 )
@@ -23,9 +26,10 @@ func NewProjectRepository(db *DBAdapter) *ProjectRepository {
 func (r *ProjectRepository) CreateProject(ctx context.Context, p projects.Project) (*projects.Project, error) {
 	// call generated SQLC method
 	res, err := r.queries.CreateProject(ctx, sqlc.CreateProjectParams{
-		Name:        p.Name,
-		Description: pgtype.Text{String: p.Description, Valid: p.Description != ""},
-		OwnerID:     p.OwnerID,
+		Name:           p.Name,
+		Description:    pgtype.Text{String: p.Description, Valid: p.Description != ""},
+		OwnerID:        p.OwnerID,
+		OrganizationID: p.OrganizationID,
 	})
 	if err != nil {
 		return nil, err
@@ -33,15 +37,91 @@ func (r *ProjectRepository) CreateProject(ctx context.Context, p projects.Projec
 
 	// map it back to /domain/project
 	return &projects.Project{
-		ID:          res.ID,
-		Name:        res.Name,
-		Description: res.Description.String,
-		OwnerID:     res.OwnerID,
-		CreatedAt:   res.CreatedAt.Time,
-		OwnerName:   res.OwnerName,
+		ID:             res.ID,
+		Name:           res.Name,
+		Description:    res.Description.String,
+		OwnerID:        res.OwnerID,
+		OrganizationID: res.OrganizationID,
+		CreatedAt:      res.CreatedAt.Time,
+		Owner:          profiles.Summary{ID: res.OwnerID, DisplayName: res.OwnerDisplayName, AvatarURL: res.OwnerAvatarUrl},
 	}, nil
 }
 
+// CreateProjectFrom writes the project, its members and its tasks in one
+// transaction, a failure halfway leaves nothing behind
+func (r *ProjectRepository) CreateProjectFrom(ctx context.Context, p projects.Project, seed projects.Blueprint) (*projects.Project, error) {
+	var created *projects.Project
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		res, err := q.CreateProject(ctx, sqlc.CreateProjectParams{
+			Name:           p.Name,
+			Description:    pgtype.Text{String: p.Description, Valid: p.Description != ""},
+			OwnerID:        p.OwnerID,
+			OrganizationID: p.OrganizationID,
+		})
+		if err != nil {
+			return err
+		}
+
+		members := append([]projects.TemplateMember{{UserID: p.OwnerID, Role: projects.RoleOwner}}, seed.Members...)
+		for _, m := range members {
+			if err := addUserToProject(ctx, q, res.ID, m.UserID, m.Role); err != nil {
+				return err
+			}
+		}
+
+		for _, t := range seed.Tasks {
+			task, err := q.CreateTask(ctx, sqlc.CreateTaskParams{
+				ProjectID:   res.ID,
+				Title:       t.Title,
+				Description: pgtype.Text{String: t.Description, Valid: t.Description != ""},
+				Status:      projects.InitialTaskStatus,
+			})
+			if err != nil {
+				return err
+			}
+			if err := q.RecordTaskActivity(ctx, sqlc.RecordTaskActivityParams{
+				TaskID:  task.ID,
+				UserID:  p.OwnerID,
+				Action:  "CREATED",
+				Details: pgtype.Text{String: "Copied in when the project was created", Valid: true},
+			}); err != nil {
+				return err
+			}
+		}
+
+		created = &projects.Project{
+			ID:             res.ID,
+			Name:           res.Name,
+			Description:    res.Description.String,
+			OwnerID:        res.OwnerID,
+			OrganizationID: res.OrganizationID,
+			CreatedAt:      res.CreatedAt.Time,
+			Owner:          profiles.Summary{ID: res.OwnerID, DisplayName: res.OwnerDisplayName, AvatarURL: res.OwnerAvatarUrl},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListTaskSkeleton returns the project's tasks in board order, reduced to what a copy keeps
+func (r *ProjectRepository) ListTaskSkeleton(ctx context.Context, projectID int64) ([]projects.TemplateTask, error) {
+	rows, err := r.queries.ListTasksForProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []projects.TemplateTask
+	for _, row := range rows {
+		list = append(list, projects.TemplateTask{Title: row.Title, Description: row.Description.String})
+	}
+	return list, nil
+}
+
 func (r *ProjectRepository) Update(ctx context.Context, p projects.Project) (*projects.Project, error) {
 	err := r.queries.UpdateProject(ctx, sqlc.UpdateProjectParams{
 		ID:   p.ID,
@@ -58,8 +138,11 @@ func (r *ProjectRepository) Update(ctx context.Context, p projects.Project) (*pr
 }
 
 // GetAllByOwner fetches all projects the user owns OR is a member of
-func (r *ProjectRepository) GetAllByOwner(ctx context.Context, ownerID int64) ([]projects.Project, error) {
-	rows, err := r.queries.ListProjectsByOwner(ctx, ownerID)
+func (r *ProjectRepository) GetAllByOwner(ctx context.Context, ownerID int64, includeArchived bool) ([]projects.Project, error) {
+	rows, err := r.queries.ListProjectsByOwner(ctx, sqlc.ListProjectsByOwnerParams{
+		OwnerID:         ownerID,
+		IncludeArchived: includeArchived,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -68,12 +151,14 @@ func (r *ProjectRepository) GetAllByOwner(ctx context.Context, ownerID int64) ([
 	var list []projects.Project
 	for _, row := range rows {
 		list = append(list, projects.Project{
-			ID:          row.ID,
-			Name:        row.Name,
-			Description: row.Description.String,
-			OwnerID:     row.OwnerID,
-			CreatedAt:   row.CreatedAt.Time,
-			OwnerName:   row.OwnerName.String,
+			ID:             row.ID,
+			Name:           row.Name,
+			Description:    row.Description.String,
+			OwnerID:        row.OwnerID,
+			OrganizationID: row.OrganizationID,
+			CreatedAt:      row.CreatedAt.Time,
+			ArchivedAt:     nullableTime(row.ArchivedAt),
+			Owner:          profiles.Summary{ID: row.OwnerID, DisplayName: row.OwnerDisplayName, AvatarURL: row.OwnerAvatarUrl},
 		})
 	}
 
@@ -93,10 +178,12 @@ func (r *ProjectRepository) GetByID(ctx context.Context, id int64) (*projects.Pr
 		// pgtype.Text -> string
 		Description: res.Description.String,
 
-		OwnerID: res.OwnerID,
+		OwnerID:        res.OwnerID,
+		OrganizationID: res.OrganizationID,
 
 		// pgtype.Timestamp/Timestamptz -> time.Time
-		CreatedAt: res.CreatedAt.Time,
+		CreatedAt:  res.CreatedAt.Time,
+		ArchivedAt: nullableTime(res.ArchivedAt),
 	}, nil
 }
 
@@ -115,25 +202,106 @@ func (r *ProjectRepository) ListUsersInProject(ctx context.Context, projectID in
 		}
 
 		members = append(members, projects.ProjectMember{
-			ID:    row.ID,
-			Email: row.Email,
-			Role:  roleStr,
+			ID:      row.ID,
+			Profile: profiles.Summary{ID: row.ID, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl},
+			Role:    roleStr,
 		})
 	}
 
-	return members, nil
+	teamRows, err := r.queries.ListProjectTeamMembers(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	teams := make([]projects.TeamMembership, 0, len(teamRows))
+	for _, row := range teamRows {
+		teams = append(teams, projects.TeamMembership{
+			TeamID:   row.TeamID,
+			TeamName: row.TeamName,
+			Role:     row.Role,
+			UserID:   row.UserID,
+			Profile:  profiles.Summary{ID: row.UserID, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl},
+		})
+	}
+
+	return projects.WithTeams(members, teams), nil
+}
+
+func (r *ProjectRepository) SetArchived(ctx context.Context, id int64, archived bool) error {
+	n, err := r.queries.SetProjectArchived(ctx, sqlc.SetProjectArchivedParams{
+		Archived: archived,
+		ID:       id,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return projects.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *ProjectRepository) SoftDeleteProject(ctx context.Context, id int64) error {
+	n, err := r.queries.SoftDeleteProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return projects.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *ProjectRepository) ListDeleted(ctx context.Context, ownerID int64, deletedAfter time.Time) ([]projects.Project, error) {
+	rows, err := r.queries.ListDeletedProjects(ctx, sqlc.ListDeletedProjectsParams{
+		OwnerID:      ownerID,
+		DeletedAfter: pgtype.Timestamptz{Time: deletedAfter, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []projects.Project
+	for _, row := range rows {
+		list = append(list, projects.Project{
+			ID:             row.ID,
+			Name:           row.Name,
+			Description:    row.Description.String,
+			OwnerID:        row.OwnerID,
+			OrganizationID: row.OrganizationID,
+			CreatedAt:      row.CreatedAt.Time,
+			ArchivedAt:     nullableTime(row.ArchivedAt),
+			DeletedAt:      nullableTime(row.DeletedAt),
+		})
+	}
+	return list, nil
 }
 
-func (r *ProjectRepository) DeleteProject(ctx context.Context, id int64, ownerID int64) error {
-	return r.queries.DeleteProject(ctx, sqlc.DeleteProjectParams{
-		ID:      id,
-		OwnerID: ownerID,
+func (r *ProjectRepository) RestoreProject(ctx context.Context, id, ownerID int64, deletedAfter time.Time) (bool, error) {
+	n, err := r.queries.RestoreProject(ctx, sqlc.RestoreProjectParams{
+		ID:           id,
+		OwnerID:      ownerID,
+		DeletedAfter: pgtype.Timestamptz{Time: deletedAfter, Valid: true},
 	})
+	return n > 0, err
+}
+
+func (r *ProjectRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return r.queries.PurgeDeletedProjects(ctx, pgtype.Timestamptz{Time: deletedBefore, Valid: true})
 }
 
 func (r *ProjectRepository) AddUserToProject(ctx context.Context, projectID int64, userID int64, role string) error {
-	// Uses the :one query you defined
-	_, err := r.queries.AddUserToProject(ctx, sqlc.AddUserToProjectParams{
+	return addUserToProject(ctx, r.queries, projectID, userID, role)
+}
+
+// AddUserToProjectTx is AddUserToProject inside the caller's transaction, for
+// memberships that must be written together with the invitation, link or join
+// request granting them
+func (r *ProjectRepository) AddUserToProjectTx(ctx context.Context, tx pgx.Tx, projectID int64, userID int64, role string) error {
+	return addUserToProject(ctx, r.queries.WithTx(tx), projectID, userID, role)
+}
+
+func addUserToProject(ctx context.Context, q *sqlc.Queries, projectID int64, userID int64, role string) error {
+	_, err := q.AddUserToProject(ctx, sqlc.AddUserToProjectParams{
 		ProjectID: projectID,
 		UserID:    userID,
 		Role:      pgtype.Text{String: role, Valid: true},
@@ -148,6 +316,47 @@ func (r *ProjectRepository) RemoveUserFromProject(ctx context.Context, projectID
 	})
 }
 
+// TransferOwnership moves the project and both member roles together, the
+// previous owner stays on as a maintainer
+func (r *ProjectRepository) TransferOwnership(ctx context.Context, projectID, currentOwnerID, newOwnerID int64) error {
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		q := r.queries.WithTx(tx)
+
+		moved, err := q.TransferProjectOwner(ctx, sqlc.TransferProjectOwnerParams{
+			NewOwnerID:     newOwnerID,
+			ID:             projectID,
+			CurrentOwnerID: currentOwnerID,
+		})
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			return projects.ErrOwnerChanged
+		}
+
+		if err := q.SetProjectMemberRole(ctx, sqlc.SetProjectMemberRoleParams{
+			ProjectID: projectID,
+			UserID:    newOwnerID,
+			Role:      pgtype.Text{String: projects.RoleOwner, Valid: true},
+		}); err != nil {
+			return err
+		}
+		return q.SetProjectMemberRole(ctx, sqlc.SetProjectMemberRoleParams{
+			ProjectID: projectID,
+			UserID:    currentOwnerID,
+			Role:      pgtype.Text{String: projects.RoleMaintainer, Valid: true},
+		})
+	})
+}
+
+func (r *ProjectRepository) UpdateMemberRole(ctx context.Context, projectID int64, userID int64, role string) error {
+	return r.queries.SetProjectMemberRole(ctx, sqlc.SetProjectMemberRoleParams{
+		ProjectID: projectID,
+		UserID:    userID,
+		Role:      pgtype.Text{String: role, Valid: true},
+	})
+}
+
 func (r *ProjectRepository) UsersShareProject(ctx context.Context, userA, userB int64) (bool, error) {
 	shared, err := r.queries.CheckSharedProject(ctx, sqlc.CheckSharedProjectParams{
 		SenderID:   userA,
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type ProjectTemplateRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewProjectTemplateRepository(db *DBAdapter) *ProjectTemplateRepository {
+	return &ProjectTemplateRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *ProjectTemplateRepository) CreateTemplate(ctx context.Context, t projects.Template) (*projects.Template, error) {
+	tasks, err := json.Marshal(emptyIfNil(t.Tasks))
+	if err != nil {
+		return nil, err
+	}
+	members, err := json.Marshal(emptyIfNil(t.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.queries.CreateProjectTemplate(ctx, sqlc.CreateProjectTemplateParams{
+		OwnerID:     t.OwnerID,
+		Name:        t.Name,
+		Description: pgtype.Text{String: t.Description, Valid: t.Description != ""},
+		Tasks:       tasks,
+		Members:     members,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templateFromRow(row)
+}
+
+func (r *ProjectTemplateRepository) GetTemplate(ctx context.Context, id, ownerID int64) (*projects.Template, error) {
+	row, err := r.queries.GetProjectTemplate(ctx, sqlc.GetProjectTemplateParams{ID: id, OwnerID: ownerID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, projects.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return templateFromRow(row)
+}
+
+func (r *ProjectTemplateRepository) ListTemplates(ctx context.Context, ownerID int64) ([]projects.Template, error) {
+	rows, err := r.queries.ListProjectTemplates(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []projects.Template{}
+	for _, row := range rows {
+		t, err := templateFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, nil
+}
+
+func (r *ProjectTemplateRepository) DeleteTemplate(ctx context.Context, id, ownerID int64) error {
+	n, err := r.queries.DeleteProjectTemplate(ctx, sqlc.DeleteProjectTemplateParams{ID: id, OwnerID: ownerID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return projects.ErrTemplateNotFound
+	}
+	return nil
+}
+
+func templateFromRow(row sqlc.ProjectTemplate) (*projects.Template, error) {
+	t := &projects.Template{
+		ID:          row.ID,
+		OwnerID:     row.OwnerID,
+		Name:        row.Name,
+		Description: row.Description.String,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+	if err := json.Unmarshal(row.Tasks, &t.Tasks); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(row.Members, &t.Members); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// emptyIfNil stores an empty list as [] rather than null
+func emptyIfNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
@@ -0,0 +1,13 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (actor_id, action, target_type, target_id, details)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+WHERE (sqlc.narg('actor_id')::bigint IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('target_type')::text IS NULL OR target_type = sqlc.narg('target_type'))
+  AND (sqlc.narg('target_id')::bigint IS NULL OR target_id = sqlc.narg('target_id'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('lim');
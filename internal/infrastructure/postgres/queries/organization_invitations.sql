@@ -0,0 +1,22 @@
+-- name: UpsertOrganizationInvitation :one
+-- inviting an address again renews its open invitation
+INSERT INTO organization_invitations (organization_id, email, role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (organization_id, lower(email)) WHERE accepted_at IS NULL
+DO UPDATE SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by, expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: GetOrganizationInvitation :one
+SELECT * FROM organization_invitations
+WHERE id = $1;
+
+-- name: AcceptOrganizationInvitation :execrows
+UPDATE organization_invitations
+SET accepted_at = NOW(), accepted_by = $2
+WHERE id = $1 AND accepted_at IS NULL AND expires_at > NOW();
+
+-- name: DeleteOrganizationInvitationsForUser :exec
+-- the invited address is personal data too, used by account deletion
+DELETE FROM organization_invitations
+WHERE accepted_by = sqlc.arg(user_id)::bigint
+   OR lower(email) = (SELECT lower(email) FROM users WHERE id = sqlc.arg(user_id)::bigint);
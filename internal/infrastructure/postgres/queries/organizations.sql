@@ -0,0 +1,76 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (name, created_by)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOrganization :one
+SELECT * FROM organizations
+WHERE id = $1;
+
+-- name: ListOrganizationsForUser :many
+SELECT o.id, o.name, o.created_by, o.created_at, m.role
+FROM organizations o
+JOIN organization_members m ON m.organization_id = o.id
+WHERE m.user_id = $1
+ORDER BY o.name ASC, o.id ASC;
+
+-- name: AddOrganizationMember :exec
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: GetOrganizationMemberRole :one
+SELECT role FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT m.user_id, u.email, m.role, m.created_at
+FROM organization_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.organization_id = $1
+ORDER BY u.email ASC;
+
+-- name: SetOrganizationMemberRole :execrows
+UPDATE organization_members
+SET role = $3
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: ListUserOrganizationProjectIDs :many
+-- the organization's projects the user reaches, directly or through a team
+SELECT p.id FROM projects p
+WHERE p.organization_id = $1
+  AND (EXISTS (SELECT 1 FROM project_users pu WHERE pu.project_id = p.id AND pu.user_id = $2)
+    OR EXISTS (SELECT 1 FROM project_teams pt
+               JOIN team_members tm ON tm.team_id = pt.team_id
+               WHERE pt.project_id = p.id AND tm.user_id = $2));
+
+-- name: RemoveUserFromOrganizationProjects :exec
+-- someone who leaves the organization leaves its projects too
+DELETE FROM project_users pu
+USING projects p
+WHERE p.id = pu.project_id AND p.organization_id = $1 AND pu.user_id = $2;
+
+-- name: RemoveUserFromOrganizationTeams :exec
+DELETE FROM team_members tm
+USING teams t
+WHERE t.id = tm.team_id AND t.organization_id = $1 AND tm.user_id = $2;
+
+-- name: CountOrganizationAdmins :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = $1 AND role = 'admin';
+
+-- name: CountOrganizationProjectsOwnedBy :one
+SELECT COUNT(*) FROM projects
+WHERE organization_id = $1 AND owner_id = $2;
+
+-- name: CheckSharedOrganization :one
+SELECT EXISTS (
+    SELECT 1
+    FROM organization_members m1
+    JOIN organization_members m2 ON m1.organization_id = m2.organization_id
+    WHERE m1.user_id = sqlc.arg('viewer_id') AND m2.user_id = sqlc.arg('user_id')
+) AS shared;
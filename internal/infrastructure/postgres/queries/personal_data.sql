@@ -0,0 +1,104 @@
+-- name: ListProjectsOwnedByUser :many
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id
+FROM projects
+WHERE owner_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListTasksAssignedToUser :many
+SELECT * FROM tasks
+WHERE assigned_to = sqlc.arg(user_id)::bigint
+ORDER BY created_at ASC;
+
+-- name: ListTaskActivitiesByUser :many
+SELECT * FROM task_activities
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListMessagesForUser :many
+-- project messages the user wrote plus their direct messages both ways
+SELECT * FROM messages
+WHERE sender_id = $1 OR receiver_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListOwnedProjectsWithSuccessor :many
+-- the successor is the member who takes over a shared project: direct
+-- members first, maintainers before the rest, then whoever joined earliest.
+-- Without any, the people a team brings in, in the same order.
+-- 0 means nobody else is in it.
+SELECT
+    p.id,
+    COALESCE((
+        SELECT pu.user_id FROM project_users pu
+        WHERE pu.project_id = p.id AND pu.user_id <> p.owner_id
+        ORDER BY (pu.role = 'maintainer') DESC, pu.id ASC
+        LIMIT 1
+    ), (
+        SELECT tm.user_id FROM project_teams pt
+        JOIN team_members tm ON tm.team_id = pt.team_id
+        WHERE pt.project_id = p.id AND tm.user_id <> p.owner_id
+        ORDER BY (pt.role = 'maintainer') DESC, pt.created_at ASC, tm.created_at ASC
+        LIMIT 1
+    ), 0)::bigint AS successor_id
+FROM projects p
+WHERE p.owner_id = $1;
+
+-- name: SetProjectOwner :exec
+UPDATE projects
+SET owner_id = $2
+WHERE id = $1;
+
+-- name: SetProjectMemberRole :exec
+UPDATE project_users
+SET role = $3
+WHERE project_id = $1 AND user_id = $2;
+
+-- name: UpsertProjectOwnerMember :exec
+-- a successor who came in through a team gets a direct membership
+INSERT INTO project_users (project_id, user_id, role)
+VALUES ($1, $2, 'owner')
+ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role;
+
+-- name: RemoveUserFromAllProjects :exec
+DELETE FROM project_users
+WHERE user_id = $1;
+
+-- name: UnassignUserTasks :exec
+UPDATE tasks
+SET assigned_to = NULL,
+    updated_at = NOW()
+WHERE assigned_to = sqlc.arg(user_id)::bigint;
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities
+WHERE user_id = $1;
+
+-- name: DeletePersonalAccessTokensForUser :exec
+DELETE FROM personal_access_tokens
+WHERE user_id = $1;
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions
+WHERE user_id = $1;
+
+-- name: DeleteProjectTemplatesForUser :exec
+DELETE FROM project_templates
+WHERE owner_id = $1;
+
+-- name: DeleteOrganizationMembershipsForUser :exec
+DELETE FROM organization_members
+WHERE user_id = $1;
+
+-- name: DeleteTeamMembershipsForUser :exec
+DELETE FROM team_members
+WHERE user_id = $1;
+
+-- name: DeleteJoinRequestsForUser :exec
+DELETE FROM project_join_requests
+WHERE user_id = $1;
+
+-- name: AnonymizeUser :exec
+UPDATE users
+SET email = $2,
+    password_hash = $3,
+    status = $4
+WHERE id = $1;
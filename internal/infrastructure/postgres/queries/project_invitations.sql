@@ -0,0 +1,46 @@
+-- name: CreateInvitation :one
+INSERT INTO project_invitations (project_id, email, role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetInvitation :one
+SELECT * FROM project_invitations
+WHERE id = $1;
+
+-- name: GetPendingInvitation :one
+SELECT * FROM project_invitations
+WHERE project_id = $1 AND lower(email) = lower(sqlc.arg(email))
+  AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: ListPendingInvitations :many
+SELECT * FROM project_invitations
+WHERE project_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+ORDER BY created_at ASC;
+
+-- name: ListPendingInvitationsForEmail :many
+SELECT * FROM project_invitations
+WHERE lower(email) = lower(sqlc.arg(email))
+  AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY created_at ASC;
+
+-- name: RenewInvitation :one
+UPDATE project_invitations
+SET sent_at = NOW(), expires_at = $3
+WHERE id = $1 AND project_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeInvitation :execrows
+UPDATE project_invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND project_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: AcceptInvitation :execrows
+UPDATE project_invitations
+SET accepted_at = NOW(), accepted_by = $2
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW();
+
+-- name: DeleteInvitationsForUser :exec
+-- the invited address is personal data too, used by account deletion
+DELETE FROM project_invitations
+WHERE accepted_by = sqlc.arg(user_id)::bigint
+   OR lower(email) = (SELECT lower(email) FROM users WHERE id = sqlc.arg(user_id)::bigint);
@@ -0,0 +1,28 @@
+-- name: CreateInviteLink :one
+INSERT INTO project_invite_links (project_id, code_hash, code_prefix, role, created_by, expires_at, max_uses)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetInviteLinkByHash :one
+SELECT * FROM project_invite_links
+WHERE code_hash = $1;
+
+-- name: ListInviteLinks :many
+SELECT * FROM project_invite_links
+WHERE project_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeInviteLink :execrows
+UPDATE project_invite_links
+SET revoked_at = NOW()
+WHERE id = $1 AND project_id = $2 AND revoked_at IS NULL;
+
+-- name: UseInviteLink :one
+-- counts a use only while the link is still good, concurrent joins can't overshoot max_uses
+UPDATE project_invite_links
+SET use_count = use_count + 1
+WHERE id = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (max_uses IS NULL OR use_count < max_uses)
+RETURNING *;
@@ -0,0 +1,30 @@
+-- name: CreateJoinRequest :one
+INSERT INTO project_join_requests (project_id, user_id, message)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListPendingJoinRequests :many
+SELECT
+    jr.id,
+    jr.project_id,
+    jr.user_id,
+    jr.message,
+    jr.created_at,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url
+FROM project_join_requests jr
+JOIN users u ON u.id = jr.user_id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE jr.project_id = $1 AND jr.status = 'pending'
+ORDER BY jr.created_at ASC;
+
+-- name: GetPendingJoinRequest :one
+SELECT * FROM project_join_requests
+WHERE id = $1 AND project_id = $2 AND status = 'pending';
+
+-- name: DecideJoinRequest :one
+-- only an open request can be decided, so two managers can't both answer it
+UPDATE project_join_requests
+SET status = $3, decided_by = $4, decided_at = NOW()
+WHERE id = $1 AND project_id = $2 AND status = 'pending'
+RETURNING *;
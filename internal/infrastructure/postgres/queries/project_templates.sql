@@ -0,0 +1,17 @@
+-- name: CreateProjectTemplate :one
+INSERT INTO project_templates (owner_id, name, description, tasks, members)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetProjectTemplate :one
+SELECT * FROM project_templates
+WHERE id = $1 AND owner_id = $2;
+
+-- name: ListProjectTemplates :many
+SELECT * FROM project_templates
+WHERE owner_id = $1
+ORDER BY name ASC, id ASC;
+
+-- name: DeleteProjectTemplate :execrows
+DELETE FROM project_templates
+WHERE id = $1 AND owner_id = $2;
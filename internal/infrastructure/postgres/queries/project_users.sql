@@ -10,7 +10,8 @@ WHERE project_id = $1 AND user_id = $2;
 -- name: ListUsersInProject :many
 SELECT 
     u.id, 
-    u.email, 
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url,
     CASE 
         WHEN p.owner_id = u.id THEN 'owner'::text
         ELSE pu.role
@@ -18,14 +19,38 @@ SELECT
 FROM users u
 JOIN project_users pu ON u.id = pu.user_id
 JOIN projects p ON pu.project_id = p.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE pu.project_id = $1;
 
+-- name: ListProjectTeamMembers :many
+-- the people the project's teams bring in, merged with the direct members in Go
+SELECT
+    pt.team_id,
+    t.name AS team_name,
+    pt.role,
+    u.id AS user_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url
+FROM project_teams pt
+JOIN teams t ON t.id = pt.team_id
+JOIN team_members tm ON tm.team_id = pt.team_id
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE pt.project_id = $1
+ORDER BY t.name ASC, u.id ASC;
+
 -- name: CheckSharedProject :one
+WITH memberships AS (
+    SELECT project_id, user_id FROM project_users
+    UNION
+    SELECT pt.project_id, tm.user_id
+    FROM project_teams pt
+    JOIN team_members tm ON tm.team_id = pt.team_id
+)
 SELECT EXISTS (
     SELECT 1 
-    FROM project_users pu1
-    JOIN project_users pu2 ON pu1.project_id = pu2.project_id
-    WHERE pu1.user_id = sqlc.arg('sender_id') AND pu2.user_id = sqlc.arg('receiver_id')
+    FROM memberships m1
+    JOIN memberships m2 ON m1.project_id = m2.project_id
+    WHERE m1.user_id = sqlc.arg('sender_id') AND m2.user_id = sqlc.arg('receiver_id')
 ) AS shared;
 
-
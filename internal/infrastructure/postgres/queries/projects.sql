@@ -1,13 +1,14 @@
 -- name: GetProjectByID :one
-SELECT id, name, description, owner_id, created_at
+-- soft deleted projects are gone as far as the app is concerned
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id
 FROM projects
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: CreateProject :one
 WITH inserted AS (
-    INSERT INTO projects (name, description, owner_id)
-    VALUES ($1, $2, $3)
-    RETURNING id, name, description, owner_id, created_at
+    INSERT INTO projects (name, description, owner_id, organization_id)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id, name, description, owner_id, created_at, organization_id
 )
 SELECT 
     i.id, 
@@ -15,9 +16,12 @@ SELECT
     i.description, 
     i.owner_id, 
     i.created_at,
-    u.email AS owner_name
+    i.organization_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS owner_display_name,
+    COALESCE(pr.avatar_url, '')::text AS owner_avatar_url
 FROM inserted i
-JOIN users u ON i.owner_id = u.id;
+JOIN users u ON i.owner_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id;
 
 -- name: UpdateProject :exec
 UPDATE projects
@@ -36,14 +40,57 @@ SELECT
     p.description, 
     p.owner_id, 
     p.created_at,
-    u.email AS owner_name
+    p.archived_at,
+    p.organization_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS owner_display_name,
+    COALESCE(pr.avatar_url, '')::text AS owner_avatar_url
 FROM projects p
 LEFT JOIN users u ON p.owner_id = u.id
-WHERE p.owner_id = $1 
-   OR p.id IN (SELECT project_id FROM project_users WHERE user_id = $1)
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE (p.owner_id = sqlc.arg(owner_id)
+   OR p.id IN (SELECT project_id FROM project_users WHERE user_id = sqlc.arg(owner_id))
+   OR p.id IN (SELECT pt.project_id FROM project_teams pt
+               JOIN team_members tm ON tm.team_id = pt.team_id
+               WHERE tm.user_id = sqlc.arg(owner_id)))
+  AND p.deleted_at IS NULL
+  AND (sqlc.arg(include_archived)::boolean OR p.archived_at IS NULL)
 ORDER BY p.created_at ASC;
 
 -- name: GetProject :one
 SELECT * FROM projects
 WHERE id = $1 LIMIT 1;
 
+-- name: SetProjectArchived :execrows
+-- archived_at keeps the first archive time, unarchiving clears it
+UPDATE projects
+SET archived_at = CASE WHEN sqlc.arg(archived)::boolean THEN COALESCE(archived_at, NOW()) END
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: SoftDeleteProject :execrows
+UPDATE projects
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListDeletedProjects :many
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id
+FROM projects
+WHERE owner_id = sqlc.arg(owner_id) AND deleted_at > sqlc.arg(deleted_after)
+ORDER BY deleted_at DESC;
+
+-- name: RestoreProject :execrows
+-- only within the retention period, after that the purge job may already have run
+UPDATE projects
+SET deleted_at = NULL
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at > sqlc.arg(deleted_after);
+
+-- name: PurgeDeletedProjects :execrows
+-- tasks, task history, chat and members go with the project (ON DELETE CASCADE)
+DELETE FROM projects
+WHERE deleted_at IS NOT NULL AND deleted_at <= $1;
+
+-- name: TransferProjectOwner :execrows
+-- only moves the project if it still belongs to current_owner_id, so two
+-- transfers racing each other can't both win
+UPDATE projects
+SET owner_id = sqlc.arg(new_owner_id)
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(current_owner_id);
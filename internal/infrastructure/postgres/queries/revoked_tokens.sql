@@ -0,0 +1,25 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: ListActiveRevokedTokens :many
+SELECT jti, expires_at
+FROM revoked_tokens
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE expires_at <= NOW();
+
+-- name: UpsertUserTokenCutoff :exec
+INSERT INTO user_token_cutoffs (user_id, not_before, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET not_before = EXCLUDED.not_before,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: ListActiveUserTokenCutoffs :many
+SELECT user_id, not_before
+FROM user_token_cutoffs
+WHERE expires_at > NOW();
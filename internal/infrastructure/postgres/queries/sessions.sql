@@ -0,0 +1,30 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, family_id, user_agent, ip_address)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1;
+
+-- name: TouchSession :one
+UPDATE sessions
+SET last_seen_at = NOW(),
+    -- clients that don't say who they are keep what we knew
+    user_agent = COALESCE(NULLIF(sqlc.arg(user_agent)::text, ''), user_agent),
+    ip_address = COALESCE(NULLIF(sqlc.arg(ip_address)::text, ''), ip_address)
+WHERE family_id = sqlc.arg(family_id)
+RETURNING *;
+
+-- name: ListActiveSessions :many
+-- a session is alive as long as its family still has a usable refresh token
+SELECT s.* FROM sessions s
+WHERE s.user_id = $1
+  AND EXISTS (
+    SELECT 1 FROM refresh_tokens rt
+    WHERE rt.family_id = s.family_id
+      AND rt.used_at IS NULL
+      AND rt.revoked_at IS NULL
+      AND rt.expires_at > NOW()
+  )
+ORDER BY s.last_seen_at DESC;
@@ -0,0 +1,12 @@
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, private_key, public_key, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListActiveSigningKeys :many
+SELECT * FROM signing_keys
+WHERE expires_at > NOW()
+ORDER BY created_at DESC;
+
+-- name: DeleteExpiredSigningKeys :exec
+DELETE FROM signing_keys
+WHERE expires_at <= NOW();
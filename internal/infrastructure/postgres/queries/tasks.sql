@@ -38,11 +38,13 @@ SELECT
     ta.id, 
     ta.task_id, 
     ta.user_id, 
-    u.email as user_email, -- Add this
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS user_display_name,
+    COALESCE(pr.avatar_url, '')::text AS user_avatar_url,
     ta.action, 
     ta.details, 
     ta.created_at
 FROM task_activities ta
-JOIN users u ON ta.user_id = u.id -- Add this join
+JOIN users u ON ta.user_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE ta.task_id = $1
 ORDER BY ta.created_at DESC;
\ No newline at end of file
@@ -0,0 +1,58 @@
+-- name: CreateTeam :one
+INSERT INTO teams (organization_id, name, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTeam :one
+SELECT * FROM teams
+WHERE id = $1;
+
+-- name: ListTeamsInOrganization :many
+SELECT * FROM teams
+WHERE organization_id = $1
+ORDER BY name ASC;
+
+-- name: DeleteTeam :exec
+DELETE FROM teams
+WHERE id = $1;
+
+-- name: AddTeamMember :execrows
+INSERT INTO team_members (team_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (team_id, user_id) DO NOTHING;
+
+-- name: RemoveTeamMember :execrows
+DELETE FROM team_members
+WHERE team_id = $1 AND user_id = $2;
+
+-- name: ListTeamMembers :many
+SELECT
+    u.id AS user_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url,
+    tm.created_at
+FROM team_members tm
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE tm.team_id = $1
+ORDER BY tm.created_at ASC;
+
+-- name: ListTeamProjectIDs :many
+SELECT project_id FROM project_teams
+WHERE team_id = $1;
+
+-- name: AddTeamToProject :execrows
+INSERT INTO project_teams (project_id, team_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, team_id) DO NOTHING;
+
+-- name: RemoveTeamFromProject :execrows
+DELETE FROM project_teams
+WHERE project_id = $1 AND team_id = $2;
+
+-- name: ListProjectTeams :many
+SELECT pt.team_id, t.name, pt.role, pt.created_at
+FROM project_teams pt
+JOIN teams t ON t.id = pt.team_id
+WHERE pt.project_id = $1
+ORDER BY t.name ASC;
@@ -0,0 +1,51 @@
+-- name: UpsertTOTPSecret :one
+INSERT INTO user_totp_secrets (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, enabled_at = NULL, last_used_step = 0, created_at = NOW()
+RETURNING *;
+
+-- name: GetTOTPSecret :one
+SELECT * FROM user_totp_secrets
+WHERE user_id = $1;
+
+-- name: EnableTOTPSecret :exec
+UPDATE user_totp_secrets
+SET enabled_at = NOW()
+WHERE user_id = $1;
+
+-- name: DeleteTOTPSecret :exec
+DELETE FROM user_totp_secrets
+WHERE user_id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp_secrets
+SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: IsTwoFactorRequired :one
+SELECT required FROM two_factor_policies
+WHERE role = $1;
+
+-- name: ListTwoFactorPolicies :many
+SELECT * FROM two_factor_policies
+ORDER BY role;
+
+-- name: SetTwoFactorPolicy :exec
+INSERT INTO two_factor_policies (role, required)
+VALUES ($1, $2)
+ON CONFLICT (role) DO UPDATE
+SET required = EXCLUDED.required, updated_at = NOW();
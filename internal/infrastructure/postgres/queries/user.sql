@@ -8,6 +8,41 @@ SELECT id, email, password_hash, role, status, created_at
 FROM users
 WHERE email = $1;
 
--- name: ListUsers :many
-SELECT id, email FROM users 
-ORDER BY email ASC;
+-- name: GetUserByID :one
+SELECT id, email, password_hash, role, status, created_at
+FROM users
+WHERE id = $1;
+
+-- name: ListUsersVisibleTo :many
+-- the people in any of the viewer's organizations
+SELECT DISTINCT u.id, u.email
+FROM users u
+JOIN organization_members m ON m.user_id = u.id
+WHERE m.organization_id IN (
+    SELECT organization_id FROM organization_members WHERE user_id = $1
+)
+ORDER BY u.email ASC;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2
+WHERE id = $1;
+
+-- name: UpdateUserStatus :exec
+UPDATE users
+SET status = $2
+WHERE id = $1;
+
+-- name: SearchUsers :many
+SELECT id, email, password_hash, role, status, created_at
+FROM users
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role'))
+  AND (sqlc.narg('email')::text IS NULL OR email ILIKE '%' || sqlc.narg('email') || '%')
+ORDER BY id ASC
+LIMIT sqlc.arg('lim') OFFSET sqlc.arg('off');
+
+-- name: UpdateUserRole :exec
+UPDATE users
+SET role = $2
+WHERE id = $1;
@@ -0,0 +1,38 @@
+-- name: GetUserProfile :one
+SELECT
+    u.id AS user_id,
+    COALESCE(p.display_name, '')::text AS display_name,
+    COALESCE(p.avatar_url, '')::text AS avatar_url,
+    COALESCE(p.timezone, 'UTC')::text AS timezone,
+    COALESCE(p.locale, 'en')::text AS locale,
+    COALESCE(p.bio, '')::text AS bio,
+    COALESCE(p.updated_at, u.created_at)::timestamptz AS updated_at
+FROM users u
+LEFT JOIN user_profiles p ON p.user_id = u.id
+WHERE u.id = $1;
+
+-- name: UpsertUserProfile :one
+INSERT INTO user_profiles (user_id, display_name, avatar_url, timezone, locale, bio)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE
+SET display_name = EXCLUDED.display_name,
+    avatar_url = EXCLUDED.avatar_url,
+    timezone = EXCLUDED.timezone,
+    locale = EXCLUDED.locale,
+    bio = EXCLUDED.bio,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: UpsertUserAvatar :exec
+INSERT INTO user_avatars (user_id, content_type, data)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET content_type = EXCLUDED.content_type, data = EXCLUDED.data, updated_at = NOW();
+
+-- name: GetUserAvatar :one
+SELECT * FROM user_avatars
+WHERE user_id = $1;
+
+-- name: DeleteUserAvatar :exec
+DELETE FROM user_avatars
+WHERE user_id = $1;
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type RefreshTokenRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewRefreshTokenRepository(db *DBAdapter) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, t tokens.RefreshToken) (*tokens.RefreshToken, error) {
+	res, err := r.queries.CreateRefreshToken(ctx, sqlc.CreateRefreshTokenParams{
+		UserID:    t.UserID,
+		FamilyID:  t.FamilyID,
+		TokenHash: t.TokenHash,
+		ExpiresAt: pgtype.Timestamptz{Time: t.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCRefreshTokenToDomain(res), nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*tokens.RefreshToken, error) {
+	res, err := r.queries.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCRefreshTokenToDomain(res), nil
+}
+
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	affected, err := r.queries.MarkRefreshTokenUsed(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.queries.RevokeRefreshTokenFamily(ctx, familyID)
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return r.queries.RevokeRefreshTokensForUser(ctx, userID)
+}
+
+func (r *RefreshTokenRepository) CreateSession(ctx context.Context, s tokens.Session) (*tokens.Session, error) {
+	res, err := r.queries.CreateSession(ctx, sqlc.CreateSessionParams{
+		UserID:    s.UserID,
+		FamilyID:  s.FamilyID,
+		UserAgent: s.UserAgent,
+		IpAddress: s.IPAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCSessionToDomain(res), nil
+}
+
+func (r *RefreshTokenRepository) GetSession(ctx context.Context, id int64) (*tokens.Session, error) {
+	res, err := r.queries.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCSessionToDomain(res), nil
+}
+
+func (r *RefreshTokenRepository) TouchSession(ctx context.Context, familyID string, device tokens.Device) (*tokens.Session, error) {
+	res, err := r.queries.TouchSession(ctx, sqlc.TouchSessionParams{
+		UserAgent: device.UserAgent,
+		IpAddress: device.IPAddress,
+		FamilyID:  familyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCSessionToDomain(res), nil
+}
+
+func (r *RefreshTokenRepository) ListActiveSessions(ctx context.Context, userID int64) ([]tokens.Session, error) {
+	rows, err := r.queries.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]tokens.Session, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *mapSQLCSessionToDomain(row))
+	}
+	return list, nil
+}
+
+func mapSQLCRefreshTokenToDomain(row sqlc.RefreshToken) *tokens.RefreshToken {
+	return &tokens.RefreshToken{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		FamilyID:  row.FamilyID,
+		TokenHash: row.TokenHash,
+		ExpiresAt: row.ExpiresAt.Time,
+		UsedAt:    nullableTime(row.UsedAt),
+		RevokedAt: nullableTime(row.RevokedAt),
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
+
+func mapSQLCSessionToDomain(row sqlc.Session) *tokens.Session {
+	return &tokens.Session{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		FamilyID:   row.FamilyID,
+		UserAgent:  row.UserAgent,
+		IPAddress:  row.IpAddress,
+		CreatedAt:  row.CreatedAt.Time,
+		LastSeenAt: row.LastSeenAt.Time,
+	}
+}
+
+// Helper: NULL timestamps become nil pointers in the domain
+func nullableTime(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
@@ -26,6 +26,7 @@ func SeedAdminUser(ctx context.Context, userRepo user.Repository) error {
 		Email:        adminEmail,
 		PasswordHash: hash,
 		Role:         "admin",
+		Status:       user.StatusActive, // seeded, nobody to verify it
 	}
 
 	_, err = userRepo.Create(ctx, admin)
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+// SigningKeyRepository is the Postgres backing store for auth.KeySet
+type SigningKeyRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewSigningKeyRepository(db *DBAdapter) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+// Create stores the 32 byte seed, the full private key is derived from it on load
+func (r *SigningKeyRepository) Create(ctx context.Context, key auth.SigningKey) error {
+	return r.queries.CreateSigningKey(ctx, sqlc.CreateSigningKeyParams{
+		Kid:        key.ID,
+		Algorithm:  "EdDSA",
+		PrivateKey: key.PrivateKey.Seed(),
+		PublicKey:  key.PublicKey,
+		CreatedAt:  pgtype.Timestamptz{Time: key.CreatedAt, Valid: true},
+		ExpiresAt:  pgtype.Timestamptz{Time: key.ExpiresAt, Valid: true},
+	})
+}
+
+func (r *SigningKeyRepository) ListActive(ctx context.Context) ([]auth.SigningKey, error) {
+	rows, err := r.queries.ListActiveSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]auth.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		if len(row.PrivateKey) != ed25519.SeedSize {
+			return nil, fmt.Errorf("signing key %s has a malformed seed", row.Kid)
+		}
+		keys = append(keys, auth.SigningKey{
+			ID:         row.Kid,
+			PrivateKey: ed25519.NewKeyFromSeed(row.PrivateKey),
+			PublicKey:  ed25519.PublicKey(row.PublicKey),
+			CreatedAt:  row.CreatedAt.Time,
+			ExpiresAt:  row.ExpiresAt.Time,
+		})
+	}
+	return keys, nil
+}
+
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context) error {
+	return r.queries.DeleteExpiredSigningKeys(ctx)
+}
@@ -17,9 +17,12 @@ WITH inserted AS (
     VALUES ($1, $2, $3, $4)
     RETURNING id, sender_id, content, project_id, receiver_id, created_at
 )
-SELECT i.id, i.sender_id, i.content, i.project_id, i.receiver_id, i.created_at, u.email as sender_email
+SELECT i.id, i.sender_id, i.content, i.project_id, i.receiver_id, i.created_at,
+       COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS sender_display_name,
+       COALESCE(pr.avatar_url, '')::text AS sender_avatar_url
 FROM inserted i
 JOIN users u ON i.sender_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 `
 
 type CreateMessageParams struct {
@@ -30,13 +33,14 @@ type CreateMessageParams struct {
 }
 
 type CreateMessageRow struct {
-	ID          int64
-	SenderID    int64
-	Content     string
-	ProjectID   pgtype.Int8
-	ReceiverID  pgtype.Int8
-	CreatedAt   pgtype.Timestamptz
-	SenderEmail string
+	ID                int64
+	SenderID          int64
+	Content           string
+	ProjectID         pgtype.Int8
+	ReceiverID        pgtype.Int8
+	CreatedAt         pgtype.Timestamptz
+	SenderDisplayName string
+	SenderAvatarUrl   string
 }
 
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (CreateMessageRow, error) {
@@ -54,15 +58,19 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (C
 		&i.ProjectID,
 		&i.ReceiverID,
 		&i.CreatedAt,
-		&i.SenderEmail,
+		&i.SenderDisplayName,
+		&i.SenderAvatarUrl,
 	)
 	return i, err
 }
 
 const getDirectMessages = `-- name: GetDirectMessages :many
-SELECT m.id, m.sender_id, m.content, m.receiver_id, m.created_at, u.email as sender_email
+SELECT m.id, m.sender_id, m.content, m.receiver_id, m.created_at,
+       COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS sender_display_name,
+       COALESCE(pr.avatar_url, '')::text AS sender_avatar_url
 FROM messages m
 JOIN users u ON m.sender_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE (m.sender_id = $1 AND m.receiver_id = $2)
    OR (m.sender_id = $2 AND m.receiver_id = $1)
 ORDER BY m.created_at ASC
@@ -74,12 +82,13 @@ type GetDirectMessagesParams struct {
 }
 
 type GetDirectMessagesRow struct {
-	ID          int64
-	SenderID    int64
-	Content     string
-	ReceiverID  pgtype.Int8
-	CreatedAt   pgtype.Timestamptz
-	SenderEmail string
+	ID                int64
+	SenderID          int64
+	Content           string
+	ReceiverID        pgtype.Int8
+	CreatedAt         pgtype.Timestamptz
+	SenderDisplayName string
+	SenderAvatarUrl   string
 }
 
 func (q *Queries) GetDirectMessages(ctx context.Context, arg GetDirectMessagesParams) ([]GetDirectMessagesRow, error) {
@@ -97,7 +106,8 @@ func (q *Queries) GetDirectMessages(ctx context.Context, arg GetDirectMessagesPa
 			&i.Content,
 			&i.ReceiverID,
 			&i.CreatedAt,
-			&i.SenderEmail,
+			&i.SenderDisplayName,
+			&i.SenderAvatarUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -110,20 +120,24 @@ func (q *Queries) GetDirectMessages(ctx context.Context, arg GetDirectMessagesPa
 }
 
 const getProjectMessages = `-- name: GetProjectMessages :many
-SELECT m.id, m.sender_id, m.content, m.project_id, m.created_at, u.email as sender_email
+SELECT m.id, m.sender_id, m.content, m.project_id, m.created_at,
+       COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS sender_display_name,
+       COALESCE(pr.avatar_url, '')::text AS sender_avatar_url
 FROM messages m
 JOIN users u ON m.sender_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE m.project_id = $1
 ORDER BY m.created_at ASC
 `
 
 type GetProjectMessagesRow struct {
-	ID          int64
-	SenderID    int64
-	Content     string
-	ProjectID   pgtype.Int8
-	CreatedAt   pgtype.Timestamptz
-	SenderEmail string
+	ID                int64
+	SenderID          int64
+	Content           string
+	ProjectID         pgtype.Int8
+	CreatedAt         pgtype.Timestamptz
+	SenderDisplayName string
+	SenderAvatarUrl   string
 }
 
 func (q *Queries) GetProjectMessages(ctx context.Context, projectID pgtype.Int8) ([]GetProjectMessagesRow, error) {
@@ -141,7 +155,8 @@ func (q *Queries) GetProjectMessages(ctx context.Context, projectID pgtype.Int8)
 			&i.Content,
 			&i.ProjectID,
 			&i.CreatedAt,
-			&i.SenderEmail,
+			&i.SenderDisplayName,
+			&i.SenderAvatarUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -8,6 +8,16 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditLog struct {
+	ID         int64
+	ActorID    pgtype.Int8
+	Action     string
+	TargetType string
+	TargetID   pgtype.Int8
+	Details    pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+}
+
 type Message struct {
 	ID         int64
 	SenderID   int64
@@ -17,11 +27,118 @@ type Message struct {
 	CreatedAt  pgtype.Timestamptz
 }
 
+type Organization struct {
+	ID        int64
+	Name      string
+	CreatedBy pgtype.Int8
+	CreatedAt pgtype.Timestamptz
+}
+
+type OrganizationInvitation struct {
+	ID             int64
+	OrganizationID int64
+	Email          string
+	Role           string
+	InvitedBy      pgtype.Int8
+	CreatedAt      pgtype.Timestamptz
+	ExpiresAt      pgtype.Timestamptz
+	AcceptedAt     pgtype.Timestamptz
+	AcceptedBy     pgtype.Int8
+}
+
+type OrganizationMember struct {
+	OrganizationID int64
+	UserID         int64
+	Role           string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type PasswordResetToken struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type PersonalAccessToken struct {
+	ID          int64
+	UserID      int64
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	Scopes      []string
+	ExpiresAt   pgtype.Timestamptz
+	LastUsedAt  pgtype.Timestamptz
+	RevokedAt   pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+}
+
 type Project struct {
+	ID             int64
+	Name           string
+	Description    pgtype.Text
+	OwnerID        int64
+	CreatedAt      pgtype.Timestamptz
+	ArchivedAt     pgtype.Timestamptz
+	DeletedAt      pgtype.Timestamptz
+	OrganizationID int64
+}
+
+type ProjectInviteLink struct {
+	ID         int64
+	ProjectID  int64
+	CodeHash   string
+	CodePrefix string
+	Role       string
+	CreatedBy  pgtype.Int8
+	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	MaxUses    pgtype.Int4
+	UseCount   int32
+	RevokedAt  pgtype.Timestamptz
+}
+
+type ProjectInvitation struct {
+	ID         int64
+	ProjectID  int64
+	Email      string
+	Role       string
+	InvitedBy  pgtype.Int8
+	CreatedAt  pgtype.Timestamptz
+	SentAt     pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	AcceptedAt pgtype.Timestamptz
+	AcceptedBy pgtype.Int8
+	RevokedAt  pgtype.Timestamptz
+}
+
+type ProjectJoinRequest struct {
+	ID        int64
+	ProjectID int64
+	UserID    int64
+	Message   string
+	Status    string
+	CreatedAt pgtype.Timestamptz
+	DecidedAt pgtype.Timestamptz
+	DecidedBy pgtype.Int8
+}
+
+type ProjectTeam struct {
+	ProjectID int64
+	TeamID    int64
+	Role      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type ProjectTemplate struct {
 	ID          int64
+	OwnerID     int64
 	Name        string
 	Description pgtype.Text
-	OwnerID     int64
+	Tasks       []byte
+	Members     []byte
 	CreatedAt   pgtype.Timestamptz
 }
 
@@ -32,6 +149,43 @@ type ProjectUser struct {
 	Role      pgtype.Text
 }
 
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	FamilyID  string
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type RevokedToken struct {
+	Jti       string
+	UserID    pgtype.Int8
+	ExpiresAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+}
+
+type Session struct {
+	ID         int64
+	UserID     int64
+	FamilyID   string
+	UserAgent  string
+	IpAddress  string
+	CreatedAt  pgtype.Timestamptz
+	LastSeenAt pgtype.Timestamptz
+}
+
+type SigningKey struct {
+	Kid        string
+	Algorithm  string
+	PrivateKey []byte
+	PublicKey  []byte
+	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+}
+
 type Task struct {
 	ID          int64
 	ProjectID   int64
@@ -52,6 +206,26 @@ type TaskActivity struct {
 	CreatedAt pgtype.Timestamptz
 }
 
+type Team struct {
+	ID             int64
+	OrganizationID int64
+	Name           string
+	CreatedBy      pgtype.Int8
+	CreatedAt      pgtype.Timestamptz
+}
+
+type TeamMember struct {
+	TeamID    int64
+	UserID    int64
+	CreatedAt pgtype.Timestamptz
+}
+
+type TwoFactorPolicy struct {
+	Role      string
+	Required  bool
+	UpdatedAt pgtype.Timestamptz
+}
+
 type User struct {
 	ID           int64
 	Email        string
@@ -60,3 +234,52 @@ type User struct {
 	Status       string
 	CreatedAt    pgtype.Timestamptz
 }
+
+type UserAvatar struct {
+	UserID      int64
+	ContentType string
+	Data        []byte
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type UserIdentity struct {
+	ID          int64
+	UserID      int64
+	Issuer      string
+	Subject     string
+	Email       string
+	LastLoginAt pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+}
+
+type UserProfile struct {
+	UserID      int64
+	DisplayName string
+	AvatarUrl   string
+	Timezone    string
+	Locale      string
+	Bio         string
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type UserRecoveryCode struct {
+	ID        int64
+	UserID    int64
+	CodeHash  string
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserTokenCutoff struct {
+	UserID    int64
+	NotBefore pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+type UserTotpSecret struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    pgtype.Timestamptz
+	LastUsedStep int64
+	CreatedAt    pgtype.Timestamptz
+}
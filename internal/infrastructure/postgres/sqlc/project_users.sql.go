@@ -36,11 +36,18 @@ func (q *Queries) AddUserToProject(ctx context.Context, arg AddUserToProjectPara
 }
 
 const checkSharedProject = `-- name: CheckSharedProject :one
+WITH memberships AS (
+    SELECT project_id, user_id FROM project_users
+    UNION
+    SELECT pt.project_id, tm.user_id
+    FROM project_teams pt
+    JOIN team_members tm ON tm.team_id = pt.team_id
+)
 SELECT EXISTS (
     SELECT 1 
-    FROM project_users pu1
-    JOIN project_users pu2 ON pu1.project_id = pu2.project_id
-    WHERE pu1.user_id = $1 AND pu2.user_id = $2
+    FROM memberships m1
+    JOIN memberships m2 ON m1.project_id = m2.project_id
+    WHERE m1.user_id = $1 AND m2.user_id = $2
 ) AS shared
 `
 
@@ -56,10 +63,65 @@ func (q *Queries) CheckSharedProject(ctx context.Context, arg CheckSharedProject
 	return shared, err
 }
 
+const listProjectTeamMembers = `-- name: ListProjectTeamMembers :many
+SELECT
+    pt.team_id,
+    t.name AS team_name,
+    pt.role,
+    u.id AS user_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url
+FROM project_teams pt
+JOIN teams t ON t.id = pt.team_id
+JOIN team_members tm ON tm.team_id = pt.team_id
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE pt.project_id = $1
+ORDER BY t.name ASC, u.id ASC
+`
+
+type ListProjectTeamMembersRow struct {
+	TeamID      int64
+	TeamName    string
+	Role        string
+	UserID      int64
+	DisplayName string
+	AvatarUrl   string
+}
+
+// the people the project's teams bring in, merged with the direct members in Go
+func (q *Queries) ListProjectTeamMembers(ctx context.Context, projectID int64) ([]ListProjectTeamMembersRow, error) {
+	rows, err := q.db.Query(ctx, listProjectTeamMembers, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProjectTeamMembersRow
+	for rows.Next() {
+		var i ListProjectTeamMembersRow
+		if err := rows.Scan(
+			&i.TeamID,
+			&i.TeamName,
+			&i.Role,
+			&i.UserID,
+			&i.DisplayName,
+			&i.AvatarUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsersInProject = `-- name: ListUsersInProject :many
 SELECT 
     u.id, 
-    u.email, 
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS display_name,
+    COALESCE(pr.avatar_url, '')::text AS avatar_url,
     CASE 
         WHEN p.owner_id = u.id THEN 'owner'::text
         ELSE pu.role
@@ -67,13 +129,15 @@ SELECT
 FROM users u
 JOIN project_users pu ON u.id = pu.user_id
 JOIN projects p ON pu.project_id = p.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE pu.project_id = $1
 `
 
 type ListUsersInProjectRow struct {
-	ID    int64
-	Email string
-	Role  interface{}
+	ID          int64
+	DisplayName string
+	AvatarUrl   string
+	Role        interface{}
 }
 
 func (q *Queries) ListUsersInProject(ctx context.Context, projectID int64) ([]ListUsersInProjectRow, error) {
@@ -85,7 +149,12 @@ func (q *Queries) ListUsersInProject(ctx context.Context, projectID int64) ([]Li
 	var items []ListUsersInProjectRow
 	for rows.Next() {
 		var i ListUsersInProjectRow
-		if err := rows.Scan(&i.ID, &i.Email, &i.Role); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Role,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
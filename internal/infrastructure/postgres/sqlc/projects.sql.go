@@ -13,9 +13,9 @@ import (
 
 const createProject = `-- name: CreateProject :one
 WITH inserted AS (
-    INSERT INTO projects (name, description, owner_id)
-    VALUES ($1, $2, $3)
-    RETURNING id, name, description, owner_id, created_at
+    INSERT INTO projects (name, description, owner_id, organization_id)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id, name, description, owner_id, created_at, organization_id
 )
 SELECT 
     i.id, 
@@ -23,28 +23,39 @@ SELECT
     i.description, 
     i.owner_id, 
     i.created_at,
-    u.email AS owner_name
+    i.organization_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS owner_display_name,
+    COALESCE(pr.avatar_url, '')::text AS owner_avatar_url
 FROM inserted i
 JOIN users u ON i.owner_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 `
 
 type CreateProjectParams struct {
-	Name        string
-	Description pgtype.Text
-	OwnerID     int64
+	Name           string
+	Description    pgtype.Text
+	OwnerID        int64
+	OrganizationID int64
 }
 
 type CreateProjectRow struct {
-	ID          int64
-	Name        string
-	Description pgtype.Text
-	OwnerID     int64
-	CreatedAt   pgtype.Timestamptz
-	OwnerName   string
+	ID               int64
+	Name             string
+	Description      pgtype.Text
+	OwnerID          int64
+	CreatedAt        pgtype.Timestamptz
+	OrganizationID   int64
+	OwnerDisplayName string
+	OwnerAvatarUrl   string
 }
 
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (CreateProjectRow, error) {
-	row := q.db.QueryRow(ctx, createProject, arg.Name, arg.Description, arg.OwnerID)
+	row := q.db.QueryRow(ctx, createProject,
+		arg.Name,
+		arg.Description,
+		arg.OwnerID,
+		arg.OrganizationID,
+	)
 	var i CreateProjectRow
 	err := row.Scan(
 		&i.ID,
@@ -52,7 +63,9 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (C
 		&i.Description,
 		&i.OwnerID,
 		&i.CreatedAt,
-		&i.OwnerName,
+		&i.OrganizationID,
+		&i.OwnerDisplayName,
+		&i.OwnerAvatarUrl,
 	)
 	return i, err
 }
@@ -73,7 +86,7 @@ func (q *Queries) DeleteProject(ctx context.Context, arg DeleteProjectParams) er
 }
 
 const getProject = `-- name: GetProject :one
-SELECT id, name, description, owner_id, created_at FROM projects
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id FROM projects
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,16 +99,20 @@ func (q *Queries) GetProject(ctx context.Context, id int64) (Project, error) {
 		&i.Description,
 		&i.OwnerID,
 		&i.CreatedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, name, description, owner_id, created_at
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id
 FROM projects
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
+// soft deleted projects are gone as far as the app is concerned
 func (q *Queries) GetProjectByID(ctx context.Context, id int64) (Project, error) {
 	row := q.db.QueryRow(ctx, getProjectByID, id)
 	var i Project
@@ -105,10 +122,54 @@ func (q *Queries) GetProjectByID(ctx context.Context, id int64) (Project, error)
 		&i.Description,
 		&i.OwnerID,
 		&i.CreatedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
+const listDeletedProjects = `-- name: ListDeletedProjects :many
+SELECT id, name, description, owner_id, created_at, archived_at, deleted_at, organization_id
+FROM projects
+WHERE owner_id = $1 AND deleted_at > $2
+ORDER BY deleted_at DESC
+`
+
+type ListDeletedProjectsParams struct {
+	OwnerID      int64
+	DeletedAfter pgtype.Timestamptz
+}
+
+func (q *Queries) ListDeletedProjects(ctx context.Context, arg ListDeletedProjectsParams) ([]Project, error) {
+	rows, err := q.db.Query(ctx, listDeletedProjects, arg.OwnerID, arg.DeletedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjectsByOwner = `-- name: ListProjectsByOwner :many
 SELECT 
     p.id, 
@@ -116,25 +177,42 @@ SELECT
     p.description, 
     p.owner_id, 
     p.created_at,
-    u.email AS owner_name
+    p.archived_at,
+    p.organization_id,
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS owner_display_name,
+    COALESCE(pr.avatar_url, '')::text AS owner_avatar_url
 FROM projects p
 LEFT JOIN users u ON p.owner_id = u.id
-WHERE p.owner_id = $1 
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
+WHERE (p.owner_id = $1
    OR p.id IN (SELECT project_id FROM project_users WHERE user_id = $1)
+   OR p.id IN (SELECT pt.project_id FROM project_teams pt
+               JOIN team_members tm ON tm.team_id = pt.team_id
+               WHERE tm.user_id = $1))
+  AND p.deleted_at IS NULL
+  AND ($2::boolean OR p.archived_at IS NULL)
 ORDER BY p.created_at ASC
 `
 
+type ListProjectsByOwnerParams struct {
+	OwnerID         int64
+	IncludeArchived bool
+}
+
 type ListProjectsByOwnerRow struct {
-	ID          int64
-	Name        string
-	Description pgtype.Text
-	OwnerID     int64
-	CreatedAt   pgtype.Timestamptz
-	OwnerName   pgtype.Text
+	ID               int64
+	Name             string
+	Description      pgtype.Text
+	OwnerID          int64
+	CreatedAt        pgtype.Timestamptz
+	ArchivedAt       pgtype.Timestamptz
+	OrganizationID   int64
+	OwnerDisplayName string
+	OwnerAvatarUrl   string
 }
 
-func (q *Queries) ListProjectsByOwner(ctx context.Context, ownerID int64) ([]ListProjectsByOwnerRow, error) {
-	rows, err := q.db.Query(ctx, listProjectsByOwner, ownerID)
+func (q *Queries) ListProjectsByOwner(ctx context.Context, arg ListProjectsByOwnerParams) ([]ListProjectsByOwnerRow, error) {
+	rows, err := q.db.Query(ctx, listProjectsByOwner, arg.OwnerID, arg.IncludeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +226,10 @@ func (q *Queries) ListProjectsByOwner(ctx context.Context, ownerID int64) ([]Lis
 			&i.Description,
 			&i.OwnerID,
 			&i.CreatedAt,
-			&i.OwnerName,
+			&i.ArchivedAt,
+			&i.OrganizationID,
+			&i.OwnerDisplayName,
+			&i.OwnerAvatarUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -160,6 +241,97 @@ func (q *Queries) ListProjectsByOwner(ctx context.Context, ownerID int64) ([]Lis
 	return items, nil
 }
 
+const purgeDeletedProjects = `-- name: PurgeDeletedProjects :execrows
+DELETE FROM projects
+WHERE deleted_at IS NOT NULL AND deleted_at <= $1
+`
+
+// tasks, task history, chat and members go with the project (ON DELETE CASCADE)
+func (q *Queries) PurgeDeletedProjects(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedProjects, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreProject = `-- name: RestoreProject :execrows
+UPDATE projects
+SET deleted_at = NULL
+WHERE id = $1 AND owner_id = $2 AND deleted_at > $3
+`
+
+type RestoreProjectParams struct {
+	ID           int64
+	OwnerID      int64
+	DeletedAfter pgtype.Timestamptz
+}
+
+// only within the retention period, after that the purge job may already have run
+func (q *Queries) RestoreProject(ctx context.Context, arg RestoreProjectParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreProject, arg.ID, arg.OwnerID, arg.DeletedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setProjectArchived = `-- name: SetProjectArchived :execrows
+UPDATE projects
+SET archived_at = CASE WHEN $1::boolean THEN COALESCE(archived_at, NOW()) END
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type SetProjectArchivedParams struct {
+	Archived bool
+	ID       int64
+}
+
+// archived_at keeps the first archive time, unarchiving clears it
+func (q *Queries) SetProjectArchived(ctx context.Context, arg SetProjectArchivedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setProjectArchived, arg.Archived, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteProject = `-- name: SoftDeleteProject :execrows
+UPDATE projects
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteProject(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteProject, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const transferProjectOwner = `-- name: TransferProjectOwner :execrows
+UPDATE projects
+SET owner_id = $1
+WHERE id = $2 AND owner_id = $3
+`
+
+type TransferProjectOwnerParams struct {
+	NewOwnerID     int64
+	ID             int64
+	CurrentOwnerID int64
+}
+
+// only moves the project if it still belongs to current_owner_id, so two
+// transfers racing each other can't both win
+func (q *Queries) TransferProjectOwner(ctx context.Context, arg TransferProjectOwnerParams) (int64, error) {
+	result, err := q.db.Exec(ctx, transferProjectOwner, arg.NewOwnerID, arg.ID, arg.CurrentOwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateProject = `-- name: UpdateProject :exec
 UPDATE projects
 SET name = $2,
@@ -82,24 +82,27 @@ SELECT
     ta.id, 
     ta.task_id, 
     ta.user_id, 
-    u.email as user_email, -- Add this
+    COALESCE(NULLIF(pr.display_name, ''), split_part(u.email, '@', 1))::text AS user_display_name,
+    COALESCE(pr.avatar_url, '')::text AS user_avatar_url,
     ta.action, 
     ta.details, 
     ta.created_at
 FROM task_activities ta
-JOIN users u ON ta.user_id = u.id -- Add this join
+JOIN users u ON ta.user_id = u.id
+LEFT JOIN user_profiles pr ON pr.user_id = u.id
 WHERE ta.task_id = $1
 ORDER BY ta.created_at DESC
 `
 
 type GetTaskHistoryRow struct {
-	ID        int64
-	TaskID    int64
-	UserID    int64
-	UserEmail string
-	Action    string
-	Details   pgtype.Text
-	CreatedAt pgtype.Timestamptz
+	ID              int64
+	TaskID          int64
+	UserID          int64
+	UserDisplayName string
+	UserAvatarUrl   string
+	Action          string
+	Details         pgtype.Text
+	CreatedAt       pgtype.Timestamptz
 }
 
 func (q *Queries) GetTaskHistory(ctx context.Context, taskID int64) ([]GetTaskHistoryRow, error) {
@@ -115,7 +118,8 @@ func (q *Queries) GetTaskHistory(ctx context.Context, taskID int64) ([]GetTaskHi
 			&i.ID,
 			&i.TaskID,
 			&i.UserID,
-			&i.UserEmail,
+			&i.UserDisplayName,
+			&i.UserAvatarUrl,
 			&i.Action,
 			&i.Details,
 			&i.CreatedAt,
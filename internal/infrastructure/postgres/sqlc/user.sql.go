@@ -7,6 +7,8 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -61,25 +63,51 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 	return i, err
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, email FROM users 
-ORDER BY email ASC
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, password_hash, role, status, created_at
+FROM users
+WHERE id = $1
 `
 
-type ListUsersRow struct {
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listUsersVisibleTo = `-- name: ListUsersVisibleTo :many
+SELECT DISTINCT u.id, u.email
+FROM users u
+JOIN organization_members m ON m.user_id = u.id
+WHERE m.organization_id IN (
+    SELECT organization_id FROM organization_members WHERE user_id = $1
+)
+ORDER BY u.email ASC
+`
+
+type ListUsersVisibleToRow struct {
 	ID    int64
 	Email string
 }
 
-func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
-	rows, err := q.db.Query(ctx, listUsers)
+// the people in any of the viewer's organizations
+func (q *Queries) ListUsersVisibleTo(ctx context.Context, userID int64) ([]ListUsersVisibleToRow, error) {
+	rows, err := q.db.Query(ctx, listUsersVisibleTo, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListUsersRow
+	var items []ListUsersVisibleToRow
 	for rows.Next() {
-		var i ListUsersRow
+		var i ListUsersVisibleToRow
 		if err := rows.Scan(&i.ID, &i.Email); err != nil {
 			return nil, err
 		}
@@ -90,3 +118,102 @@ func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
 	}
 	return items, nil
 }
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, email, password_hash, role, status, created_at
+FROM users
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::text IS NULL OR role = $2)
+  AND ($3::text IS NULL OR email ILIKE '%' || $3 || '%')
+ORDER BY id ASC
+LIMIT $4 OFFSET $5
+`
+
+type SearchUsersParams struct {
+	Status pgtype.Text
+	Role   pgtype.Text
+	Email  pgtype.Text
+	Lim    int32
+	Off    int32
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.Status,
+		arg.Role,
+		arg.Email,
+		arg.Lim,
+		arg.Off,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.Role,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           int64
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :exec
+UPDATE users
+SET status = $2
+WHERE id = $1
+`
+
+type UpdateUserStatusParams struct {
+	ID     int64
+	Status string
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) error {
+	_, err := q.db.Exec(ctx, updateUserStatus, arg.ID, arg.Status)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :exec
+UPDATE users
+SET role = $2
+WHERE id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   int64
+	Role string
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.Exec(ctx, updateUserRole, arg.ID, arg.Role)
+	return err
+}
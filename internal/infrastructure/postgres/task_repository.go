@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 	"github.com/nelfander/Playingfield/internal/domain/tasks"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
 )
@@ -127,7 +128,7 @@ func (r *TaskRepository) GetTaskHistory(ctx context.Context, taskID int64) ([]*t
 			ID:        row.ID,
 			TaskID:    row.TaskID,
 			UserID:    row.UserID,
-			UserEmail: row.UserEmail,
+			User:      profiles.Summary{ID: row.UserID, DisplayName: row.UserDisplayName, AvatarURL: row.UserAvatarUrl},
 			Action:    row.Action,
 			Details:   row.Details.String,
 			CreatedAt: row.CreatedAt.Time,
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/teams"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type TeamRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewTeamRepository(db *DBAdapter) *TeamRepository {
+	return &TeamRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *TeamRepository) Create(ctx context.Context, t teams.Team) (*teams.Team, error) {
+	params := sqlc.CreateTeamParams{
+		OrganizationID: t.OrganizationID,
+		Name:           t.Name,
+	}
+	if t.CreatedBy != nil {
+		params.CreatedBy = pgtype.Int8{Int64: *t.CreatedBy, Valid: true}
+	}
+
+	row, err := r.queries.CreateTeam(ctx, params)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, teams.ErrNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return teamFromRow(row), nil
+}
+
+func (r *TeamRepository) GetByID(ctx context.Context, id int64) (*teams.Team, error) {
+	row, err := r.queries.GetTeam(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, teams.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return teamFromRow(row), nil
+}
+
+func (r *TeamRepository) ListForOrganization(ctx context.Context, orgID int64) ([]teams.Team, error) {
+	rows, err := r.queries.ListTeamsInOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]teams.Team, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, *teamFromRow(row))
+	}
+	return res, nil
+}
+
+func (r *TeamRepository) Delete(ctx context.Context, id int64) error {
+	return r.queries.DeleteTeam(ctx, id)
+}
+
+func (r *TeamRepository) ListMembers(ctx context.Context, teamID int64) ([]teams.Member, error) {
+	rows, err := r.queries.ListTeamMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]teams.Member, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, teams.Member{
+			UserID:   row.UserID,
+			Profile:  profiles.Summary{ID: row.UserID, DisplayName: row.DisplayName, AvatarURL: row.AvatarUrl},
+			JoinedAt: row.CreatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+func (r *TeamRepository) AddMember(ctx context.Context, teamID, userID int64) (bool, error) {
+	n, err := r.queries.AddTeamMember(ctx, sqlc.AddTeamMemberParams{TeamID: teamID, UserID: userID})
+	return n > 0, err
+}
+
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamID, userID int64) (bool, error) {
+	n, err := r.queries.RemoveTeamMember(ctx, sqlc.RemoveTeamMemberParams{TeamID: teamID, UserID: userID})
+	return n > 0, err
+}
+
+func (r *TeamRepository) ProjectIDs(ctx context.Context, teamID int64) ([]int64, error) {
+	return r.queries.ListTeamProjectIDs(ctx, teamID)
+}
+
+func (r *TeamRepository) AddToProject(ctx context.Context, projectID, teamID int64, role string) (bool, error) {
+	n, err := r.queries.AddTeamToProject(ctx, sqlc.AddTeamToProjectParams{
+		ProjectID: projectID,
+		TeamID:    teamID,
+		Role:      role,
+	})
+	return n > 0, err
+}
+
+func (r *TeamRepository) RemoveFromProject(ctx context.Context, projectID, teamID int64) (bool, error) {
+	n, err := r.queries.RemoveTeamFromProject(ctx, sqlc.RemoveTeamFromProjectParams{
+		ProjectID: projectID,
+		TeamID:    teamID,
+	})
+	return n > 0, err
+}
+
+func (r *TeamRepository) ListForProject(ctx context.Context, projectID int64) ([]teams.ProjectTeam, error) {
+	rows, err := r.queries.ListProjectTeams(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]teams.ProjectTeam, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, teams.ProjectTeam{
+			TeamID:  row.TeamID,
+			Name:    row.Name,
+			Role:    row.Role,
+			AddedAt: row.CreatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+func teamFromRow(row sqlc.Team) *teams.Team {
+	t := &teams.Team{
+		ID:             row.ID,
+		OrganizationID: row.OrganizationID,
+		Name:           row.Name,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+	if row.CreatedBy.Valid {
+		t.CreatedBy = &row.CreatedBy.Int64
+	}
+	return t
+}
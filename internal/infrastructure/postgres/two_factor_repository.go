@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
+)
+
+type TwoFactorRepository struct {
+	db      *DBAdapter
+	queries *sqlc.Queries
+}
+
+func NewTwoFactorRepository(db *DBAdapter) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+func (r *TwoFactorRepository) SaveSecret(ctx context.Context, userID int64, secret string) (*user.TOTPSecret, error) {
+	res, err := r.queries.UpsertTOTPSecret(ctx, sqlc.UpsertTOTPSecretParams{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCTOTPSecretToDomain(res), nil
+}
+
+func (r *TwoFactorRepository) GetSecret(ctx context.Context, userID int64) (*user.TOTPSecret, error) {
+	res, err := r.queries.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mapSQLCTOTPSecretToDomain(res), nil
+}
+
+func (r *TwoFactorRepository) EnableSecret(ctx context.Context, userID int64) error {
+	return r.queries.EnableTOTPSecret(ctx, userID)
+}
+
+func (r *TwoFactorRepository) DeleteSecret(ctx context.Context, userID int64) error {
+	return r.queries.DeleteTOTPSecret(ctx, userID)
+}
+
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	affected, err := r.queries.UseTOTPStep(ctx, sqlc.UseTOTPStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	if err := r.queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if err := r.queries.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: h,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	affected, err := r.queries.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *TwoFactorRepository) IsRequired(ctx context.Context, role string) (bool, error) {
+	required, err := r.queries.IsTwoFactorRequired(ctx, role)
+	// no row means nobody ever set a policy for this role
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return required, err
+}
+
+func (r *TwoFactorRepository) SetRequired(ctx context.Context, role string, required bool) error {
+	return r.queries.SetTwoFactorPolicy(ctx, sqlc.SetTwoFactorPolicyParams{
+		Role:     role,
+		Required: required,
+	})
+}
+
+func (r *TwoFactorRepository) ListPolicies(ctx context.Context) ([]user.TwoFactorPolicy, error) {
+	rows, err := r.queries.ListTwoFactorPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]user.TwoFactorPolicy, 0, len(rows))
+	for _, row := range rows {
+		policies = append(policies, user.TwoFactorPolicy{
+			Role:      row.Role,
+			Required:  row.Required,
+			UpdatedAt: row.UpdatedAt.Time,
+		})
+	}
+	return policies, nil
+}
+
+func mapSQLCTOTPSecretToDomain(row sqlc.UserTotpSecret) *user.TOTPSecret {
+	return &user.TOTPSecret{
+		UserID:       row.UserID,
+		Secret:       row.Secret,
+		EnabledAt:    nullableTime(row.EnabledAt),
+		LastUsedStep: row.LastUsedStep,
+		CreatedAt:    row.CreatedAt.Time,
+	}
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/nelfander/Playingfield/internal/domain/user"
 
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
@@ -34,6 +35,23 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 	}, nil
 }
 
+// GetByID returns a domain User pointer
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*user.User, error) {
+	row, err := r.queries.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.User{
+		ID:           row.ID,
+		Email:        row.Email,
+		PasswordHash: row.PasswordHash,
+		Role:         row.Role,
+		Status:       row.Status,
+		CreatedAt:    row.CreatedAt.Time,
+	}, nil
+}
+
 // create inserts a new user and returns a pointer to domain User
 func (r *UserRepository) Create(ctx context.Context, u user.User) (*user.User, error) {
 	res, err := r.queries.CreateUser(ctx, sqlc.CreateUserParams{
@@ -57,8 +75,56 @@ func (r *UserRepository) Create(ctx context.Context, u user.User) (*user.User, e
 	}, nil
 }
 
-func (r *UserRepository) ListUsers(ctx context.Context) ([]user.UserListRow, error) {
-	rows, err := r.queries.ListUsers(ctx)
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	return r.queries.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	})
+}
+
+func (r *UserRepository) UpdateStatus(ctx context.Context, userID int64, status string) error {
+	return r.queries.UpdateUserStatus(ctx, sqlc.UpdateUserStatusParams{
+		ID:     userID,
+		Status: status,
+	})
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, userID int64, role string) error {
+	return r.queries.UpdateUserRole(ctx, sqlc.UpdateUserRoleParams{
+		ID:   userID,
+		Role: role,
+	})
+}
+
+func (r *UserRepository) SearchUsers(ctx context.Context, f user.UserFilter) ([]user.User, error) {
+	rows, err := r.queries.SearchUsers(ctx, sqlc.SearchUsersParams{
+		Status: pgtype.Text{String: f.Status, Valid: f.Status != ""},
+		Role:   pgtype.Text{String: f.Role, Valid: f.Role != ""},
+		Email:  pgtype.Text{String: f.Email, Valid: f.Email != ""},
+		Lim:    int32(f.Limit),
+		Off:    int32(f.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []user.User
+	for _, row := range rows {
+		list = append(list, user.User{
+			ID:           row.ID,
+			Email:        row.Email,
+			PasswordHash: row.PasswordHash,
+			Role:         row.Role,
+			Status:       row.Status,
+			CreatedAt:    row.CreatedAt.Time,
+		})
+	}
+	return list, nil
+}
+
+// ListUsersVisibleTo returns the people who share an organization with the viewer
+func (r *UserRepository) ListUsersVisibleTo(ctx context.Context, viewerID int64) ([]user.UserListRow, error) {
+	rows, err := r.queries.ListUsersVisibleTo(ctx, viewerID)
 	if err != nil {
 		return nil, err
 	}
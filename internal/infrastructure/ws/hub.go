@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -11,6 +12,7 @@ import (
 // client represents a single connected user
 type Client struct {
 	UserID    int64
+	SessionID int64 // login session of the token used for the handshake, 0 if unknown
 	ProjectID int64
 	Conn      *websocket.Conn
 	Send      chan []byte   // a channel to send messages to this specific user
@@ -39,9 +41,10 @@ func NewHub() *Hub {
 	}
 }
 
-func NewClient(userID, projectID int64, conn *websocket.Conn) *Client {
+func NewClient(userID, sessionID, projectID int64, conn *websocket.Conn) *Client {
 	return &Client{
 		UserID:    userID,
+		SessionID: sessionID,
 		ProjectID: projectID,
 		Conn:      conn,
 		Send:      make(chan []byte, 256),
@@ -151,6 +154,48 @@ func (h *Hub) Run() {
 	}
 }
 
+// DisconnectUser closes every socket the user has open (global and chat rooms)
+// with a close frame. The handler's read loop then fails and unregisters the client.
+func (h *Hub) DisconnectUser(userID int64, reason string) {
+	h.disconnect(func(c *Client) bool { return c.UserID == userID }, reason)
+}
+
+// DisconnectSession is DisconnectUser for a single login session, the
+// user's other devices stay connected
+func (h *Hub) DisconnectSession(sessionID int64, reason string) {
+	if sessionID == 0 {
+		return
+	}
+	h.disconnect(func(c *Client) bool { return c.SessionID == sessionID }, reason)
+}
+
+// disconnect collects the matching clients under the lock and closes them
+// after releasing it, so a slow close frame doesn't hold up the hub
+func (h *Hub) disconnect(match func(*Client) bool, reason string) {
+	targets := make(map[*Client]bool)
+	h.mu.RLock()
+	for _, client := range h.clients {
+		if match(client) {
+			targets[client] = true
+		}
+	}
+	for _, room := range h.ProjectRooms {
+		for client := range room {
+			if match(client) {
+				targets[client] = true
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	for client := range targets {
+		// WriteControl is safe to call next to the write pump
+		client.Conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		client.Conn.Close()
+	}
+}
+
 func (h *Hub) SendToUser(userID int64, message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
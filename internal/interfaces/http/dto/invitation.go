@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+)
+
+type InviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // member (default) or admin
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"` // from the link in the invitation mail
+}
+
+type CreateInviteLinkRequest struct {
+	Role      string     `json:"role"`       // member (default) or admin
+	ExpiresAt *time.Time `json:"expires_at"` // omit for a link that never expires
+	MaxUses   *int       `json:"max_uses"`   // omit for unlimited joins
+}
+
+// CreateInviteLinkResponse is the only time the code is shown
+type CreateInviteLinkResponse struct {
+	Code string                  `json:"code"`
+	URL  string                  `json:"url"`
+	Info *invitations.InviteLink `json:"info"`
+}
+
+type CreateJoinRequestRequest struct {
+	Message string `json:"message"` // optional note for the owners
+}
+
+type ApproveJoinRequestRequest struct {
+	Role string `json:"role"` // member (default), viewer or maintainer
+}
@@ -1,7 +1,8 @@
 package dto
 
 type LoginResponse struct {
-	Token  string       `json:"token"`
-	UserId int64        `json:"userId"`
-	User   UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	UserId       int64        `json:"userId"`
+	User         UserResponse `json:"user"`
 }
@@ -0,0 +1,14 @@
+package dto
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type InviteOrganizationMemberRequest struct {
+	Email string `json:"email"` // the account may not exist yet
+	Role  string `json:"role"`  // member (default) or admin
+}
+
+type ChangeOrganizationRoleRequest struct {
+	Role string `json:"role"`
+}
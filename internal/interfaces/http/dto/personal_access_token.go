@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+)
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // omit for a token that never expires
+}
+
+// CreatePersonalAccessTokenResponse is the only time the raw token is shown
+type CreatePersonalAccessTokenResponse struct {
+	Token string      `json:"token"`
+	Info  *pats.Token `json:"info"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// DeleteAccountRequest confirms DELETE /me with the password. Accounts that
+// only sign in through the identity provider send none, they need a fresh login.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	TransferredProjects map[int64]int64 `json:"transferred_projects"` // project id -> new owner
+	DeletedProjects     []int64         `json:"deleted_projects"`
+}
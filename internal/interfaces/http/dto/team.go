@@ -0,0 +1,15 @@
+package dto
+
+type CreateTeamRequest struct {
+	OrganizationID int64  `json:"organization_id"`
+	Name           string `json:"name"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type AddProjectTeamRequest struct {
+	TeamID int64  `json:"team_id"`
+	Role   string `json:"role"` // maintainer, member (default) or viewer
+}
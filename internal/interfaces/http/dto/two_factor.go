@@ -0,0 +1,21 @@
+package dto
+
+// TwoFactorChallengeResponse replaces LoginResponse when a second factor is needed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	ChallengeToken     string `json:"challenge_token"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // authenticator code or recovery code
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorPolicyRequest struct {
+	Required bool `json:"required"`
+}
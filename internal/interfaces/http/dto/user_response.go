@@ -19,6 +19,8 @@ func MapUser(u *user.User) UserResponse {
 	return UserResponse{
 		ID:        u.ID,
 		Email:     u.Email,
+		Role:      u.Role,
+		Status:    u.Status,
 		CreatedAt: u.CreatedAt,
 	}
 }
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/audit"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// AdminHandler holds the routes mounted under RequireRole("admin")
+type AdminHandler struct {
+	service *user.AdminService
+	audit   *audit.Service
+}
+
+func NewAdminHandler(service *user.AdminService, audit *audit.Service) *AdminHandler {
+	return &AdminHandler{service: service, audit: audit}
+}
+
+// GET /admin/users?status=&role=&email=&limit=&offset=
+func (h *AdminHandler) ListUsers(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	offset, _ := strconv.Atoi(c.QueryParam("offset"))
+
+	users, err := h.service.ListUsers(c.Request().Context(), user.UserFilter{
+		Status: c.QueryParam("status"),
+		Role:   c.QueryParam("role"),
+		Email:  c.QueryParam("email"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch users"})
+	}
+
+	resp := make([]dto.UserResponse, 0, len(users))
+	for i := range users {
+		resp = append(resp, dto.MapUser(&users[i]))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// POST /admin/users/:id/suspend
+func (h *AdminHandler) Suspend(c echo.Context) error {
+	return h.setStatus(c, user.StatusSuspended)
+}
+
+// POST /admin/users/:id/ban
+func (h *AdminHandler) Ban(c echo.Context) error {
+	return h.setStatus(c, user.StatusBanned)
+}
+
+// POST /admin/users/:id/reactivate
+func (h *AdminHandler) Reactivate(c echo.Context) error {
+	return h.setStatus(c, user.StatusActive)
+}
+
+func (h *AdminHandler) setStatus(c echo.Context, status string) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	u, err := h.service.SetStatus(c.Request().Context(), claims.UserID, userID, status)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, dto.MapUser(u))
+}
+
+// PUT /admin/users/:id/role
+func (h *AdminHandler) SetRole(c echo.Context) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	u, err := h.service.SetRole(c.Request().Context(), claims.UserID, userID, req.Role)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, dto.MapUser(u))
+}
+
+// POST /admin/users/:id/password-reset
+func (h *AdminHandler) ForcePasswordReset(c echo.Context) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.service.ForcePasswordReset(c.Request().Context(), claims.UserID, userID); err != nil {
+		return adminError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /admin/users/:id/logout
+// ends every session of a user: refresh tokens and live access tokens
+func (h *AdminHandler) LogoutUser(c echo.Context) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.service.RevokeSessions(c.Request().Context(), claims.UserID, userID); err != nil {
+		return adminError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /admin/tokens/revoke
+// puts a single access token id on the denylist, takes effect on the next request
+func (h *AdminHandler) RevokeToken(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		JTI    string `json:"jti"`
+		UserID int64  `json:"user_id"`
+	}
+	if err := c.Bind(&req); err != nil || req.JTI == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "jti is required"})
+	}
+
+	if err := h.service.RevokeToken(c.Request().Context(), claims.UserID, req.JTI, req.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to revoke token"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /admin/users/:id/verification/resend
+func (h *AdminHandler) ResendVerification(c echo.Context) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.service.ResendVerification(c.Request().Context(), claims.UserID, userID); err != nil {
+		return adminError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /admin/users/:id/verify
+func (h *AdminHandler) ForceVerify(c echo.Context) error {
+	claims, userID, ok := adminTarget(c)
+	if !ok {
+		return nil
+	}
+
+	u, err := h.service.ForceVerify(c.Request().Context(), claims.UserID, userID)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, dto.MapUser(u))
+}
+
+// GET /admin/2fa/policies
+func (h *AdminHandler) TwoFactorPolicies(c echo.Context) error {
+	policies, err := h.service.TwoFactorPolicies(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch 2fa policies"})
+	}
+	return c.JSON(http.StatusOK, policies)
+}
+
+// PUT /admin/2fa/policies/:role
+func (h *AdminHandler) SetTwoFactorPolicy(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.TwoFactorPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	if err := h.service.SetTwoFactorPolicy(c.Request().Context(), claims.UserID, c.Param("role"), req.Required); err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"role": c.Param("role"), "required": req.Required})
+}
+
+// GET /admin/audit?actor_id=&action=&target_type=&target_id=&limit=
+func (h *AdminHandler) AuditLog(c echo.Context) error {
+	actorID, _ := strconv.ParseInt(c.QueryParam("actor_id"), 10, 64)
+	targetID, _ := strconv.ParseInt(c.QueryParam("target_id"), 10, 64)
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	entries, err := h.audit.List(c.Request().Context(), audit.Filter{
+		ActorID:    actorID,
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   targetID,
+		Limit:      limit,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch audit log"})
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// adminTarget pulls the acting admin and the :id of the user being acted on.
+// When ok is false the error response has already been written.
+func adminTarget(c echo.Context) (claims *auth.Claims, userID int64, ok bool) {
+	claims, ok = c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		_ = c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		return nil, 0, false
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+		return nil, 0, false
+	}
+	return claims, userID, true
+}
+
+func adminError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": user.ErrUserNotFound.Error()})
+	case errors.Is(err, user.ErrInvalidStatus):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": user.ErrInvalidStatus.Error()})
+	case errors.Is(err, user.ErrInvalidRole):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": user.ErrInvalidRole.Error()})
+	case errors.Is(err, user.ErrSelfAction):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": user.ErrSelfAction.Error()})
+	case errors.Is(err, user.ErrAlreadyVerified):
+		return c.JSON(http.StatusConflict, echo.Map{"error": user.ErrAlreadyVerified.Error()})
+	}
+	// anything else is a storage or mail failure, keep the details in the log
+	log.Printf("admin action failed: %v", err)
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": "internal server error"})
+}
@@ -24,9 +24,14 @@ func (h *ChatHandler) GetProjectHistory(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
 	}
 
-	history, err := h.service.GetProjectHistory(c.Request().Context(), projectID)
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	history, err := h.service.GetProjectHistory(c.Request().Context(), claims.UserID, projectID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return policyError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, history)
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// InvitationHandler lets project owners invite people by email and lets
+// the invited accept with the link from the mail
+type InvitationHandler struct {
+	service *invitations.Service
+}
+
+func NewInvitationHandler(service *invitations.Service) *InvitationHandler {
+	return &InvitationHandler{service: service}
+}
+
+// POST /projects/:id/invitations
+func (h *InvitationHandler) Invite(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req dto.InviteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	inv, err := h.service.Invite(c.Request().Context(), claims.UserID, projectID, req.Email, req.Role)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusCreated, inv)
+}
+
+// GET /projects/:id/invitations
+func (h *InvitationHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	list, err := h.service.List(c.Request().Context(), claims.UserID, projectID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// POST /projects/:id/invitations/:invitation_id/resend
+func (h *InvitationHandler) Resend(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, invitationID, err := invitationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	inv, err := h.service.Resend(c.Request().Context(), claims.UserID, projectID, invitationID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, inv)
+}
+
+// DELETE /projects/:id/invitations/:invitation_id
+func (h *InvitationHandler) Revoke(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, invitationID, err := invitationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), claims.UserID, projectID, invitationID); err != nil {
+		return invitationError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /invitations/accept
+// for users who already have an account and open the link while signed in
+func (h *InvitationHandler) Accept(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "token is required"})
+	}
+
+	inv, err := h.service.Accept(c.Request().Context(), req.Token, claims.UserID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, inv)
+}
+
+func invitationParams(c echo.Context) (int64, int64, error) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid project id")
+	}
+	invitationID, err := strconv.ParseInt(c.Param("invitation_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid invitation id")
+	}
+	return projectID, invitationID, nil
+}
+
+func invitationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, invitations.ErrProjectNotFound), errors.Is(err, invitations.ErrInvitationNotFound),
+		errors.Is(err, invitations.ErrInviteLinkNotFound), errors.Is(err, invitations.ErrJoinRequestNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, invitations.ErrNotProjectManager), errors.Is(err, invitations.ErrInvitationMismatch),
+		errors.Is(err, invitations.ErrAccountNotActivated):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case errors.Is(err, invitations.ErrInvalidEmail), errors.Is(err, invitations.ErrInvalidRole),
+		errors.Is(err, invitations.ErrInvalidInvitation), errors.Is(err, invitations.ErrInvalidInviteLink),
+		errors.Is(err, invitations.ErrInvalidExpiry), errors.Is(err, invitations.ErrInvalidMaxUses),
+		errors.Is(err, invitations.ErrJoinMessageTooLong):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, invitations.ErrAlreadyMember), errors.Is(err, invitations.ErrAlreadyInvited),
+		errors.Is(err, invitations.ErrAlreadyRequested):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	return policyError(c, err)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// InviteLinkHandler manages the join links of a project and lets people join through them
+type InviteLinkHandler struct {
+	service *invitations.LinkService
+}
+
+func NewInviteLinkHandler(service *invitations.LinkService) *InviteLinkHandler {
+	return &InviteLinkHandler{service: service}
+}
+
+// POST /projects/:id/invite-links
+func (h *InviteLinkHandler) Create(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req dto.CreateInviteLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	code, link, err := h.service.Create(c.Request().Context(), claims.UserID, projectID, req.Role, req.ExpiresAt, req.MaxUses)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusCreated, dto.CreateInviteLinkResponse{Code: code, URL: h.service.URL(code), Info: link})
+}
+
+// GET /projects/:id/invite-links
+func (h *InviteLinkHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	links, err := h.service.List(c.Request().Context(), claims.UserID, projectID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, links)
+}
+
+// DELETE /projects/:id/invite-links/:link_id
+func (h *InviteLinkHandler) Revoke(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+	linkID, err := strconv.ParseInt(c.Param("link_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid link id"})
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), claims.UserID, projectID, linkID); err != nil {
+		return invitationError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /invites/:code/accept
+func (h *InviteLinkHandler) Accept(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	link, err := h.service.Accept(c.Request().Context(), c.Param("code"), claims.UserID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"project_id": link.ProjectID, "role": link.Role})
+}
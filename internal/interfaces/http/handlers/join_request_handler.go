@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// JoinRequestHandler lets people ask to join a project and its managers answer
+type JoinRequestHandler struct {
+	service *invitations.JoinRequestService
+}
+
+func NewJoinRequestHandler(service *invitations.JoinRequestService) *JoinRequestHandler {
+	return &JoinRequestHandler{service: service}
+}
+
+// POST /projects/:id/join-requests
+func (h *JoinRequestHandler) Create(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req dto.CreateJoinRequestRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	jr, err := h.service.Request(c.Request().Context(), claims.UserID, projectID, req.Message)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusCreated, jr)
+}
+
+// GET /projects/:id/join-requests, the open ones
+func (h *JoinRequestHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	pending, err := h.service.List(c.Request().Context(), claims.UserID, projectID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	if pending == nil {
+		pending = []invitations.JoinRequest{}
+	}
+	return c.JSON(http.StatusOK, pending)
+}
+
+// POST /projects/:id/join-requests/:request_id/approve
+func (h *JoinRequestHandler) Approve(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, requestID, err := joinRequestParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	var req dto.ApproveJoinRequestRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	jr, err := h.service.Approve(c.Request().Context(), claims.UserID, projectID, requestID, req.Role)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, jr)
+}
+
+// POST /projects/:id/join-requests/:request_id/reject
+func (h *JoinRequestHandler) Reject(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	projectID, requestID, err := joinRequestParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	jr, err := h.service.Reject(c.Request().Context(), claims.UserID, projectID, requestID)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, jr)
+}
+
+func joinRequestParams(c echo.Context) (int64, int64, error) {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid project id")
+	}
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid join request id")
+	}
+	return projectID, requestID, nil
+}
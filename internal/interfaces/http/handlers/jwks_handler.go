@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+)
+
+type JWKSHandler struct {
+	jwt *auth.JWTManager
+}
+
+func NewJWKSHandler(jwt *auth.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwt: jwt}
+}
+
+// GET /.well-known/jwks.json
+// public keys for verifying our access tokens offline, no auth needed
+func (h *JWKSHandler) Keys(c echo.Context) error {
+	// short cache, new keys are published well before they start signing
+	c.Response().Header().Set("Cache-Control", auth.JWKSCacheControl)
+	return c.JSON(http.StatusOK, h.jwt.JWKS())
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// OrganizationHandler manages organizations and who is in them
+type OrganizationHandler struct {
+	service *organizations.Service
+}
+
+func NewOrganizationHandler(service *organizations.Service) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+// POST /organizations
+func (h *OrganizationHandler) Create(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.CreateOrganizationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	org, err := h.service.Create(c.Request().Context(), claims.UserID, req.Name)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(http.StatusCreated, org)
+}
+
+// GET /organizations, the ones the user is in
+func (h *OrganizationHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgs, err := h.service.ListMine(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	if orgs == nil {
+		orgs = []organizations.Organization{}
+	}
+	return c.JSON(http.StatusOK, orgs)
+}
+
+// GET /organizations/:id/members
+func (h *OrganizationHandler) ListMembers(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid organization id"})
+	}
+
+	members, err := h.service.ListMembers(c.Request().Context(), claims.UserID, orgID)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	if members == nil {
+		members = []organizations.Member{}
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// POST /organizations/:id/invitations
+// mails an invitation, answered the same whether or not the address has an account
+func (h *OrganizationHandler) Invite(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid organization id"})
+	}
+
+	var req dto.InviteOrganizationMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	if err := h.service.Invite(c.Request().Context(), claims.UserID, orgID, req.Email, req.Role); err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "invitation sent"})
+}
+
+// POST /organizations/invitations/accept
+// the invited person, signed in with the invited address, takes the invitation
+func (h *OrganizationHandler) AcceptInvitation(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "token is required"})
+	}
+
+	org, err := h.service.AcceptInvitation(c.Request().Context(), req.Token, claims.UserID)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(http.StatusOK, org)
+}
+
+// PUT /organizations/:id/members/:user_id/role
+func (h *OrganizationHandler) ChangeRole(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid organization id"})
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	var req dto.ChangeOrganizationRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	if err := h.service.ChangeRole(c.Request().Context(), claims.UserID, orgID, userID, req.Role); err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"message": "role updated"})
+}
+
+// DELETE /organizations/:id/members/:user_id, also how members leave
+func (h *OrganizationHandler) RemoveMember(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid organization id"})
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	if err := h.service.RemoveMember(c.Request().Context(), claims.UserID, orgID, userID); err != nil {
+		return organizationError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func organizationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, organizations.ErrNotFound), errors.Is(err, organizations.ErrMemberNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, organizations.ErrNotMember), errors.Is(err, organizations.ErrNotAdmin),
+		errors.Is(err, organizations.ErrInvitationMismatch), errors.Is(err, organizations.ErrAccountNotActivated):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case errors.Is(err, organizations.ErrInvalidName), errors.Is(err, organizations.ErrInvalidRole),
+		errors.Is(err, organizations.ErrInvalidEmail), errors.Is(err, organizations.ErrInvalidInvitation):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, organizations.ErrLastAdmin), errors.Is(err, organizations.ErrOwnsProjects):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+}
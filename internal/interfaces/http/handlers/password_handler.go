@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+type PasswordHandler struct {
+	service *user.PasswordResetService
+	policy  *user.PasswordPolicy
+}
+
+func NewPasswordHandler(service *user.PasswordResetService) *PasswordHandler {
+	return &PasswordHandler{service: service, policy: user.DefaultPasswordPolicy()}
+}
+
+// UsePasswordPolicy replaces the default policy new passwords are checked against
+func (h *PasswordHandler) UsePasswordPolicy(p *user.PasswordPolicy) {
+	h.policy = p
+}
+
+// POST /password/forgot
+func (h *PasswordHandler) Forgot(c echo.Context) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "email is required"})
+	}
+
+	// same answer whether the account exists or not, failures only go to the log
+	if err := h.service.RequestReset(c.Request().Context(), req.Email); err != nil {
+		log.Printf("password reset request failed: %v", err)
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "if that account exists, a reset link is on its way"})
+}
+
+// POST /password/reset
+func (h *PasswordHandler) Reset(c echo.Context) error {
+	var req dto.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	if req.Token == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "token and password are required"})
+	}
+
+	email, err := h.service.ResetEmail(c.Request().Context(), req.Token)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": user.ErrInvalidResetToken.Error()})
+	}
+	if err := h.policy.Validate(req.Password, email); err != nil {
+		return passwordRejected(c, err)
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to hash password"})
+	}
+
+	if err := h.service.ResetPassword(c.Request().Context(), req.Token, hash); err != nil {
+		if err == user.ErrInvalidResetToken {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to reset password"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "password updated, please log in again"})
+}
+
+// passwordRejected answers a failed policy check with every violation, so
+// the client can show them all next to the field
+func passwordRejected(c echo.Context, err error) error {
+	var policyErr *user.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":      "password does not meet the password policy",
+			"violations": policyErr.Violations,
+		})
+	}
+	return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+}
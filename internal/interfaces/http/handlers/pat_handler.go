@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// PATHandler lets users manage their own personal access tokens
+type PATHandler struct {
+	service *pats.Service
+}
+
+func NewPATHandler(service *pats.Service) *PATHandler {
+	return &PATHandler{service: service}
+}
+
+// POST /tokens
+func (h *PATHandler) Create(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.CreatePersonalAccessTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	raw, token, err := h.service.Create(c.Request().Context(), claims.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return patError(c, err)
+	}
+	return c.JSON(http.StatusCreated, dto.CreatePersonalAccessTokenResponse{Token: raw, Info: token})
+}
+
+// GET /tokens
+func (h *PATHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	list, err := h.service.List(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return patError(c, err)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// DELETE /tokens/:id
+func (h *PATHandler) Revoke(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid token id"})
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), claims.UserID, id); err != nil {
+		return patError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func patError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, pats.ErrTokenNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, pats.ErrInvalidScope), errors.Is(err, pats.ErrNoScopes),
+		errors.Is(err, pats.ErrInvalidName), errors.Is(err, pats.ErrInvalidExpiry):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+}
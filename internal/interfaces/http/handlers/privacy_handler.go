@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/privacy"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+type PrivacyHandler struct {
+	service *privacy.Service
+}
+
+func NewPrivacyHandler(service *privacy.Service) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+// GET /me/export
+// served as a download so browsers save it instead of rendering it
+func (h *PrivacyHandler) Export(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	export, err := h.service.Export(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	filename := fmt.Sprintf("playingfield-export-%d.json", claims.UserID)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.JSONPretty(http.StatusOK, export, "  ")
+}
+
+// DELETE /me
+func (h *PrivacyHandler) DeleteAccount(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	erasure, err := h.service.DeleteAccount(c.Request().Context(), claims.UserID, claims.SessionID, req.Password)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	resp := dto.DeleteAccountResponse{
+		TransferredProjects: erasure.TransferredProjects,
+		DeletedProjects:     erasure.DeletedProjects,
+	}
+	if resp.DeletedProjects == nil {
+		resp.DeletedProjects = []int64{}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func privacyError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, privacy.ErrAccountNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, privacy.ErrConfirmationFailed), errors.Is(err, privacy.ErrRecentSignInRequired):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "internal server error"})
+	}
+}
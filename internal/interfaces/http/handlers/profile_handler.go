@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+)
+
+// ProfileHandler serves the user's own profile and everybody else's
+type ProfileHandler struct {
+	service *profiles.Service
+	orgs    *organizations.Service
+}
+
+func NewProfileHandler(service *profiles.Service) *ProfileHandler {
+	return &ProfileHandler{service: service}
+}
+
+// UseOrganizations limits profile lookups to people sharing an organization
+func (h *ProfileHandler) UseOrganizations(orgs *organizations.Service) {
+	h.orgs = orgs
+}
+
+// GET /me/profile
+func (h *ProfileHandler) GetMine(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	p, err := h.service.Get(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// PUT /me/profile, fields left out of the body stay as they are
+func (h *ProfileHandler) UpdateMine(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req profiles.Update
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	p, err := h.service.Update(c.Request().Context(), claims.UserID, req)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// PUT /me/profile/avatar, multipart with the image in the "avatar" field
+func (h *ProfileHandler) UploadAvatar(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "avatar file is required"})
+	}
+	if file.Size > profiles.MaxAvatarBytes {
+		return profileError(c, profiles.ErrAvatarTooLarge)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid avatar file"})
+	}
+	defer src.Close()
+
+	// one byte over the limit is enough to tell it's too large
+	data, err := io.ReadAll(io.LimitReader(src, profiles.MaxAvatarBytes+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid avatar file"})
+	}
+
+	p, err := h.service.SetAvatarImage(c.Request().Context(), claims.UserID, data)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// DELETE /me/profile/avatar
+func (h *ProfileHandler) RemoveAvatar(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	p, err := h.service.RemoveAvatar(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// GET /users/:id/profile
+func (h *ProfileHandler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	// people outside the viewer's organizations look like they don't exist
+	if h.orgs != nil {
+		claims, ok := c.Get("user").(*auth.Claims)
+		if !ok || claims == nil {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+		}
+		visible, err := h.orgs.CanSee(c.Request().Context(), claims.UserID, id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		}
+		if !visible {
+			return profileError(c, profiles.ErrProfileNotFound)
+		}
+	}
+
+	p, err := h.service.Get(c.Request().Context(), id)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// GET /users/:id/avatar, public so it works in a plain <img src>
+func (h *ProfileHandler) Avatar(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	a, err := h.service.Avatar(c.Request().Context(), id)
+	if err != nil {
+		return profileError(c, err)
+	}
+	// urls carry a version, a new upload gets a new url
+	c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Blob(http.StatusOK, a.ContentType, a.Data)
+}
+
+func profileError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, profiles.ErrProfileNotFound), errors.Is(err, profiles.ErrAvatarNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, profiles.ErrInvalidProfile), errors.Is(err, profiles.ErrInvalidAvatar):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, profiles.ErrAvatarTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
 	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 	"github.com/nelfander/Playingfield/internal/infrastructure/postgres/sqlc"
 )
@@ -21,6 +25,26 @@ type AddUserToProjectRequest struct {
 	Role      string `json:"role"`
 }
 
+type ChangeMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type TransferProjectRequest struct {
+	NewOwnerID int64 `json:"new_owner_id"`
+}
+
+type CloneProjectRequest struct {
+	Name                string `json:"name"`
+	IncludeTasks        bool   `json:"include_tasks"`
+	IncludeMembers      bool   `json:"include_members"`
+	IncludeDescriptions bool   `json:"include_descriptions"`
+}
+
+type SaveTemplateRequest struct {
+	Name           string `json:"name"`
+	IncludeMembers bool   `json:"include_members"`
+}
+
 type ProjectUserResponse struct {
 	ID        int64  `json:"id"`
 	ProjectID int64  `json:"project_id"`
@@ -47,6 +71,8 @@ func (h *ProjectHandler) Create(c echo.Context) error {
 		Name           string `json:"name"`
 		Description    string `json:"description"`
 		AssignedUserID string `json:"assigned_user_id"` // matches React frontend
+		TemplateID     int64  `json:"template_id"`
+		OrganizationID int64  `json:"organization_id"` // optional for people in one organization
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -59,18 +85,27 @@ func (h *ProjectHandler) Create(c echo.Context) error {
 	}
 	ownerID := claims.UserID
 
-	project, err := h.service.CreateProject(c.Request().Context(), req.Name, req.Description, ownerID)
+	project, err := h.service.CreateProject(c.Request().Context(), req.OrganizationID, req.Name, req.Description, ownerID, req.TemplateID)
 	if err != nil {
+		if errors.Is(err, organizations.ErrNoOrganization) || errors.Is(err, organizations.ErrChooseOrganization) {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+		if errors.Is(err, projects.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+		}
 		if strings.Contains(err.Error(), "already have a project with the name") {
 			return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
 		}
+		if errors.Is(err, projects.ErrTemplateNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create project"})
 	}
 
 	if req.AssignedUserID != "" {
 		targetUserID, parseErr := strconv.ParseInt(req.AssignedUserID, 10, 64)
 		if parseErr == nil {
-			_ = h.service.AddUserToProject(c.Request().Context(), 0, project.ID, targetUserID, "member")
+			_ = h.service.AddUserToProject(c.Request().Context(), ownerID, project.ID, targetUserID, projects.RoleMember)
 		}
 	}
 
@@ -101,10 +136,10 @@ func (h *ProjectHandler) Update(c echo.Context) error {
 	// call the Service
 	updatedProject, err := h.service.UpdateProject(c.Request().Context(), requesterID, projectID, req.Name, req.Description)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return echo.NewHTTPError(http.StatusForbidden, err.Error())
 		}
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, projects.ErrProjectNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
@@ -120,8 +155,19 @@ func (h *ProjectHandler) List(c echo.Context) error {
 	}
 
 	currentUserID := claims.UserID
+	// archived projects are left out unless asked for
+	includeArchived := c.QueryParam("include_archived") == "true"
+
+	var orgID int64
+	if raw := c.QueryParam("organization_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid organization_id"})
+		}
+		orgID = id
+	}
 
-	projects, err := h.service.ListProjects(c.Request().Context(), currentUserID)
+	projects, err := h.service.ListProjects(c.Request().Context(), currentUserID, orgID, includeArchived)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch projects"})
 	}
@@ -143,12 +189,80 @@ func (h *ProjectHandler) DeleteProject(c echo.Context) error {
 
 	err = h.service.DeleteProject(c.Request().Context(), projectID, userID)
 	if err != nil {
-		return c.JSON(http.StatusForbidden, echo.Map{"error": "You do not have permission to delete this project"})
+		return policyError(c, err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// POST /projects/:id/archive
+func (h *ProjectHandler) Archive(c echo.Context) error {
+	return h.setArchived(c, true)
+}
+
+// POST /projects/:id/unarchive
+func (h *ProjectHandler) Unarchive(c echo.Context) error {
+	return h.setArchived(c, false)
+}
+
+func (h *ProjectHandler) setArchived(c echo.Context, archived bool) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	if archived {
+		err = h.service.ArchiveProject(c.Request().Context(), claims.UserID, projectID)
+	} else {
+		err = h.service.UnarchiveProject(c.Request().Context(), claims.UserID, projectID)
+	}
+	if err != nil {
+		return policyError(c, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"project_id": projectID, "archived": archived})
+}
+
+// GET /projects/deleted
+func (h *ProjectHandler) ListDeleted(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	deleted, err := h.service.ListDeletedProjects(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch deleted projects"})
+	}
+	return c.JSON(http.StatusOK, deleted)
+}
+
+// POST /projects/:id/restore
+func (h *ProjectHandler) Restore(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	err = h.service.RestoreProject(c.Request().Context(), claims.UserID, projectID)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, echo.Map{"project_id": projectID, "restored": true})
+	case errors.Is(err, projects.ErrNotRestorable):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to restore project"})
+}
+
 func (h *ProjectHandler) AddUserToProject(c echo.Context) error {
 	var req AddUserToProjectRequest
 	if err := c.Bind(&req); err != nil {
@@ -168,8 +282,11 @@ func (h *ProjectHandler) AddUserToProject(c echo.Context) error {
 		if strings.Contains(err.Error(), "already a member") {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
+		if errors.Is(err, projects.ErrInvalidRole) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		//  if authorization error return 403 Forbidden
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
 		}
 		// otherwise return 500
@@ -191,30 +308,200 @@ func (h *ProjectHandler) ListUsersInProject(c echo.Context) error {
 		return c.JSON(400, map[string]string{"error": "invalid project_id"})
 	}
 
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
 	// call the service
-	users, err := h.service.ListUsersInProject(c.Request().Context(), projectID)
+	users, err := h.service.ListUsersInProject(c.Request().Context(), claims.UserID, projectID)
 	if err != nil {
-		return c.JSON(500, map[string]string{"error": err.Error()})
+		return policyError(c, err)
 	}
 
 	// convert to JSON-friendly response
 	type UserResponse struct {
-		ID    int64  `json:"id"`
-		Email string `json:"email"`
-		Role  string `json:"role"`
+		ID      int64              `json:"id"`
+		Profile profiles.Summary   `json:"profile"`
+		Role    string             `json:"role"`
+		Direct  bool               `json:"direct"`
+		Teams   []projects.TeamRef `json:"teams,omitempty"`
 	}
 
 	var resp []UserResponse
 	for _, u := range users {
 		resp = append(resp, UserResponse{
-			ID:    u.ID,
-			Email: u.Email,
-			Role:  u.Role,
+			ID:      u.ID,
+			Profile: u.Profile,
+			Role:    u.Role,
+			Direct:  u.Direct,
+			Teams:   u.Teams,
 		})
 	}
 	return c.JSON(200, resp)
 }
 
+// PUT /projects/:id/members/:user_id/role
+func (h *ProjectHandler) ChangeMemberRole(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	var req ChangeMemberRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	err = h.service.ChangeMemberRole(c.Request().Context(), claims.UserID, projectID, userID, req.Role)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, echo.Map{"user_id": userID, "role": req.Role})
+	case errors.Is(err, projects.ErrInvalidRole), errors.Is(err, projects.ErrOwnerRole):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrMemberNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrThroughTeam):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrForbidden):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrProjectNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "project not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to change role"})
+}
+
+// POST /projects/:id/transfer
+func (h *ProjectHandler) TransferOwnership(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req TransferProjectRequest
+	if err := c.Bind(&req); err != nil || req.NewOwnerID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "new_owner_id is required"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+	systemAdmin := claims.Role == user.RoleAdmin
+
+	project, err := h.service.TransferOwnership(c.Request().Context(), claims.UserID, systemAdmin, projectID, req.NewOwnerID)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, project)
+	case errors.Is(err, projects.ErrMemberNotFound), errors.Is(err, projects.ErrAlreadyOwner):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrOwnerChanged), errors.Is(err, projects.ErrThroughTeam):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	return policyError(c, err)
+}
+
+// POST /projects/:id/clone
+func (h *ProjectHandler) Clone(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req CloneProjectRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	project, err := h.service.CloneProject(c.Request().Context(), claims.UserID, projectID, projects.CloneOptions{
+		Name:         req.Name,
+		Tasks:        req.IncludeTasks,
+		Members:      req.IncludeMembers,
+		Descriptions: req.IncludeDescriptions,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "already have a project with the name") {
+			return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+		}
+		return policyError(c, err)
+	}
+	return c.JSON(http.StatusCreated, project)
+}
+
+// POST /projects/:id/templates
+func (h *ProjectHandler) SaveTemplate(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req SaveTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	template, err := h.service.SaveTemplate(c.Request().Context(), claims.UserID, projectID, req.Name, req.IncludeMembers)
+	if err != nil {
+		return policyError(c, err)
+	}
+	return c.JSON(http.StatusCreated, template)
+}
+
+// GET /projects/templates
+func (h *ProjectHandler) ListTemplates(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	templates, err := h.service.ListTemplates(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch templates"})
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+// DELETE /projects/templates/:template_id
+func (h *ProjectHandler) DeleteTemplate(c echo.Context) error {
+	templateID, err := strconv.ParseInt(c.Param("template_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid template id"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	err = h.service.DeleteTemplate(c.Request().Context(), claims.UserID, templateID)
+	switch {
+	case err == nil:
+		return c.NoContent(http.StatusNoContent)
+	case errors.Is(err, projects.ErrTemplateNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete template"})
+}
+
 func (h *ProjectHandler) RemoveUserFromProject(c echo.Context) error {
 
 	type RemoveUserRequest struct {
@@ -234,6 +521,9 @@ func (h *ProjectHandler) RemoveUserFromProject(c echo.Context) error {
 	requesterID := claims.UserID
 
 	err := h.service.RemoveUserFromProject(requesterID, req.ProjectID, req.UserID)
+	if errors.Is(err, projects.ErrThroughTeam) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(403, map[string]string{"error": err.Error()})
 	}
@@ -254,10 +544,21 @@ func (h *ProjectHandler) GetByID(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
 	}
 
-	project, err := h.service.GetProject(c.Request().Context(), id)
+	project, err := h.service.GetProject(c.Request().Context(), claims.UserID, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, echo.Map{"error": "project not found"})
+		return policyError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, project)
 }
+
+// policyError answers a refused project check, or a project that isn't there
+func policyError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, projects.ErrForbidden):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case errors.Is(err, projects.ErrProjectNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "project not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+}
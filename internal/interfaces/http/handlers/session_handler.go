@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// SessionHandler shows users where they're logged in and lets them end
+// a session, e.g. of a lost laptop
+type SessionHandler struct {
+	tokens *tokens.Service
+	auth   *auth.JWTManager
+	hub    *ws.Hub
+}
+
+func NewSessionHandler(tokens *tokens.Service, auth *auth.JWTManager, hub *ws.Hub) *SessionHandler {
+	return &SessionHandler{tokens: tokens, auth: auth, hub: hub}
+}
+
+// GET /me/sessions
+func (h *SessionHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	sessions, err := h.tokens.Sessions(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list sessions"})
+	}
+
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, dto.SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IPAddress,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.ID == claims.SessionID,
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /me/sessions/:id
+// ends the refresh token family, the access tokens and the open sockets of the session
+func (h *SessionHandler) Revoke(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid session id"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.tokens.RevokeSession(ctx, claims.UserID, id); err != nil {
+		if errors.Is(err, tokens.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to revoke session"})
+	}
+
+	if err := h.auth.RevokeSession(ctx, claims.UserID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to revoke session"})
+	}
+	if h.hub != nil {
+		h.hub.DisconnectSession(id, "session revoked")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deviceOf is what a session remembers about the client
+func deviceOf(c echo.Context) tokens.Device {
+	return tokens.Device{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
+}
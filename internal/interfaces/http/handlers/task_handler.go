@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
 	"github.com/nelfander/Playingfield/internal/domain/tasks"
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 )
@@ -47,7 +48,7 @@ func (h *TaskHandler) CreateTask(c echo.Context) error {
 
 	created, err := h.service.CreateTask(c.Request().Context(), claims.UserID, task)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
 		}
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
@@ -87,7 +88,7 @@ func (h *TaskHandler) UpdateTask(c echo.Context) error {
 
 	updated, err := h.service.UpdateTask(c.Request().Context(), claims.UserID, task, req.Message)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
 		}
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
@@ -107,9 +108,12 @@ func (h *TaskHandler) ListTaskByProject(c echo.Context) error {
 
 	list, err := h.service.ListTasks(c.Request().Context(), claims.UserID, projectID)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
 		}
+		if errors.Is(err, projects.ErrProjectNotFound) {
+			return c.JSON(http.StatusNotFound, echo.Map{"error": "project not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch tasks"})
 	}
 
@@ -144,7 +148,7 @@ func (h *TaskHandler) GetTaskHistory(c echo.Context) error {
 
 	history, err := h.service.GetTaskHistory(c.Request().Context(), claims.UserID, taskID)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, projects.ErrForbidden) {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
 		}
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch history"})
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/teams"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// TeamHandler manages teams and the projects they are on
+type TeamHandler struct {
+	service *teams.Service
+}
+
+func NewTeamHandler(service *teams.Service) *TeamHandler {
+	return &TeamHandler{service: service}
+}
+
+// POST /teams
+func (h *TeamHandler) Create(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.CreateTeamRequest
+	if err := c.Bind(&req); err != nil || req.OrganizationID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "organization_id and name are required"})
+	}
+
+	team, err := h.service.Create(c.Request().Context(), claims.UserID, req.OrganizationID, req.Name)
+	if err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(http.StatusCreated, team)
+}
+
+// GET /teams?organization_id=
+func (h *TeamHandler) List(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	orgID, err := strconv.ParseInt(c.QueryParam("organization_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "organization_id is required"})
+	}
+
+	list, err := h.service.List(c.Request().Context(), claims.UserID, orgID)
+	if err != nil {
+		return teamError(c, err)
+	}
+	if list == nil {
+		list = []teams.Team{}
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GET /teams/:id, with its members
+func (h *TeamHandler) Get(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid team id"})
+	}
+
+	team, err := h.service.Get(c.Request().Context(), claims.UserID, teamID)
+	if err != nil {
+		return teamError(c, err)
+	}
+	if team.Members == nil {
+		team.Members = []teams.Member{}
+	}
+	return c.JSON(http.StatusOK, team)
+}
+
+// DELETE /teams/:id
+func (h *TeamHandler) Delete(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid team id"})
+	}
+
+	if err := h.service.Delete(c.Request().Context(), claims.UserID, teamID); err != nil {
+		return teamError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /teams/:id/members
+func (h *TeamHandler) AddMember(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid team id"})
+	}
+
+	var req dto.AddTeamMemberRequest
+	if err := c.Bind(&req); err != nil || req.UserID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "user_id is required"})
+	}
+
+	if err := h.service.AddMember(c.Request().Context(), claims.UserID, teamID, req.UserID); err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(http.StatusCreated, echo.Map{"team_id": teamID, "user_id": req.UserID})
+}
+
+// DELETE /teams/:id/members/:user_id, also how members leave
+func (h *TeamHandler) RemoveMember(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid team id"})
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid user id"})
+	}
+
+	if err := h.service.RemoveMember(c.Request().Context(), claims.UserID, teamID, userID); err != nil {
+		return teamError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /projects/:id/teams
+func (h *TeamHandler) AddToProject(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	var req dto.AddProjectTeamRequest
+	if err := c.Bind(&req); err != nil || req.TeamID == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "team_id is required"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	role := req.Role
+	if role == "" {
+		role = projects.RoleMember
+	}
+	if err := h.service.AddToProject(c.Request().Context(), claims.UserID, projectID, req.TeamID, role); err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(http.StatusCreated, echo.Map{"project_id": projectID, "team_id": req.TeamID, "role": role})
+}
+
+// GET /projects/:id/teams
+func (h *TeamHandler) ListForProject(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	list, err := h.service.ListForProject(c.Request().Context(), claims.UserID, projectID)
+	if err != nil {
+		return teamError(c, err)
+	}
+	if list == nil {
+		list = []teams.ProjectTeam{}
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// DELETE /projects/:id/teams/:team_id
+func (h *TeamHandler) RemoveFromProject(c echo.Context) error {
+	projectID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid project id"})
+	}
+	teamID, err := strconv.ParseInt(c.Param("team_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid team id"})
+	}
+
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	if err := h.service.RemoveFromProject(c.Request().Context(), claims.UserID, projectID, teamID); err != nil {
+		return teamError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func teamError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, teams.ErrNotFound), errors.Is(err, teams.ErrMemberNotFound), errors.Is(err, teams.ErrNotOnProject):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, teams.ErrNotInOrganization), errors.Is(err, teams.ErrNotTeamManager):
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case errors.Is(err, teams.ErrInvalidName), errors.Is(err, teams.ErrOutsider),
+		errors.Is(err, teams.ErrOtherOrganization), errors.Is(err, projects.ErrInvalidRole):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case errors.Is(err, teams.ErrNameTaken), errors.Is(err, teams.ErrAlreadyMember), errors.Is(err, teams.ErrAlreadyOnProject):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	return policyError(c, err)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+)
+
+// TwoFactorHandler manages the logged in user's own 2fa setup
+type TwoFactorHandler struct {
+	service *user.TwoFactorService
+}
+
+func NewTwoFactorHandler(service *user.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{service: service}
+}
+
+// POST /2fa/enroll
+// returns the secret, otpauth uri and recovery codes, 2fa stays off until confirmed
+func (h *TwoFactorHandler) Enroll(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	enrollment, err := h.service.Enroll(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// POST /2fa/confirm
+func (h *TwoFactorHandler) Confirm(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "code is required"})
+	}
+
+	if err := h.service.Confirm(c.Request().Context(), claims.UserID, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DELETE /2fa
+func (h *TwoFactorHandler) Disable(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "code is required"})
+	}
+
+	if err := h.service.Disable(c.Request().Context(), claims.UserID, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /2fa/recovery-codes
+// old codes stop working, the new ones are only shown in this response
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "code is required"})
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request().Context(), claims.UserID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"recovery_codes": codes})
+}
+
+func twoFactorError(c echo.Context, err error) error {
+	switch err {
+	case user.ErrUserNotFound:
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case user.ErrInvalidTwoFactorCode:
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+	case user.ErrTwoFactorRequired:
+		return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+	case user.ErrTwoFactorAlreadyEnabled, user.ErrTwoFactorNotEnabled:
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+}
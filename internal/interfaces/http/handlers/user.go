@@ -2,18 +2,34 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
 	"github.com/nelfander/Playingfield/internal/domain/user"
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
 )
 
 type UserHandler struct {
-	service user.Service
-	auth    *auth.JWTManager
+	service      user.Service
+	auth         *auth.JWTManager
+	tokens       *tokens.Service
+	verification *user.VerificationService
+	twoFactor    *user.TwoFactorService
+	throttle     *auth.LoginThrottle
+	oidc         *user.OIDCService
+	policy       *user.PasswordPolicy
+	invitations  *invitations.Service
+	pats         *pats.Service
 }
 
 // for test purposes
@@ -26,8 +42,47 @@ func (h *UserHandler) GenerateTokenForTest(id int64, email, role string) (string
 	return h.auth.GenerateToken(id, email, role)
 }
 
-func NewUserHandler(service user.Service, auth *auth.JWTManager) *UserHandler {
-	return &UserHandler{service: service, auth: auth}
+func NewUserHandler(
+	service user.Service,
+	auth *auth.JWTManager,
+	tokens *tokens.Service,
+	verification *user.VerificationService,
+	twoFactor *user.TwoFactorService,
+) *UserHandler {
+	return &UserHandler{
+		service:      service,
+		auth:         auth,
+		tokens:       tokens,
+		verification: verification,
+		twoFactor:    twoFactor,
+		policy:       user.DefaultPasswordPolicy(),
+	}
+}
+
+// UsePasswordPolicy replaces the default policy passwords are checked against at registration
+func (h *UserHandler) UsePasswordPolicy(p *user.PasswordPolicy) {
+	h.policy = p
+}
+
+// UseLoginThrottle turns on brute-force protection for /login and /login/2fa
+func (h *UserHandler) UseLoginThrottle(t *auth.LoginThrottle) {
+	h.throttle = t
+}
+
+// UseOIDC turns on sign-in through an external identity provider
+func (h *UserHandler) UseOIDC(s *user.OIDCService) {
+	h.oidc = s
+}
+
+// UsePersonalAccessTokens makes /logout/all revoke personal access tokens too
+func (h *UserHandler) UsePersonalAccessTokens(p *pats.Service) {
+	h.pats = p
+}
+
+// UseInvitations makes every login and email verification turn open
+// project invitations for the user's address into memberships
+func (h *UserHandler) UseInvitations(s *invitations.Service) {
+	h.invitations = s
 }
 
 // register handles POST /users
@@ -36,6 +91,12 @@ func (h *UserHandler) Register(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
 	}
+	if req.Email == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "email is required"})
+	}
+	if err := h.policy.Validate(req.Password, req.Email); err != nil {
+		return passwordRejected(c, err)
+	}
 
 	hash, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -50,6 +111,11 @@ func (h *UserHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "internal error"})
 	}
 
+	// the account exists either way, a failed mail can be resent by an admin
+	if err := h.verification.SendVerification(c.Request().Context(), u); err != nil {
+		log.Printf("failed to send verification mail to user %d: %v", u.ID, err)
+	}
+
 	resp := dto.UserResponse{
 		ID:        u.ID,
 		Email:     u.Email,
@@ -68,26 +134,201 @@ func (h *UserHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
 	}
 
+	// locked out callers don't even get a password check
+	ip := c.RealIP()
+	if h.throttle != nil {
+		if wait := h.throttle.Check(req.Email, ip); wait > 0 {
+			return tooManyAttempts(c, wait)
+		}
+	}
+
 	// call domain service
 	u, err := h.service.Login(c.Request().Context(), req.Email, req.Password)
 	if err != nil {
-		if err == user.ErrInactiveAccount {
+		if err == user.ErrInactiveAccount || err == user.ErrEmailNotVerified {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
 		}
+		if h.throttle != nil {
+			h.throttle.Fail(req.Email, ip)
+		}
 		// all the other errors (wrong credentials, etc.)
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid credentials"})
 	}
+	if h.throttle != nil {
+		h.throttle.Succeed(req.Email)
+	}
+
+	// password was fine, but the account may need a second factor first
+	challenge, err := h.twoFactor.BeginLogin(c.Request().Context(), u)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "login failed"})
+	}
+	if challenge != nil {
+		return c.JSON(http.StatusOK, dto.TwoFactorChallengeResponse{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: challenge.EnrollmentRequired,
+			ChallengeToken:     challenge.Token,
+		})
+	}
+
+	return h.startSession(c, u)
+}
+
+// LoginTwoFactor handles POST /login/2fa
+// trades the challenge from /login plus a code for the real tokens
+func (h *UserHandler) LoginTwoFactor(c echo.Context) error {
+	var req dto.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "challenge_token and code are required"})
+	}
+
+	// codes are throttled per account as well as per ip, under their own key
+	// so that a fresh password login doesn't clear the count
+	ip := c.RealIP()
+	account := ""
+	if userID, err := h.twoFactor.ChallengeUserID(req.ChallengeToken); err == nil {
+		account = "2fa:" + strconv.FormatInt(userID, 10)
+	}
+	if h.throttle != nil {
+		if wait := h.throttle.Check(account, ip); wait > 0 {
+			return tooManyAttempts(c, wait)
+		}
+	}
+
+	u, err := h.twoFactor.CompleteLogin(c.Request().Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		switch err {
+		case user.ErrInvalidLoginChallenge, user.ErrInvalidTwoFactorCode:
+			if h.throttle != nil {
+				h.throttle.Fail(account, ip)
+			}
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+		case user.ErrInactiveAccount, user.ErrTwoFactorNotEnabled:
+			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "login failed"})
+	}
+	if h.throttle != nil {
+		h.throttle.Succeed(account)
+	}
+
+	return h.startSession(c, u)
+}
+
+// LoginTwoFactorEnroll handles POST /login/2fa/enroll
+// for accounts whose role requires 2fa but that haven't set it up yet
+func (h *UserHandler) LoginTwoFactorEnroll(c echo.Context) error {
+	var req dto.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil || req.ChallengeToken == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "challenge_token is required"})
+	}
+
+	enrollment, err := h.twoFactor.EnrollWithChallenge(c.Request().Context(), req.ChallengeToken)
+	if err != nil {
+		switch err {
+		case user.ErrInvalidLoginChallenge:
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+		case user.ErrInactiveAccount:
+			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+		case user.ErrTwoFactorAlreadyEnabled:
+			return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start enrollment"})
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// oidcStateCookie holds the signed state between the redirect and the callback
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin handles GET /auth/oidc/login
+// sends the browser to the identity provider
+func (h *UserHandler) OIDCLogin(c echo.Context) error {
+	login, err := h.oidc.Begin()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start sign-in"})
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    login.State,
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode, // has to survive the top-level redirect back from the provider
+	})
+	return c.Redirect(http.StatusFound, login.URL)
+}
+
+// OIDCCallback handles GET /auth/oidc/callback
+// the provider redirects here with a code, answered like /login
+func (h *UserHandler) OIDCCallback(c echo.Context) error {
+	if e := c.QueryParam("error"); e != "" {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "identity provider refused sign-in: " + e})
+	}
+
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || c.QueryParam("code") == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": user.ErrInvalidOIDCState.Error()})
+	}
+	// the state is single use
+	c.SetCookie(&http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc", MaxAge: -1})
+
+	u, err := h.oidc.Complete(c.Request().Context(), cookie.Value, c.QueryParam("state"), c.QueryParam("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidOIDCState), errors.Is(err, user.ErrOIDCEmailRequired):
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		case errors.Is(err, user.ErrOIDCLoginFailed):
+			log.Printf("oidc sign-in failed: %v", err)
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": user.ErrOIDCLoginFailed.Error()})
+		case errors.Is(err, user.ErrOIDCEmailNotVerified):
+			return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+		case errors.Is(err, user.ErrInactiveAccount), errors.Is(err, user.ErrEmailNotVerified):
+			return c.JSON(http.StatusForbidden, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "login failed"})
+	}
+
+	// a second factor set up here still counts, the provider doesn't replace it
+	challenge, err := h.twoFactor.BeginLogin(c.Request().Context(), u)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "login failed"})
+	}
+	if challenge != nil {
+		return c.JSON(http.StatusOK, dto.TwoFactorChallengeResponse{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: challenge.EnrollmentRequired,
+			ChallengeToken:     challenge.Token,
+		})
+	}
+
+	return h.startSession(c, u)
+}
+
+// startSession issues the access + refresh token pair once every factor checked out
+func (h *UserHandler) startSession(c echo.Context, u *user.User) error {
+	// a broken invitation mustn't keep anyone from logging in
+	h.acceptInvitations(c.Request().Context(), u)
+
+	// every login starts a new session with its own refresh token family
+	refreshToken, rt, err := h.tokens.Issue(c.Request().Context(), u.ID, deviceOf(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to generate token"})
+	}
 
 	// generate JWT
-	token, err := h.auth.GenerateToken(u.ID, u.Email, u.Role)
+	token, err := h.auth.GenerateSessionToken(u.ID, rt.SessionID, u.Email, u.Role, u.Status)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to generate token"})
 	}
 
 	// map domain User -> DTO
 	resp := dto.LoginResponse{
-		Token:  token,
-		UserId: u.ID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		UserId:       u.ID,
 		User: dto.UserResponse{
 			ID:        u.ID,
 			Email:     u.Email,
@@ -100,6 +341,134 @@ func (h *UserHandler) Login(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+// Refresh handles POST /auth/refresh
+// trades a refresh token for a new access token + a rotated refresh token
+func (h *UserHandler) Refresh(c echo.Context) error {
+	var req dto.RefreshRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "refresh_token is required"})
+	}
+
+	ctx := c.Request().Context()
+	refreshToken, rt, err := h.tokens.Rotate(ctx, req.RefreshToken, deviceOf(c))
+	if err != nil {
+		if err == tokens.ErrInvalidRefreshToken || err == tokens.ErrRefreshTokenReused {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to refresh token"})
+	}
+
+	// re-read the user so role/status changes are picked up on refresh
+	u, err := h.service.GetUser(ctx, rt.UserID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or expired refresh token"})
+	}
+	if u.Status != user.StatusActive {
+		_ = h.tokens.RevokeAll(ctx, u.ID)
+		return c.JSON(http.StatusForbidden, echo.Map{"error": user.ErrInactiveAccount.Error()})
+	}
+
+	token, err := h.auth.GenerateSessionToken(u.ID, rt.SessionID, u.Email, u.Role, u.Status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to generate token"})
+	}
+
+	return c.JSON(http.StatusOK, dto.RefreshResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Logout handles POST /logout
+// revokes the refresh token family of the current session
+func (h *UserHandler) Logout(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	var req dto.RefreshRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "refresh_token is required"})
+	}
+
+	if err := h.tokens.Revoke(c.Request().Context(), claims.UserID, req.RefreshToken); err != nil {
+		if err == tokens.ErrInvalidRefreshToken {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to log out"})
+	}
+
+	// kill the access token too, otherwise it stays valid until it expires
+	if err := h.auth.RevokeToken(c.Request().Context(), claims); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to log out"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// LogoutAll handles POST /logout/all
+// revokes every refresh token family and personal access token the user has
+func (h *UserHandler) LogoutAll(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	if err := h.tokens.RevokeAll(c.Request().Context(), claims.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to log out"})
+	}
+
+	// every access token of this user, not just the one making the request
+	if err := h.auth.RevokeUserTokens(c.Request().Context(), claims.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to log out"})
+	}
+	if h.pats != nil {
+		if err := h.pats.RevokeAll(c.Request().Context(), claims.UserID); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to log out"})
+		}
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// VerifyEmail handles GET /verify-email?token=...
+// this is the link from the verification mail
+func (h *UserHandler) VerifyEmail(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "token is required"})
+	}
+
+	u, err := h.verification.Verify(c.Request().Context(), token)
+	if err != nil {
+		if err == user.ErrInvalidVerificationToken || err == user.ErrAlreadyVerified {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to verify account"})
+	}
+	// the address is proven now, its invitations are safe to turn into memberships
+	h.acceptInvitations(c.Request().Context(), u)
+
+	return c.JSON(http.StatusOK, dto.UserResponse{
+		ID:        u.ID,
+		Email:     u.Email,
+		Role:      u.Role,
+		Status:    u.Status,
+		CreatedAt: u.CreatedAt,
+	})
+}
+
+// acceptInvitations only logs failures, the caller goes ahead either way
+func (h *UserHandler) acceptInvitations(ctx context.Context, u *user.User) {
+	if h.invitations == nil {
+		return
+	}
+	if _, err := h.invitations.AcceptPending(ctx, u); err != nil {
+		log.Printf("failed to accept invitations for user %d: %v", u.ID, err)
+	}
+}
+
 // Me handles GET /me
 func (h *UserHandler) Me(c echo.Context) error {
 	// grab claims from context (set by JWT middleware)
@@ -127,10 +496,25 @@ func (h *UserHandler) Admin(c echo.Context) error {
 
 // GET /users
 func (h *UserHandler) List(c echo.Context) error {
-	users, err := h.service.ListAllUsers(c.Request().Context())
+	claims, ok := c.Get("user").(*auth.Claims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "unauthorized"})
+	}
+
+	users, err := h.service.ListUsers(c.Request().Context(), claims.UserID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to fetch users"})
 	}
 
 	return c.JSON(http.StatusOK, users)
 }
+
+// tooManyAttempts answers a throttled login, Retry-After is in whole seconds
+func tooManyAttempts(c echo.Context, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	return c.JSON(http.StatusTooManyRequests, echo.Map{
+		"error":       "too many failed login attempts, try again later",
+		"retry_after": seconds,
+	})
+}
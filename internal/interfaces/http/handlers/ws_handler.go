@@ -62,6 +62,16 @@ func (h *WSHandler) HandleConnection(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"})
 	}
+	// listening is reading chat, sending is checked per message below
+	if !claims.HasScope("chat:read") {
+		return c.JSON(http.StatusForbidden, map[string]string{"message": "personal access token lacks the chat:read scope"})
+	}
+	// the room carries the project's chat, same rule as reading its history
+	if projectID != 0 {
+		if err := h.chatService.CanJoinProjectRoom(c.Request().Context(), claims.UserID, projectID); err != nil {
+			return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+		}
+	}
 
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
@@ -70,7 +80,7 @@ func (h *WSHandler) HandleConnection(c echo.Context) error {
 	}
 
 	// include the ProjectID so the Hub knows where to route messages
-	client := ws.NewClient(claims.UserID, projectID, conn)
+	client := ws.NewClient(claims.UserID, claims.SessionID, projectID, conn)
 
 	h.hub.Register <- client
 
@@ -111,6 +121,11 @@ func (h *WSHandler) HandleConnection(c echo.Context) error {
 			continue
 		}
 
+		if !claims.HasScope("chat:write") {
+			h.sendWSError(conn, "personal access token lacks the chat:write scope")
+			continue
+		}
+
 		ctx := context.Background()
 		var chatErr error
 
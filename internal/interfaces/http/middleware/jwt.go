@@ -8,7 +8,7 @@ import (
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 )
 
-// JWTMiddleware verifies a JWT token and stores claims in context
+// JWTMiddleware verifies a JWT (or a personal access token) and stores claims in context
 func JWTMiddleware(jwtManager *auth.JWTManager) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -23,10 +23,21 @@ func JWTMiddleware(jwtManager *auth.JWTManager) echo.MiddlewareFunc {
 			}
 
 			claims, err := jwtManager.VerifyToken(parts[1])
+			if err == auth.ErrAccountInactive {
+				return c.JSON(http.StatusForbidden, map[string]string{"message": "account is not active"})
+			}
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"})
 			}
 
+			// personal access tokens only get into routes their scopes cover
+			if claims.PersonalToken {
+				scope, ok := RouteScopes[c.Request().Method+" "+c.Path()]
+				if !ok || !claims.HasScope(scope) {
+					return c.JSON(http.StatusForbidden, map[string]string{"message": "personal access token lacks the required scope"})
+				}
+			}
+
 			// Store full claims
 			c.Set("user", claims)
 
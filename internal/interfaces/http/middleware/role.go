@@ -19,10 +19,18 @@ func RequireRole(jwtManager *auth.JWTManager, allowedRoles ...string) echo.Middl
 
 			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 			claims, err := jwtManager.VerifyToken(tokenStr)
+			if err == auth.ErrAccountInactive {
+				return c.JSON(http.StatusForbidden, map[string]string{"message": "account is not active"})
+			}
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"})
 			}
 
+			// role protected routes always need a real login
+			if claims.PersonalToken {
+				return c.JSON(http.StatusForbidden, map[string]string{"message": "personal access tokens can't be used here"})
+			}
+
 			for _, role := range allowedRoles {
 				if claims.Role == role {
 					c.Set("user", claims) // store claims for handler
@@ -0,0 +1,27 @@
+package middleware
+
+// RouteScopes says which scope a personal access token needs for a route,
+// keyed by method and route pattern as registered with echo.
+// Anything not listed here can only be used with a real login (JWT).
+var RouteScopes = map[string]string{
+	"GET /projects":          "projects:read",
+	"GET /projects/:id":      "projects:read",
+	"GET /projects/users":    "projects:read",
+	"POST /projects":         "projects:write",
+	"PUT /projects/:id":      "projects:write",
+	"DELETE /projects/:id":   "projects:write",
+	"POST /projects/users":   "projects:write",
+	"DELETE /projects/users": "projects:write",
+
+	"GET /projects/:id/tasks": "tasks:read",
+	"GET /tasks/:id/history":  "tasks:read",
+	"POST /tasks":             "tasks:write",
+	"PUT /tasks/:id":          "tasks:write",
+	"DELETE /tasks/:id":       "tasks:write",
+
+	"GET /projects/:id/messages":     "chat:read",
+	"GET /messages/direct/:other_id": "chat:read",
+	// checked by the websocket handshake itself, which also wants
+	// chat:write for every message sent over the socket
+	"GET /ws": "chat:read",
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -76,3 +77,54 @@ func TestRequireRole_MissingToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
 }
+
+func TestRequireRole_DemotedAdminForbidden(t *testing.T) {
+	e := echo.New()
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseAccountCache(auth.NewAccountCache(func(ctx context.Context, userID int64) (string, string, error) {
+		return "user", "active", nil
+	}, time.Minute))
+	token, _ := jwtManager.GenerateToken(1, "admin@test.com", "admin")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	handler := middleware.RequireRole(jwtManager, "admin")(func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	err := handler(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRequireRole_SuspendedAdminForbidden(t *testing.T) {
+	e := echo.New()
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseAccountCache(auth.NewAccountCache(func(ctx context.Context, userID int64) (string, string, error) {
+		return "admin", "suspended", nil
+	}, time.Minute))
+	token, _ := jwtManager.GenerateToken(1, "admin@test.com", "admin")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	handler := middleware.RequireRole(jwtManager, "admin")(func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	err := handler(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "account is not active")
+}
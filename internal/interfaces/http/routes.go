@@ -1,17 +1,172 @@
 package http
 
 import (
+	stdhttp "net/http"
+
 	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
 	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
 )
 
-func RegisterRoutes(e *echo.Echo, userHandler *handlers.UserHandler) {
-	// Health route
+// Handlers is everything RegisterRoutes mounts
+type Handlers struct {
+	User         *handlers.UserHandler
+	Session      *handlers.SessionHandler
+	TwoFactor    *handlers.TwoFactorHandler
+	PAT          *handlers.PATHandler
+	Profile      *handlers.ProfileHandler
+	Password     *handlers.PasswordHandler
+	Privacy      *handlers.PrivacyHandler
+	Admin        *handlers.AdminHandler
+	JWKS         *handlers.JWKSHandler
+	Organization *handlers.OrganizationHandler
+	Team         *handlers.TeamHandler
+	Project      *handlers.ProjectHandler
+	Invitation   *handlers.InvitationHandler
+	InviteLink   *handlers.InviteLinkHandler
+	JoinRequest  *handlers.JoinRequestHandler
+	Task         *handlers.TaskHandler
+	Chat         *handlers.ChatHandler
+	WS           *handlers.WSHandler
+
+	// the sign-in routes are only mounted when an issuer is configured
+	OIDCEnabled bool
+}
+
+// RegisterRoutes mounts the whole API. The policy test mounts it too,
+// so a project, organization or team route added here without a policy
+// case fails the build.
+func RegisterRoutes(e *echo.Echo, jwtManager *auth.JWTManager, h Handlers) {
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.GET("/me", h.User.Me)
+	authGroup.DELETE("/me", h.Privacy.DeleteAccount)
+	authGroup.GET("/me/export", h.Privacy.Export)
+	authGroup.POST("/logout", h.User.Logout)
+	authGroup.POST("/logout/all", h.User.LogoutAll)
+	authGroup.GET("/users", h.User.List)
+	authGroup.POST("/2fa/enroll", h.TwoFactor.Enroll)
+	authGroup.POST("/2fa/confirm", h.TwoFactor.Confirm)
+	authGroup.DELETE("/2fa", h.TwoFactor.Disable)
+	authGroup.POST("/2fa/recovery-codes", h.TwoFactor.RegenerateRecoveryCodes)
+	authGroup.POST("/tokens", h.PAT.Create)
+	authGroup.GET("/tokens", h.PAT.List)
+	authGroup.DELETE("/tokens/:id", h.PAT.Revoke)
+	authGroup.GET("/me/sessions", h.Session.List)
+	authGroup.DELETE("/me/sessions/:id", h.Session.Revoke)
+	authGroup.GET("/me/profile", h.Profile.GetMine)
+	authGroup.PUT("/me/profile", h.Profile.UpdateMine)
+	authGroup.PUT("/me/profile/avatar", h.Profile.UploadAvatar)
+	authGroup.DELETE("/me/profile/avatar", h.Profile.RemoveAvatar)
+	authGroup.GET("/users/:id/profile", h.Profile.Get)
+	authGroup.POST("/invitations/accept", h.Invitation.Accept)
+	authGroup.POST("/invites/:code/accept", h.InviteLink.Accept)
+	authGroup.POST("/organizations", h.Organization.Create)
+	authGroup.GET("/organizations", h.Organization.List)
+	authGroup.GET("/organizations/:id/members", h.Organization.ListMembers)
+	authGroup.POST("/organizations/:id/invitations", h.Organization.Invite)
+	authGroup.POST("/organizations/invitations/accept", h.Organization.AcceptInvitation)
+	authGroup.PUT("/organizations/:id/members/:user_id/role", h.Organization.ChangeRole)
+	authGroup.DELETE("/organizations/:id/members/:user_id", h.Organization.RemoveMember)
+	authGroup.POST("/teams", h.Team.Create)
+	authGroup.GET("/teams", h.Team.List)
+	authGroup.GET("/teams/:id", h.Team.Get)
+	authGroup.DELETE("/teams/:id", h.Team.Delete)
+	authGroup.POST("/teams/:id/members", h.Team.AddMember)
+	authGroup.DELETE("/teams/:id/members/:user_id", h.Team.RemoveMember)
+	// DM Chat History: /messages/direct/:other_id
+	authGroup.GET("/messages/direct/:other_id", h.Chat.GetDMHistory)
+
+	// a group for all project-related routes w
+	r := e.Group("/projects")
+	r.Use(middleware.JWTMiddleware(jwtManager))
+
+	// a group for specific task actions
+	t := e.Group("/tasks")
+	t.Use(middleware.JWTMiddleware(jwtManager))
+
+	// --- Routes ---
+	e.POST("/register", h.User.Register)
+	e.GET("/admin", h.User.Admin, middleware.RequireRole(jwtManager, "admin"))
+	admin := e.Group("/admin")
+	admin.Use(middleware.RequireRole(jwtManager, "admin"))
+	admin.GET("/users", h.Admin.ListUsers)
+	admin.POST("/users/:id/suspend", h.Admin.Suspend)
+	admin.POST("/users/:id/ban", h.Admin.Ban)
+	admin.POST("/users/:id/reactivate", h.Admin.Reactivate)
+	admin.PUT("/users/:id/role", h.Admin.SetRole)
+	admin.POST("/users/:id/password-reset", h.Admin.ForcePasswordReset)
+	admin.GET("/audit", h.Admin.AuditLog)
+	admin.GET("/2fa/policies", h.Admin.TwoFactorPolicies)
+	admin.PUT("/2fa/policies/:role", h.Admin.SetTwoFactorPolicy)
+	admin.POST("/tokens/revoke", h.Admin.RevokeToken)
+	admin.POST("/users/:id/logout", h.Admin.LogoutUser)
+	admin.POST("/users/:id/verification/resend", h.Admin.ResendVerification)
+	admin.POST("/users/:id/verify", h.Admin.ForceVerify)
+	e.POST("/users", h.User.Register) // for now i leave it public to allow user creation
+	e.POST("/login", h.User.Login)
+	e.POST("/login/2fa", h.User.LoginTwoFactor)
+	e.POST("/login/2fa/enroll", h.User.LoginTwoFactorEnroll)
+	e.POST("/auth/refresh", h.User.Refresh)
+	if h.OIDCEnabled {
+		e.GET("/auth/oidc/login", h.User.OIDCLogin)
+		e.GET("/auth/oidc/callback", h.User.OIDCCallback)
+	}
+	e.GET("/verify-email", h.User.VerifyEmail)
+	e.GET("/users/:id/avatar", h.Profile.Avatar)
+	e.POST("/password/forgot", h.Password.Forgot)
+	e.POST("/password/reset", h.Password.Reset)
+	e.GET("/.well-known/jwks.json", h.JWKS.Keys)
 	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{"status": "ok"})
+		return c.JSON(stdhttp.StatusOK, map[string]string{"status": "ok"})
 	})
 
-	// User routes
-	e.POST("/users", userHandler.Register)
-	e.POST("/login", userHandler.Login)
+	// project routes
+	r.POST("", h.Project.Create)
+	r.PUT("/:id", h.Project.Update)
+	r.GET("", h.Project.List)
+	r.GET("/deleted", h.Project.ListDeleted)
+	r.GET("/templates", h.Project.ListTemplates)
+	r.DELETE("/templates/:template_id", h.Project.DeleteTemplate)
+	r.GET("/:id", h.Project.GetByID)
+	r.DELETE("/:id", h.Project.DeleteProject)
+	r.POST("/:id/archive", h.Project.Archive)
+	r.POST("/:id/unarchive", h.Project.Unarchive)
+	r.POST("/:id/restore", h.Project.Restore)
+	r.POST("/users", h.Project.AddUserToProject)
+	r.GET("/users", h.Project.ListUsersInProject)
+	r.DELETE("/users", h.Project.RemoveUserFromProject)
+	r.PUT("/:id/members/:user_id/role", h.Project.ChangeMemberRole)
+	r.POST("/:id/transfer", h.Project.TransferOwnership)
+	r.POST("/:id/clone", h.Project.Clone)
+	r.POST("/:id/templates", h.Project.SaveTemplate)
+	r.POST("/:id/invitations", h.Invitation.Invite)
+	r.GET("/:id/invitations", h.Invitation.List)
+	r.POST("/:id/invitations/:invitation_id/resend", h.Invitation.Resend)
+	r.DELETE("/:id/invitations/:invitation_id", h.Invitation.Revoke)
+	r.POST("/:id/invite-links", h.InviteLink.Create)
+	r.GET("/:id/invite-links", h.InviteLink.List)
+	r.DELETE("/:id/invite-links/:link_id", h.InviteLink.Revoke)
+	r.POST("/:id/join-requests", h.JoinRequest.Create)
+	r.GET("/:id/join-requests", h.JoinRequest.List)
+	r.POST("/:id/join-requests/:request_id/approve", h.JoinRequest.Approve)
+	r.POST("/:id/join-requests/:request_id/reject", h.JoinRequest.Reject)
+	r.POST("/:id/teams", h.Team.AddToProject)
+	r.GET("/:id/teams", h.Team.ListForProject)
+	r.DELETE("/:id/teams/:team_id", h.Team.RemoveFromProject)
+
+	// task routes
+	t.POST("", h.Task.CreateTask)
+	t.PUT("/:id", h.Task.UpdateTask)
+	t.DELETE("/:id", h.Task.DeleteTask)
+	t.GET("/:id/history", h.Task.GetTaskHistory)
+
+	// project task list: /projects/:id/tasks
+	r.GET("/:id/tasks", h.Task.ListTaskByProject)
+	// project chat history: /projects/:id/messages
+	r.GET("/:id/messages", h.Chat.GetProjectHistory)
+
+	// websocket route
+	e.GET("/ws", h.WS.HandleConnection)
 }
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandlerRejectsBadTarget(t *testing.T) {
+	// the service is never reached on bad input, a nil one proves it
+	adminHandler := handlers.NewAdminHandler(nil, nil)
+
+	e := echo.New()
+	admin := e.Group("/admin")
+	admin.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &auth.Claims{UserID: 1, Role: "admin"})
+			return next(c)
+		}
+	})
+	admin.POST("/users/:id/suspend", adminHandler.Suspend)
+	admin.PUT("/users/:id/role", adminHandler.SetRole)
+	admin.POST("/users/:id/password-reset", adminHandler.ForcePasswordReset)
+	admin.POST("/users/:id/logout", adminHandler.LogoutUser)
+	admin.POST("/users/:id/verification/resend", adminHandler.ResendVerification)
+	admin.POST("/users/:id/verify", adminHandler.ForceVerify)
+
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/admin/users/abc/suspend"},
+		{http.MethodPut, "/admin/users/abc/role"},
+		{http.MethodPost, "/admin/users/abc/password-reset"},
+		{http.MethodPost, "/admin/users/abc/logout"},
+		{http.MethodPost, "/admin/users/abc/verification/resend"},
+		{http.MethodPost, "/admin/users/abc/verify"},
+	}
+	for _, r := range routes {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			assert.NotPanics(t, func() {
+				e.ServeHTTP(rec, httptest.NewRequest(r.method, r.path, nil))
+			})
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "invalid user id")
+		})
+	}
+}
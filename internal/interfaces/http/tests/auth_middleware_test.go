@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -97,3 +98,128 @@ func TestJWTMiddleware_ValidToken(t *testing.T) {
 	assert.True(t, called)
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
+
+// memoryDenylistStore stands in for the postgres tables
+type memoryDenylistStore struct {
+	entries map[string]time.Time
+}
+
+func (m *memoryDenylistStore) AddUserCutoff(ctx context.Context, userID int64, notBefore, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *memoryDenylistStore) ListActiveCutoffs(ctx context.Context) (map[int64]time.Time, error) {
+	return map[int64]time.Time{}, nil
+}
+
+func (m *memoryDenylistStore) Add(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	m.entries[jti] = expiresAt
+	return nil
+}
+
+func (m *memoryDenylistStore) ListActive(ctx context.Context) (map[string]time.Time, error) {
+	active := make(map[string]time.Time)
+	for jti, exp := range m.entries {
+		active[jti] = exp
+	}
+	return active, nil
+}
+
+func TestJWTMiddleware_RevokedToken(t *testing.T) {
+	e := echo.New()
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseDenylist(auth.NewDenylist(&memoryDenylistStore{entries: map[string]time.Time{}}))
+
+	token, err := jwtManager.GenerateToken(1, "test@example.com", "user")
+	assert.NoError(t, err)
+
+	claims, err := jwtManager.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID, "every token should carry a jti")
+
+	// revoke it, the very next request must be rejected
+	assert.NoError(t, jwtManager.RevokeToken(context.Background(), claims))
+
+	called := false
+	handler := middleware.JWTMiddleware(jwtManager)(func(c echo.Context) error {
+		called = true
+		return c.JSON(http.StatusOK, map[string]string{"message": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, handler(c))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// a fresh token for the same user still works
+	other, _ := jwtManager.GenerateToken(1, "test@example.com", "user")
+	_, err = jwtManager.VerifyToken(other)
+	assert.NoError(t, err)
+}
+
+func TestJWTManager_RevokeUserTokensSameSecond(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseDenylist(auth.NewDenylist(&memoryDenylistStore{entries: map[string]time.Time{}}))
+
+	// issued a moment before the revocation, most likely in the same second
+	token, err := jwtManager.GenerateToken(1, "test@example.com", "user")
+	assert.NoError(t, err)
+	assert.NoError(t, jwtManager.RevokeUserTokens(context.Background(), 1))
+
+	_, err = jwtManager.VerifyToken(token)
+	assert.ErrorIs(t, err, auth.ErrTokenRevoked)
+
+	// a login in a later second isn't caught by it
+	now := time.Now()
+	time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
+	fresh, _ := jwtManager.GenerateToken(1, "test@example.com", "user")
+	_, err = jwtManager.VerifyToken(fresh)
+	assert.NoError(t, err)
+}
+
+func TestJWTMiddleware_LiveAccountStatus(t *testing.T) {
+	e := echo.New()
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+
+	role, status := "admin", "active"
+	jwtManager.UseAccountCache(auth.NewAccountCache(func(ctx context.Context, userID int64) (string, string, error) {
+		return role, status, nil
+	}, time.Minute))
+
+	token, err := jwtManager.GenerateToken(1, "test@example.com", "admin")
+	assert.NoError(t, err)
+
+	serve := func() (*httptest.ResponseRecorder, *auth.Claims) {
+		var seen *auth.Claims
+		handler := middleware.JWTMiddleware(jwtManager)(func(c echo.Context) error {
+			seen = c.Get("user").(*auth.Claims)
+			return c.JSON(http.StatusOK, map[string]string{"message": "ok"})
+		})
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler(e.NewContext(req, rec)))
+		return rec, seen
+	}
+
+	rec, claims := serve()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "admin", claims.Role)
+
+	// demoted: the same token now carries the new role
+	role = "user"
+	jwtManager.ForgetAccount(1)
+	rec, claims = serve()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user", claims.Role)
+
+	// suspended: the token is refused
+	status = "suspended"
+	jwtManager.ForgetAccount(1)
+	rec, _ = serve()
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
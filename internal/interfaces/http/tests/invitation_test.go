@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationEndpoints(t *testing.T) {
+	ctx := context.Background()
+	userRepo := user.NewFakeRepository()
+	userRepo.Create(ctx, user.User{Email: "owner@example.com", Role: "user", Status: user.StatusActive})
+
+	projectRepo := projects.NewFakeRepository()
+	projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+	projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	outbox := &bytes.Buffer{}
+	service := invitations.NewService(invitations.NewFakeRepository(projectRepo), projectRepo, userRepo,
+		mail.NewLogMailer(outbox), jwtManager, nil, time.Hour, "http://localhost/invitations/accept?token=")
+	handler := handlers.NewInvitationHandler(service)
+
+	verifyOutbox := &bytes.Buffer{}
+	verification := user.NewVerificationService(userRepo, mail.NewLogMailer(verifyOutbox), jwtManager, time.Hour, "http://localhost/verify-email?token=")
+	twoFactor := user.NewTwoFactorService(userRepo, user.NewFakeTwoFactorRepository(), jwtManager, "Playingfield", time.Minute)
+	userHandler := handlers.NewUserHandler(user.NewService(userRepo), jwtManager,
+		tokens.NewService(tokens.NewFakeRepository(), time.Hour), verification, twoFactor)
+	userHandler.UseInvitations(service)
+
+	e := echo.New()
+	e.POST("/login", userHandler.Login)
+	e.GET("/verify-email", userHandler.VerifyEmail)
+	r := e.Group("/projects")
+	r.Use(middleware.JWTMiddleware(jwtManager))
+	r.POST("/:id/invitations", handler.Invite)
+	r.GET("/:id/invitations", handler.List)
+	r.POST("/:id/invitations/:invitation_id/resend", handler.Resend)
+	r.DELETE("/:id/invitations/:invitation_id", handler.Revoke)
+
+	ownerToken, _ := jwtManager.GenerateToken(1, "owner@example.com", "user")
+	strangerToken, _ := jwtManager.GenerateToken(99, "stranger@example.com", "user")
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var inv invitations.Invitation
+
+	t.Run("Owner invites an address without an account", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/invitations", ownerToken, `{"email":"newbie@example.com"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &inv))
+		assert.Equal(t, "member", inv.Role)
+		assert.Contains(t, outbox.String(), "http://localhost/invitations/accept?token=")
+
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/projects/1/invitations", ownerToken, `{"email":"newbie@example.com"}`).Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/projects/1/invitations", strangerToken, `{"email":"x@example.com"}`).Code)
+	})
+
+	t.Run("Owner lists and resends pending invitations", func(t *testing.T) {
+		rec := do(http.MethodGet, "/projects/1/invitations", ownerToken, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var list []invitations.Invitation
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		assert.Len(t, list, 1)
+
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/projects/1/invitations", strangerToken, "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/projects/1/invitations/1/resend", ownerToken, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/projects/1/invitations/42/resend", ownerToken, "").Code)
+	})
+
+	t.Run("Logging in after registering turns the invitation into membership", func(t *testing.T) {
+		hash, _ := auth.HashPassword("supersecret")
+		newbie, _ := userRepo.Create(ctx, user.User{Email: "newbie@example.com", PasswordHash: hash, Role: "user", Status: user.StatusActive})
+
+		rec := do(http.MethodPost, "/login", "", `{"email":"newbie@example.com","password":"supersecret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		members, _ := projectRepo.ListUsersInProject(ctx, 1)
+		assert.Len(t, members, 2)
+		assert.Equal(t, newbie.ID, members[1].ID)
+
+		rec = do(http.MethodGet, "/projects/1/invitations", ownerToken, "")
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("A revoked invitation is gone", func(t *testing.T) {
+		do(http.MethodPost, "/projects/1/invitations", ownerToken, `{"email":"late@example.com"}`)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/projects/1/invitations/2", ownerToken, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/projects/1/invitations/2", ownerToken, "").Code)
+	})
+
+	t.Run("Verifying the email turns the invitation into membership", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/projects/1/invitations", ownerToken, `{"email":"fresh@example.com"}`).Code)
+		fresh, _ := userRepo.Create(ctx, user.User{Email: "fresh@example.com", Role: "user", Status: user.StatusPendingVerification})
+		assert.NoError(t, verification.SendVerification(ctx, fresh))
+		link := regexp.MustCompile(`verify-email\?token=(\S+)`).FindStringSubmatch(verifyOutbox.String())
+		assert.Len(t, link, 2)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/verify-email?token="+link[1], "", "").Code)
+
+		members, _ := projectRepo.ListUsersInProject(ctx, 1)
+		joined := false
+		for _, m := range members {
+			joined = joined || m.ID == fresh.ID
+		}
+		assert.True(t, joined)
+	})
+}
+
+func TestInviteLinkEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectRepo := projects.NewFakeRepository()
+	projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1})
+	projectRepo.AddUserToProject(ctx, 1, 1, "owner")
+
+	hub := ws.NewHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	handler := handlers.NewInviteLinkHandler(invitations.NewLinkService(
+		invitations.NewFakeLinkRepository(projectRepo), projectRepo, hub, "http://localhost/invites/"))
+
+	e := echo.New()
+	e.GET("/ws", handlers.NewWSHandler(jwtManager, hub, nil).HandleConnection)
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.POST("/projects/:id/invite-links", handler.Create)
+	authGroup.GET("/projects/:id/invite-links", handler.List)
+	authGroup.DELETE("/projects/:id/invite-links/:link_id", handler.Revoke)
+	authGroup.POST("/invites/:code/accept", handler.Accept)
+
+	token := func(userID int64) string {
+		t, _ := jwtManager.GenerateToken(userID, "", "user")
+		return t
+	}
+	do := func(method, path string, userID int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token(userID))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var created dto.CreateInviteLinkResponse
+	t.Run("Owner creates a link with a use limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/projects/1/invite-links", 2, `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/projects/1/invite-links", 1, `{"max_uses":0}`).Code)
+
+		rec := do(http.MethodPost, "/projects/1/invite-links", 1, `{"role":"member","max_uses":1}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "http://localhost/invites/"+created.Code, created.URL)
+		assert.NotContains(t, do(http.MethodGet, "/projects/1/invite-links", 1, "").Body.String(), created.Code)
+	})
+
+	t.Run("Joining through the link announces the new member", func(t *testing.T) {
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + token(1)
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		assert.NoError(t, err)
+		defer conn.Close()
+		time.Sleep(50 * time.Millisecond) // let the hub register the client
+
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/invites/"+created.Code+"/accept", 2, "").Code)
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, msg, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "USER_ADDED:1:2:member", string(msg))
+
+		// the only use is gone
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/invites/"+created.Code+"/accept", 3, "").Code)
+	})
+
+	t.Run("Revoked links stop working", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/invite-links", 1, `{}`)
+		var link dto.CreateInviteLinkResponse
+		json.Unmarshal(rec.Body.Bytes(), &link)
+
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/projects/1/invite-links/"+strconv.FormatInt(link.Info.ID, 10), 1, "").Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/invites/"+link.Code+"/accept", 3, "").Code)
+	})
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinRequestEndpoints(t *testing.T) {
+	ctx := context.Background()
+
+	// 1 owns the project in Acme, 5 and 6 are in Acme too, 7 is in Globex
+	orgRepo := organizations.NewFakeRepository()
+	orgRepo.Create(ctx, "Acme", 1)
+	orgRepo.AddMember(ctx, 1, 5, organizations.RoleMember)
+	orgRepo.AddMember(ctx, 1, 6, organizations.RoleMember)
+	orgRepo.Create(ctx, "Globex", 7)
+	orgService := organizations.NewService(orgRepo, user.NewFakeRepository())
+
+	projectRepo := projects.NewFakeRepository()
+	projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: 1})
+	projectRepo.AddUserToProject(ctx, 1, 1, projects.RoleOwner)
+	projectService := projects.NewService(projectRepo, nil)
+	projectService.UseOrganizations(orgService)
+
+	hub := ws.NewHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	service := invitations.NewJoinRequestService(invitations.NewFakeJoinRequestRepository(projectRepo), projectRepo, hub)
+	service.UseOrganizations(orgService)
+	handler := handlers.NewJoinRequestHandler(service)
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	e := echo.New()
+	e.GET("/ws", handlers.NewWSHandler(jwtManager, hub, nil).HandleConnection)
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.POST("/projects/:id/join-requests", handler.Create)
+	authGroup.GET("/projects/:id/join-requests", handler.List)
+	authGroup.POST("/projects/:id/join-requests/:request_id/approve", handler.Approve)
+	authGroup.POST("/projects/:id/join-requests/:request_id/reject", handler.Reject)
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	token := func(userID int64) string {
+		t, _ := jwtManager.GenerateToken(userID, "", "user")
+		return t
+	}
+	do := func(method, path string, userID int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token(userID))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	connect := func(userID int64) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?token="+token(userID), nil)
+		assert.NoError(t, err)
+		return conn
+	}
+	event := func(conn *websocket.Conn) (string, invitations.JoinRequest) {
+		var msg struct {
+			Type string                  `json:"type"`
+			Data invitations.JoinRequest `json:"data"`
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			_, raw, err := conn.ReadMessage()
+			if !assert.NoError(t, err) {
+				return "", msg.Data
+			}
+			// plain USER_ADDED broadcasts go to everyone, they're not what's tested here
+			if strings.HasPrefix(string(raw), "USER_ADDED:") {
+				continue
+			}
+			assert.NoError(t, json.Unmarshal(raw, &msg))
+			return msg.Type, msg.Data
+		}
+	}
+
+	owner := connect(1)
+	defer owner.Close()
+	requester := connect(5)
+	defer requester.Close()
+	time.Sleep(50 * time.Millisecond) // let the hub register the clients
+
+	var requestID int64
+	t.Run("The owner hears about a new request right away", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/join-requests", 5, `{"message":"I'm on the launch crew"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		kind, data := event(owner)
+		assert.Equal(t, "join_request", kind)
+		assert.Equal(t, int64(5), data.UserID)
+		assert.Equal(t, "I'm on the launch crew", data.Message)
+		requestID = data.ID
+
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/projects/1/join-requests", 5, `{}`).Code)
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/projects/1/join-requests", 1, `{}`).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/projects/9/join-requests", 5, `{}`).Code)
+	})
+
+	t.Run("Projects of other organizations look missing", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/join-requests", 7, `{}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, do(http.MethodPost, "/projects/9/join-requests", 7, `{}`).Body.String(), rec.Body.String())
+	})
+
+	t.Run("Only managers see the queue", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/projects/1/join-requests", 5, "").Code)
+
+		rec := do(http.MethodGet, "/projects/1/join-requests", 1, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var pending []invitations.JoinRequest
+		json.Unmarshal(rec.Body.Bytes(), &pending)
+		assert.Len(t, pending, 1)
+	})
+
+	t.Run("Approving adds the requester and tells them", func(t *testing.T) {
+		path := fmt.Sprintf("/projects/1/join-requests/%d/approve", requestID)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, path, 5, `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, path, 1, `{"role":"owner"}`).Code)
+
+		rec := do(http.MethodPost, path, 1, `{"role":"viewer"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		kind, data := event(requester)
+		assert.Equal(t, "join_request_approved", kind)
+		assert.Equal(t, requestID, data.ID)
+
+		role, _ := projects.NewAuthorizer(projectRepo).Role(ctx, 1, 5)
+		assert.Equal(t, projects.RoleViewer, role)
+
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, fmt.Sprintf("/projects/1/join-requests/%d/reject", requestID), 1, "").Code)
+	})
+
+	t.Run("Rejecting leaves the requester out", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/join-requests", 6, `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		_, data := event(owner)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("/projects/1/join-requests/%d/reject", data.ID), 1, "").Code)
+		role, _ := projects.NewAuthorizer(projectRepo).Role(ctx, 1, 6)
+		assert.Empty(t, role)
+	})
+}
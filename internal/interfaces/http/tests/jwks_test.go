@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryKeyStore stands in for the signing_keys table
+type memoryKeyStore struct {
+	keys []auth.SigningKey
+}
+
+func (m *memoryKeyStore) Create(ctx context.Context, key auth.SigningKey) error {
+	m.keys = append([]auth.SigningKey{key}, m.keys...)
+	return nil
+}
+
+func (m *memoryKeyStore) ListActive(ctx context.Context) ([]auth.SigningKey, error) {
+	var active []auth.SigningKey
+	for _, k := range m.keys {
+		if time.Now().Before(k.ExpiresAt) {
+			active = append(active, k)
+		}
+	}
+	return active, nil
+}
+
+func (m *memoryKeyStore) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func TestJWKS_VerifyOffline(t *testing.T) {
+	store := &memoryKeyStore{}
+	keySet := auth.NewKeySet(store, time.Hour, time.Hour)
+	assert.NoError(t, keySet.Rotate(context.Background()))
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	legacy, _ := jwtManager.GenerateToken(1, "test@example.com", "user")
+	jwtManager.UseKeySet(keySet)
+
+	token, err := jwtManager.GenerateToken(1, "test@example.com", "user")
+	assert.NoError(t, err)
+
+	// fetch the published keys like another service would
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), rec)
+	assert.NoError(t, handlers.NewJWKSHandler(jwtManager).Keys(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var set auth.JWKS
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	if !assert.Len(t, set.Keys, 1) {
+		return
+	}
+
+	// verify with nothing but the jwks, no shared secret
+	parsed, err := jwt.Parse(token, func(tok *jwt.Token) (interface{}, error) {
+		for _, k := range set.Keys {
+			if k.Kid == tok.Header["kid"] {
+				x, err := base64.RawURLEncoding.DecodeString(k.X)
+				return ed25519.PublicKey(x), err
+			}
+		}
+		return nil, auth.ErrUnknownKey
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	assert.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	// HS256 tokens from before the switch still work until they expire
+	_, err = jwtManager.VerifyToken(legacy)
+	assert.NoError(t, err)
+
+	// rotating keeps tokens signed with the old key valid
+	store.keys[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, keySet.Rotate(context.Background()))
+	assert.Len(t, keySet.JWKS().Keys, 2)
+
+	_, err = jwtManager.VerifyToken(token)
+	assert.NoError(t, err)
+
+	fresh, _ := jwtManager.GenerateToken(1, "test@example.com", "user")
+	_, err = jwtManager.VerifyToken(fresh)
+	assert.NoError(t, err)
+}
+
+func TestJWKS_NextKeyPublishedBeforeItSigns(t *testing.T) {
+	store := &memoryKeyStore{}
+	keySet := auth.NewKeySet(store, time.Hour, time.Hour)
+	assert.NoError(t, keySet.Rotate(context.Background()))
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseKeySet(keySet)
+
+	kidOf := func(token string) interface{} {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+		assert.NoError(t, err)
+		return parsed.Header["kid"]
+	}
+
+	// the first key is old enough to be replaced
+	store.keys[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	first := store.keys[0].ID
+	assert.NoError(t, keySet.Rotate(context.Background()))
+	next := store.keys[0].ID
+	assert.NotEqual(t, first, next)
+
+	// the successor is published right away but doesn't sign yet
+	var kids []string
+	for _, k := range keySet.JWKS().Keys {
+		kids = append(kids, k.Kid)
+	}
+	assert.ElementsMatch(t, []string{first, next}, kids)
+
+	token, err := jwtManager.GenerateToken(1, "test@example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, first, kidOf(token))
+
+	// once every cache has caught up it takes over
+	store.keys[0].CreatedAt = time.Now().Add(-time.Hour)
+	assert.NoError(t, keySet.Load(context.Background()))
+	token, err = jwtManager.GenerateToken(1, "test@example.com", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, next, kidOf(token))
+}
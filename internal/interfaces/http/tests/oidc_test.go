@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/oidc"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	mockClientID    = "playingfield"
+	mockRedirectURL = "http://playingfield.test/auth/oidc/callback"
+)
+
+// mockIssuer is a minimal OIDC provider: discovery, JWKS, an authorize
+// endpoint that signs in whoever is set in claims, and a token endpoint
+// that enforces PKCE
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims // the "logged in" user at the provider
+	codes  map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	m := &mockIssuer{key: key, codes: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != mockClientID || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		code, _ := oidc.NewVerifier()
+		m.mu.Lock()
+		m.codes[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: m.claims}
+		m.mu.Unlock()
+
+		back := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, back, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mu.Lock()
+		grant, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code")) // codes are single use
+		m.mu.Unlock()
+
+		if !ok || r.PostForm.Get("client_id") != mockClientID ||
+			oidc.Challenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   m.server.URL,
+			"aud":   mockClientID,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": grant.nonce,
+		}
+		for k, v := range grant.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "mock-key"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "token_type": "Bearer", "id_token": signed})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) signIn(claims jwt.MapClaims) {
+	m.mu.Lock()
+	m.claims = claims
+	m.mu.Unlock()
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		IssuerURL:   issuer.server.URL,
+		ClientID:    mockClientID,
+		RedirectURL: mockRedirectURL,
+		Scopes:      []string{"email", "groups"},
+	}, nil)
+	assert.NoError(t, err)
+
+	handler, fakeRepo := setupHandler()
+	// same secret as setupHandler, so tokens from either one verify
+	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
+	handler.UseOIDC(user.NewOIDCService(
+		fakeRepo,
+		user.NewFakeIdentityRepository(),
+		provider,
+		jwtManager,
+		nil,
+		user.RoleMapping{Claim: "groups", Values: map[string]string{"pf-admins": user.RoleAdmin}},
+		time.Minute,
+	))
+
+	e := echo.New()
+	e.GET("/auth/oidc/login", handler.OIDCLogin)
+	e.GET("/auth/oidc/callback", handler.OIDCCallback)
+
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	// start returns the state cookie and the url the provider sends the browser back to
+	start := func(t *testing.T) (*http.Cookie, *url.URL) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+		assert.Equal(t, http.StatusFound, rec.Code)
+		cookies := rec.Result().Cookies()
+		assert.Len(t, cookies, 1)
+
+		resp, err := noRedirects.Get(rec.Header().Get("Location"))
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+		back, err := url.Parse(resp.Header.Get("Location"))
+		assert.NoError(t, err)
+		return cookies[0], back
+	}
+
+	callback := func(cookie *http.Cookie, back *url.URL) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+back.RawQuery, nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("First sign-in creates the account with the mapped role", func(t *testing.T) {
+		issuer.signIn(jwt.MapClaims{"sub": "alice-1", "email": "Alice@corp.example", "email_verified": true, "groups": []string{"staff", "pf-admins"}})
+
+		rec := callback(start(t))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp dto.LoginResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEmpty(t, resp.RefreshToken)
+		assert.Equal(t, "alice@corp.example", resp.User.Email)
+		assert.Equal(t, user.RoleAdmin, resp.User.Role)
+
+		claims, err := jwtManager.VerifyToken(resp.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, resp.UserId, claims.UserID)
+	})
+
+	t.Run("Next sign-in finds the linked account and follows role changes", func(t *testing.T) {
+		// the email changed at the provider, the subject didn't
+		issuer.signIn(jwt.MapClaims{"sub": "alice-1", "email": "alice.new@corp.example", "email_verified": true, "groups": []string{"staff"}})
+
+		rec := callback(start(t))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp dto.LoginResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "alice@corp.example", resp.User.Email)
+		assert.Equal(t, user.RoleUser, resp.User.Role)
+		assert.Len(t, fakeRepo.Users, 1)
+	})
+
+	t.Run("State from another attempt is refused", func(t *testing.T) {
+		issuer.signIn(jwt.MapClaims{"sub": "bob-1", "email": "bob@corp.example", "email_verified": true})
+
+		cookie, _ := start(t)
+		_, back := start(t)
+		assert.Equal(t, http.StatusBadRequest, callback(cookie, back).Code)
+	})
+
+	t.Run("A code can't be redeemed twice", func(t *testing.T) {
+		issuer.signIn(jwt.MapClaims{"sub": "bob-1", "email": "bob@corp.example", "email_verified": true})
+
+		cookie, back := start(t)
+		assert.Equal(t, http.StatusOK, callback(cookie, back).Code)
+		assert.Equal(t, http.StatusUnauthorized, callback(cookie, back).Code)
+	})
+
+	t.Run("Existing local accounts are only linked with a verified email", func(t *testing.T) {
+		fakeRepo.Users = append(fakeRepo.Users, user.User{
+			ID:     int64(len(fakeRepo.Users) + 1),
+			Email:  "carol@corp.example",
+			Role:   user.RoleUser,
+			Status: user.StatusActive,
+		})
+
+		issuer.signIn(jwt.MapClaims{"sub": "carol-1", "email": "carol@corp.example", "email_verified": false})
+		assert.Equal(t, http.StatusConflict, callback(start(t)).Code)
+
+		issuer.signIn(jwt.MapClaims{"sub": "carol-1", "email": "carol@corp.example", "email_verified": true})
+		assert.Equal(t, http.StatusOK, callback(start(t)).Code)
+	})
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationEndpoints(t *testing.T) {
+	ctx := context.Background()
+	userRepo := user.NewFakeRepository()
+	userRepo.Create(ctx, user.User{Email: "alice@example.com", Status: user.StatusActive})
+	userRepo.Create(ctx, user.User{Email: "bob@example.com", Status: user.StatusActive})
+	userRepo.Create(ctx, user.User{Email: "carol@example.com", Status: user.StatusActive})
+
+	profileRepo := profiles.NewFakeRepository()
+	profileRepo.Users[1] = "alice@example.com"
+	profileRepo.Users[2] = "bob@example.com"
+	profileRepo.Users[3] = "carol@example.com"
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	outbox := &bytes.Buffer{}
+	orgService := organizations.NewService(organizations.NewFakeRepository(), userRepo)
+	orgService.UseInvitations(mail.NewLogMailer(outbox), jwtManager, time.Hour, "http://localhost/organizations/invitations/accept?token=")
+	projectRepo := projects.NewFakeRepository()
+	projectService := projects.NewService(projectRepo, nil)
+	projectService.UseOrganizations(orgService)
+	linkRepo := invitations.NewFakeLinkRepository(projectRepo)
+	linkRepo.Organizations = orgService
+	linkService := invitations.NewLinkService(linkRepo, projectRepo, nil, "")
+	linkService.UseOrganizations(orgService)
+
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	profileHandler := handlers.NewProfileHandler(profiles.NewService(profileRepo))
+	profileHandler.UseOrganizations(orgService)
+	projectHandler := handlers.NewProjectHandler(projectService)
+	inviteLinkHandler := handlers.NewInviteLinkHandler(linkService)
+
+	e := echo.New()
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.POST("/organizations", orgHandler.Create)
+	authGroup.GET("/organizations", orgHandler.List)
+	authGroup.GET("/organizations/:id/members", orgHandler.ListMembers)
+	authGroup.POST("/organizations/:id/invitations", orgHandler.Invite)
+	authGroup.POST("/organizations/invitations/accept", orgHandler.AcceptInvitation)
+	authGroup.PUT("/organizations/:id/members/:user_id/role", orgHandler.ChangeRole)
+	authGroup.DELETE("/organizations/:id/members/:user_id", orgHandler.RemoveMember)
+	authGroup.GET("/users/:id/profile", profileHandler.Get)
+	authGroup.POST("/projects", projectHandler.Create)
+	authGroup.POST("/projects/users", projectHandler.AddUserToProject)
+	authGroup.POST("/projects/:id/invite-links", inviteLinkHandler.Create)
+	authGroup.POST("/invites/:code/accept", inviteLinkHandler.Accept)
+
+	do := func(method, path string, userID int64, body string) *httptest.ResponseRecorder {
+		token, _ := jwtManager.GenerateToken(userID, "", "user")
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Projects need an organization", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/projects", 1, `{"name":"Apollo"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/organizations", 1, `{"name":" "}`).Code)
+
+		rec := do(http.MethodPost, "/organizations", 1, `{"name":"Acme"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var org organizations.Organization
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &org))
+		assert.Equal(t, organizations.RoleAdmin, org.Role)
+
+		rec = do(http.MethodPost, "/projects", 1, `{"name":"Apollo"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var project projects.Project
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &project))
+		assert.Equal(t, org.ID, project.OrganizationID)
+	})
+
+	t.Run("People outside the organization stay invisible", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/users/2/profile", 1, "").Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/projects/users", 1, `{"project_id":1,"user_id":2}`).Code)
+
+		assert.Equal(t, http.StatusAccepted, do(http.MethodPost, "/organizations/1/invitations", 1, `{"email":"nobody@example.com"}`).Code)
+		outbox.Reset()
+		assert.Equal(t, http.StatusAccepted, do(http.MethodPost, "/organizations/1/invitations", 1, `{"email":"bob@example.com"}`).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/users/2/profile", 1, "").Code)
+
+		token := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(outbox.String())[1]
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/organizations/invitations/accept", 3, `{"token":"`+token+`"}`).Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/organizations/invitations/accept", 2, `{"token":"`+token+`"}`).Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/2/profile", 1, "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/projects/users", 1, `{"project_id":1,"user_id":2}`).Code)
+	})
+
+	t.Run("Only admins manage members and the last admin stays", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/organizations/1/invitations", 2, `{"email":"carol@example.com"}`).Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/organizations/1/members", 3, "").Code)
+		assert.Equal(t, http.StatusConflict, do(http.MethodPut, "/organizations/1/members/1/role", 1, `{"role":"member"}`).Code)
+		assert.Equal(t, http.StatusConflict, do(http.MethodDelete, "/organizations/1/members/1", 1, "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPut, "/organizations/1/members/2/role", 1, `{"role":"admin"}`).Code)
+
+		rec := do(http.MethodGet, "/organizations/1/members", 2, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"role":"admin"`)
+	})
+
+	t.Run("Joining a project through a link joins its organization", func(t *testing.T) {
+		rec := do(http.MethodPost, "/projects/1/invite-links", 1, `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var link dto.CreateInviteLinkResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &link))
+
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/invites/"+link.Code+"/accept", 3, "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/1/profile", 3, "").Code)
+		assert.Contains(t, do(http.MethodGet, "/organizations", 3, "").Body.String(), `"name":"Acme"`)
+	})
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordReset_RejectsPasswordWithEmail(t *testing.T) {
+	ctx := context.Background()
+	users := user.NewFakeRepository()
+	hash, _ := auth.HashPassword("old-password")
+	users.Create(ctx, user.User{Email: "jordan@example.com", PasswordHash: hash, Status: "active"})
+
+	outbox := &bytes.Buffer{}
+	resets := user.NewPasswordResetService(users, user.NewFakePasswordResetRepository(), mail.NewLogMailer(outbox),
+		nil, nil, time.Hour, "http://localhost/reset?token=")
+	handler := handlers.NewPasswordHandler(resets)
+	e := echo.New()
+
+	assert.NoError(t, resets.RequestReset(ctx, "jordan@example.com"))
+	token := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(outbox.String())[1]
+
+	reset := func(password string) *httptest.ResponseRecorder {
+		body := `{"token":"` + token + `","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler.Reset(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := reset("jordan-likes-long-passwords")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), user.ViolationContainsMail)
+
+	// the token wasn't used up by the rejected attempt
+	assert.Equal(t, http.StatusOK, reset("correct horse battery staple").Code)
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/pats"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalAccessTokens(t *testing.T) {
+	ctx := context.Background()
+
+	service := pats.NewService(pats.NewFakeRepository())
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UsePersonalAccessTokens(func(ctx context.Context, raw string) (*auth.Claims, error) {
+		tok, err := service.Authenticate(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Claims{
+			UserID:           tok.UserID,
+			Role:             "admin",
+			Status:           "active",
+			Scopes:           tok.Scopes,
+			RegisteredClaims: jwt.RegisteredClaims{ID: "pat"},
+		}, nil
+	})
+
+	readOnly, _, err := service.Create(ctx, 1, "reports", []string{pats.ScopeTasksRead}, nil)
+	assert.NoError(t, err)
+
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/tasks/:id/history", ok, middleware.JWTMiddleware(jwtManager))
+	e.PUT("/tasks/:id", ok, middleware.JWTMiddleware(jwtManager))
+	e.GET("/me", ok, middleware.JWTMiddleware(jwtManager))
+	e.GET("/admin", ok, middleware.RequireRole(jwtManager, "admin"))
+
+	do := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("Scope granted", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/tasks/5/history", readOnly))
+	})
+
+	t.Run("Scope missing", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/tasks/5", readOnly))
+	})
+
+	t.Run("Routes without a scope need a real login", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/me", readOnly))
+
+		token, _ := jwtManager.GenerateToken(1, "a@b.com", "admin")
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", token))
+	})
+
+	t.Run("Admin routes reject personal access tokens even for admins", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin", readOnly))
+	})
+
+	t.Run("Chat read tokens listen on the socket but can't send", func(t *testing.T) {
+		hub := ws.NewHub()
+		go hub.Run()
+		defer hub.Stop()
+		e.GET("/ws", handlers.NewWSHandler(jwtManager, hub, nil).HandleConnection)
+		server := httptest.NewServer(e)
+		defer server.Close()
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token="
+
+		_, res, err := websocket.DefaultDialer.Dial(wsURL+readOnly, nil)
+		assert.Error(t, err)
+		if assert.NotNil(t, res) {
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		}
+
+		chatReader, _, _ := service.Create(ctx, 1, "chat bot", []string{pats.ScopeChatRead}, nil)
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+chatReader, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		assert.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "project_chat", "project_id": 1, "content": "hi"}))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, msg, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Contains(t, string(msg), "chat:write")
+	})
+
+	t.Run("Revoked tokens are rejected", func(t *testing.T) {
+		raw, tok, _ := service.Create(ctx, 1, "old", []string{pats.ScopeTasksRead}, nil)
+		assert.NoError(t, service.Revoke(ctx, 1, tok.ID))
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/tasks/5/history", raw))
+	})
+
+	t.Run("A password reset ends tokens created before it", func(t *testing.T) {
+		users := user.NewFakeRepository()
+		users.Create(ctx, user.User{Email: "reset@example.com"})
+		outbox := &bytes.Buffer{}
+		resets := user.NewPasswordResetService(users, user.NewFakePasswordResetRepository(), mail.NewLogMailer(outbox),
+			nil, jwtManager, time.Hour, "http://localhost/reset?token=")
+		resets.UsePersonalAccessTokens(service)
+
+		raw, _, _ := service.Create(ctx, 1, "before reset", []string{pats.ScopeTasksRead}, nil)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/tasks/5/history", raw))
+
+		assert.NoError(t, resets.RequestReset(ctx, "reset@example.com"))
+		token := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(outbox.String())[1]
+		hash, _ := auth.HashPassword("a new password")
+		assert.NoError(t, resets.ResetPassword(ctx, token, hash))
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/tasks/5/history", raw))
+		after, _, _ := service.Create(ctx, 1, "after reset", []string{pats.ScopeTasksRead}, nil)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/tasks/5/history", after))
+	})
+
+	t.Run("Logging out everywhere ends them too", func(t *testing.T) {
+		userHandler := handlers.NewUserHandler(nil, jwtManager, tokens.NewService(tokens.NewFakeRepository(), time.Hour), nil, nil)
+		userHandler.UsePersonalAccessTokens(service)
+		e.POST("/logout/all", userHandler.LogoutAll, middleware.JWTMiddleware(jwtManager))
+
+		raw, _, _ := service.Create(ctx, 1, "script", []string{pats.ScopeTasksRead}, nil)
+		login, _ := jwtManager.GenerateToken(1, "a@b.com", "admin")
+		assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/logout/all", login))
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/tasks/5/history", raw))
+	})
+}
@@ -0,0 +1,300 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/invitations"
+	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/tasks"
+	"github.com/nelfander/Playingfield/internal/domain/teams"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	apphttp "github.com/nelfander/Playingfield/internal/interfaces/http"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// the users of the policy test project, by role
+var policyUsers = map[string]int64{
+	"owner":      1,
+	"maintainer": 2,
+	"member":     3,
+	"viewer":     4,
+	"outsider":   5,
+}
+
+// the code of the invite link every policy server starts with
+const policyLinkCode = "policy-link-code"
+
+// newPolicyServer mounts the real route table on a fresh project,
+// so every request starts from the same state. The project's members are
+// in its organization, where the owner is the admin and the member created
+// team 1 with the viewer in it. The outsider has an open invitation to the
+// project and one to the organization.
+func newPolicyServer(jwtManager *auth.JWTManager) *echo.Echo {
+	ctx := context.Background()
+	userRepo := user.NewFakeRepository()
+	for _, role := range []string{"owner", "maintainer", "member", "viewer", "outsider"} {
+		userRepo.Create(ctx, user.User{Email: role + "@example.com", Status: user.StatusActive})
+	}
+
+	orgRepo := organizations.NewFakeRepository()
+	orgRepo.Create(ctx, "Acme", policyUsers["owner"])
+	projectRepo := projects.NewFakeRepository()
+	projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: 1})
+	for role, id := range policyUsers {
+		if role != "outsider" {
+			projectRepo.AddUserToProject(ctx, 1, id, role)
+		}
+		if role != "outsider" && role != "owner" {
+			orgRepo.AddMember(ctx, 1, id, organizations.RoleMember)
+		}
+	}
+	expires := time.Now().Add(time.Hour)
+	orgRepo.CreateInvitation(ctx, organizations.Invitation{OrganizationID: 1, Email: "outsider@example.com", Role: organizations.RoleMember, ExpiresAt: expires})
+	orgService := organizations.NewService(orgRepo, userRepo)
+	orgService.UseInvitations(mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+
+	teamRepo := teams.NewFakeRepository()
+	creator := policyUsers["member"]
+	teamRepo.Create(ctx, teams.Team{OrganizationID: 1, Name: "Design", CreatedBy: &creator})
+	teamRepo.AddMember(ctx, 1, policyUsers["viewer"])
+
+	taskRepo := tasks.NewFakeRepository()
+	assignee := policyUsers["member"]
+	taskRepo.CreateTask(ctx, &tasks.Task{ProjectID: 1, Title: "Assigned", Status: "TODO", AssignedTo: &assignee})
+	taskRepo.CreateTask(ctx, &tasks.Task{ProjectID: 1, Title: "Unassigned", Status: "TODO"})
+
+	policy := projects.NewCachedAuthorizer(projectRepo, time.Minute)
+	projectService := projects.NewService(projectRepo, nil)
+	projectService.UseAuthorizer(policy)
+	projectService.UseTemplates(projects.NewFakeTemplateRepository())
+	taskService := tasks.NewService(taskRepo, projectRepo, nil)
+	taskService.UseAuthorizer(policy)
+	chatService := messages.NewService(messages.NewFakeRepository(), projectRepo, nil)
+	chatService.UseAuthorizer(policy)
+	invitationRepo := invitations.NewFakeRepository(projectRepo)
+	invitationRepo.Create(ctx, invitations.Invitation{ProjectID: 1, Email: "outsider@example.com", Role: projects.RoleMember, ExpiresAt: expires})
+	invitationService := invitations.NewService(invitationRepo, projectRepo, userRepo,
+		mail.NewLogMailer(io.Discard), jwtManager, nil, time.Hour, "")
+	invitationService.UseAuthorizer(policy)
+	linkRepo := invitations.NewFakeLinkRepository(projectRepo)
+	linkRepo.Create(ctx, invitations.InviteLink{ProjectID: 1, CodeHash: auth.HashToken(policyLinkCode), Role: projects.RoleViewer})
+	linkService := invitations.NewLinkService(linkRepo, projectRepo, nil, "")
+	linkService.UseAuthorizer(policy)
+	joinRequestService := invitations.NewJoinRequestService(invitations.NewFakeJoinRequestRepository(projectRepo), projectRepo, nil)
+	joinRequestService.UseAuthorizer(policy)
+	teamService := teams.NewService(teamRepo, projectRepo, orgService, nil)
+	teamService.UseAuthorizer(policy)
+
+	// only the handlers behind scoped routes, the rest are never reached
+	e := echo.New()
+	apphttp.RegisterRoutes(e, jwtManager, apphttp.Handlers{
+		Organization: handlers.NewOrganizationHandler(orgService),
+		Team:         handlers.NewTeamHandler(teamService),
+		Project:      handlers.NewProjectHandler(projectService),
+		Task:         handlers.NewTaskHandler(taskService),
+		Chat:         handlers.NewChatHandler(chatService),
+		WS:           handlers.NewWSHandler(jwtManager, nil, chatService),
+		Invitation:   handlers.NewInvitationHandler(invitationService),
+		InviteLink:   handlers.NewInviteLinkHandler(linkService),
+		JoinRequest:  handlers.NewJoinRequestHandler(joinRequestService),
+	})
+	return e
+}
+
+func TestRoutePolicy(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+
+	everyone := []string{"owner", "maintainer", "member", "viewer"}
+	managers := []string{"owner", "maintainer"}
+	anyone := append(everyone, "outsider")
+
+	// the outsider's invitations, both have id 1
+	invitation, _ := jwtManager.GenerateActionToken("accept_invitation", 1, "outsider@example.com", time.Hour)
+	orgInvitation, _ := jwtManager.GenerateActionToken("accept_organization_invitation", 1, "outsider@example.com", time.Hour)
+
+	routes := []struct {
+		method  string
+		route   string // as registered, to check nothing is left out
+		path    string
+		body    string
+		allowed []string
+	}{
+		// creating and listing only ever touch the requester's own projects
+		{http.MethodPost, "/projects", "/projects", `{"name":"Gemini"}`, anyone},
+		{http.MethodGet, "/projects", "/projects", "", anyone},
+		{http.MethodGet, "/projects/:id", "/projects/1", "", everyone},
+		{http.MethodPut, "/projects/:id", "/projects/1", `{"name":"Renamed"}`, managers},
+		{http.MethodDelete, "/projects/:id", "/projects/1", "", []string{"owner"}},
+		{http.MethodPost, "/projects/:id/archive", "/projects/1/archive", "", managers},
+		{http.MethodPost, "/projects/:id/unarchive", "/projects/1/unarchive", "", managers},
+		// both only ever show the requester's own deleted projects
+		{http.MethodGet, "/projects/deleted", "/projects/deleted", "", anyone},
+		{http.MethodPost, "/projects/:id/restore", "/projects/1/restore", "", anyone},
+		{http.MethodPost, "/projects/users", "/projects/users", `{"project_id":1,"user_id":9,"role":"viewer"}`, managers},
+		{http.MethodGet, "/projects/users", "/projects/users?project_id=1", "", everyone},
+		{http.MethodDelete, "/projects/users", "/projects/users", `{"project_id":1,"user_id":4}`, managers},
+		{http.MethodPut, "/projects/:id/members/:user_id/role", "/projects/1/members/4/role", `{"role":"member"}`, managers},
+		{http.MethodPost, "/projects/:id/transfer", "/projects/1/transfer", `{"new_owner_id":3}`, []string{"owner"}},
+		{http.MethodPost, "/projects/:id/clone", "/projects/1/clone", `{"include_tasks":true}`, everyone},
+		{http.MethodPost, "/projects/:id/clone", "/projects/1/clone", `{"include_members":true}`, managers},
+		{http.MethodPost, "/projects/:id/templates", "/projects/1/templates", `{"name":"Skeleton"}`, everyone},
+		{http.MethodPost, "/projects/:id/templates", "/projects/1/templates", `{"include_members":true}`, managers},
+		// templates are personal, there is nothing project scoped to refuse
+		{http.MethodGet, "/projects/templates", "/projects/templates", "", anyone},
+		{http.MethodDelete, "/projects/templates/:template_id", "/projects/templates/1", "", anyone},
+		{http.MethodPost, "/projects/:id/invitations", "/projects/1/invitations", `{"email":"new@example.com"}`, managers},
+		{http.MethodGet, "/projects/:id/invitations", "/projects/1/invitations", "", managers},
+		{http.MethodPost, "/projects/:id/invitations/:invitation_id/resend", "/projects/1/invitations/1/resend", "", managers},
+		{http.MethodDelete, "/projects/:id/invitations/:invitation_id", "/projects/1/invitations/1", "", managers},
+		{http.MethodPost, "/projects/:id/invite-links", "/projects/1/invite-links", `{}`, managers},
+		{http.MethodGet, "/projects/:id/invite-links", "/projects/1/invite-links", "", managers},
+		{http.MethodDelete, "/projects/:id/invite-links/:link_id", "/projects/1/invite-links/1", "", managers},
+		// asking to join is open to anyone, members are only told they're in already
+		{http.MethodPost, "/projects/:id/join-requests", "/projects/1/join-requests", `{}`, anyone},
+		{http.MethodGet, "/projects/:id/join-requests", "/projects/1/join-requests", "", managers},
+		{http.MethodPost, "/projects/:id/join-requests/:request_id/approve", "/projects/1/join-requests/1/approve", `{}`, managers},
+		{http.MethodPost, "/projects/:id/join-requests/:request_id/reject", "/projects/1/join-requests/1/reject", "", managers},
+		{http.MethodPost, "/projects/:id/teams", "/projects/1/teams", `{"team_id":1}`, managers},
+		{http.MethodGet, "/projects/:id/teams", "/projects/1/teams", "", everyone},
+		{http.MethodDelete, "/projects/:id/teams/:team_id", "/projects/1/teams/1", "", managers},
+		{http.MethodGet, "/projects/:id/tasks", "/projects/1/tasks", "", everyone},
+		{http.MethodGet, "/projects/:id/messages", "/projects/1/messages", "", everyone},
+		{http.MethodPost, "/tasks", "/tasks", `{"project_id":1,"title":"New"}`, []string{"owner", "maintainer", "member"}},
+		// task 1 is assigned to the member, task 2 to nobody
+		{http.MethodPut, "/tasks/:id", "/tasks/1", `{"title":"Edited","status":"DONE"}`, []string{"owner", "maintainer", "member"}},
+		{http.MethodPut, "/tasks/:id", "/tasks/2", `{"title":"Edited","status":"DONE"}`, managers},
+		{http.MethodDelete, "/tasks/:id", "/tasks/1", "", managers},
+		{http.MethodGet, "/tasks/:id/history", "/tasks/1/history", "", everyone},
+		// the socket takes its token from the query, a project room is joined like reading its chat
+		{http.MethodGet, "/ws", "/ws?projectId=1&token=", "", everyone},
+		{http.MethodGet, "/ws", "/ws?token=", "", anyone},
+		// direct messages are between two users, no project involved
+		{http.MethodGet, "/messages/direct/:other_id", "/messages/direct/2", "", anyone},
+		// an invitation only lets in the address it was sent to, a link anyone holding it
+		{http.MethodPost, "/invitations/accept", "/invitations/accept", `{"token":"` + invitation + `"}`, []string{"outsider"}},
+		{http.MethodPost, "/invites/:code/accept", "/invites/" + policyLinkCode + "/accept", "", anyone},
+		// everyone but the outsider is in the organization, the owner is its admin
+		{http.MethodPost, "/organizations", "/organizations", `{"name":"Other"}`, anyone},
+		{http.MethodGet, "/organizations", "/organizations", "", anyone},
+		{http.MethodGet, "/organizations/:id/members", "/organizations/1/members", "", everyone},
+		{http.MethodPost, "/organizations/:id/invitations", "/organizations/1/invitations", `{"email":"new@example.com"}`, []string{"owner"}},
+		{http.MethodPost, "/organizations/invitations/accept", "/organizations/invitations/accept", `{"token":"` + orgInvitation + `"}`, []string{"outsider"}},
+		{http.MethodPut, "/organizations/:id/members/:user_id/role", "/organizations/1/members/4/role", `{"role":"admin"}`, []string{"owner"}},
+		// members leave on their own
+		{http.MethodDelete, "/organizations/:id/members/:user_id", "/organizations/1/members/4", "", []string{"owner", "viewer"}},
+		// team 1 was created by the member and has the viewer in it
+		{http.MethodPost, "/teams", "/teams", `{"organization_id":1,"name":"Ops"}`, everyone},
+		{http.MethodGet, "/teams", "/teams?organization_id=1", "", everyone},
+		{http.MethodGet, "/teams/:id", "/teams/1", "", everyone},
+		{http.MethodDelete, "/teams/:id", "/teams/1", "", []string{"owner", "member"}},
+		{http.MethodPost, "/teams/:id/members", "/teams/1/members", `{"user_id":2}`, []string{"owner", "member"}},
+		{http.MethodDelete, "/teams/:id/members/:user_id", "/teams/1/members/4", "", []string{"owner", "member", "viewer"}},
+	}
+
+	// routes under these prefixes reach project or organization data and need a policy case
+	scoped := []string{"/projects", "/tasks", "/messages", "/ws", "/invitations", "/invites", "/organizations", "/teams"}
+
+	doOn := func(e *echo.Echo, method, path, role, body string) int {
+		token, _ := jwtManager.GenerateToken(policyUsers[role], role+"@example.com", "user")
+		if strings.HasSuffix(path, "token=") {
+			path += token
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	do := func(method, path, role, body string) int {
+		return doOn(newPolicyServer(jwtManager), method, path, role, body)
+	}
+
+	for _, route := range routes {
+		for role := range policyUsers {
+			allowed := false
+			for _, r := range route.allowed {
+				allowed = allowed || r == role
+			}
+
+			code := do(route.method, route.path, role, route.body)
+			if allowed {
+				assert.NotEqual(t, http.StatusForbidden, code, "%s %s as %s", route.method, route.path, role)
+				assert.Less(t, code, http.StatusInternalServerError, "%s %s as %s", route.method, route.path, role)
+			} else {
+				assert.Equal(t, http.StatusForbidden, code, "%s %s as %s", route.method, route.path, role)
+			}
+		}
+	}
+
+	t.Run("Every scoped route is in the table", func(t *testing.T) {
+		covered := map[string]bool{}
+		for _, route := range routes {
+			covered[route.method+" "+route.route] = true
+		}
+		for _, r := range newPolicyServer(jwtManager).Routes() {
+			if r.Method == echo.RouteNotFound {
+				continue // the groups' catch-alls
+			}
+			inScope := false
+			for _, prefix := range scoped {
+				inScope = inScope || strings.HasPrefix(r.Path, prefix)
+			}
+			if !inScope {
+				continue
+			}
+			assert.True(t, covered[r.Method+" "+r.Path], "no policy case for %s %s", r.Method, r.Path)
+		}
+	})
+
+	t.Run("System admins transfer projects they aren't in", func(t *testing.T) {
+		token, _ := jwtManager.GenerateToken(policyUsers["outsider"], "admin@example.com", user.RoleAdmin)
+		req := httptest.NewRequest(http.MethodPost, "/projects/1/transfer", strings.NewReader(`{"new_owner_id":3}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		newPolicyServer(jwtManager).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Archived projects are read-only", func(t *testing.T) {
+		e := newPolicyServer(jwtManager)
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodPost, "/projects/1/archive", "maintainer", ""))
+
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodGet, "/projects/1/tasks", "viewer", ""))
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodGet, "/projects/1/messages", "member", ""))
+		assert.Equal(t, http.StatusForbidden, doOn(e, http.MethodPost, "/tasks", "owner", `{"project_id":1,"title":"New"}`))
+		assert.Equal(t, http.StatusForbidden, doOn(e, http.MethodPut, "/projects/1", "owner", `{"name":"Renamed"}`))
+
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodPost, "/projects/1/unarchive", "owner", ""))
+		assert.Equal(t, http.StatusCreated, doOn(e, http.MethodPost, "/tasks", "member", `{"project_id":1,"title":"New"}`))
+	})
+
+	t.Run("Only the owner restores a deleted project", func(t *testing.T) {
+		e := newPolicyServer(jwtManager)
+		assert.Equal(t, http.StatusNoContent, doOn(e, http.MethodDelete, "/projects/1", "owner", ""))
+		assert.Equal(t, http.StatusNotFound, doOn(e, http.MethodGet, "/projects/1", "owner", ""))
+		assert.Equal(t, http.StatusNotFound, doOn(e, http.MethodGet, "/projects/1/tasks", "member", ""))
+
+		assert.Equal(t, http.StatusNotFound, doOn(e, http.MethodPost, "/projects/1/restore", "maintainer", ""))
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodPost, "/projects/1/restore", "owner", ""))
+		assert.Equal(t, http.StatusOK, doOn(e, http.MethodGet, "/projects/1", "viewer", ""))
+	})
+
+	t.Run("A missing project is a 404 for everyone", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/projects/99", "outsider", ""))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/projects/99/messages", "owner", ""))
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/messages"
+	"github.com/nelfander/Playingfield/internal/domain/privacy"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivacyEndpoints(t *testing.T) {
+	users := user.NewFakeRepository()
+	hashed, _ := auth.HashPassword("supersecret")
+	users.Users = append(users.Users, user.User{
+		ID:           1,
+		Email:        "alice@example.com",
+		PasswordHash: hashed,
+		Role:         "user",
+		Status:       "active",
+	})
+
+	profileRepo := profiles.NewFakeRepository()
+	profileRepo.Users[1] = "alice@example.com"
+
+	repo := privacy.NewFakeRepository(users)
+	repo.Data[1] = &privacy.Export{Messages: []messages.Message{{ID: 1, SenderID: 1, Content: "hello team"}}}
+	repo.Owned[1] = []privacy.OwnedProject{{ID: 5, SuccessorID: 2}}
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	// the live status check is what shuts the deleted account out
+	jwtManager.UseAccountCache(auth.NewAccountCache(func(ctx context.Context, userID int64) (string, string, error) {
+		u, err := users.GetByID(ctx, userID)
+		if err != nil {
+			return "", "", err
+		}
+		return u.Role, u.Status, nil
+	}, time.Minute))
+	token, _ := jwtManager.GenerateToken(1, "alice@example.com", "user")
+
+	service := privacy.NewService(repo, users, profiles.NewService(profileRepo), jwtManager, nil, nil)
+	handler := handlers.NewPrivacyHandler(service)
+
+	e := echo.New()
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.GET("/me/export", handler.Export)
+	authGroup.DELETE("/me", handler.DeleteAccount)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(method, path, nil)
+		} else {
+			req = httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Export downloads everything as JSON", func(t *testing.T) {
+		rec := do(http.MethodGet, "/me/export", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), `filename="playingfield-export-1.json"`)
+
+		var export privacy.Export
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+		assert.Equal(t, "alice@example.com", export.Account.Email)
+		assert.Equal(t, "hello team", export.Messages[0].Content)
+		assert.NotContains(t, rec.Body.String(), hashed)
+	})
+
+	t.Run("Deleting with the wrong password is refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/me", `{"password":"nope"}`).Code)
+		assert.Empty(t, repo.Anonymized)
+	})
+
+	t.Run("Deleting the account", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/me", `{"password":"supersecret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp dto.DeleteAccountResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, map[int64]int64{5: 2}, resp.TransferredProjects)
+
+		// the token that deleted the account is no good anymore
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/me/export", "").Code)
+	})
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/profiles"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileEndpoints(t *testing.T) {
+	repo := profiles.NewFakeRepository()
+	repo.Users[1] = "alice@example.com"
+	repo.Users[2] = "bob@example.com"
+	handler := handlers.NewProfileHandler(profiles.NewService(repo))
+
+	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
+	token, _ := jwtManager.GenerateToken(1, "alice@example.com", "user")
+
+	e := echo.New()
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.GET("/me/profile", handler.GetMine)
+	authGroup.PUT("/me/profile", handler.UpdateMine)
+	authGroup.PUT("/me/profile/avatar", handler.UploadAvatar)
+	authGroup.GET("/users/:id/profile", handler.Get)
+	e.GET("/users/:id/avatar", handler.Avatar)
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Update my profile", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/me/profile",
+			strings.NewReader(`{"display_name":"Alice","timezone":"Europe/Tallinn"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := do(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var p profiles.Profile
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		assert.Equal(t, "Alice", p.DisplayName)
+		assert.Equal(t, "Europe/Tallinn", p.Timezone)
+		assert.NotContains(t, rec.Body.String(), "alice@example.com")
+	})
+
+	t.Run("Invalid values are a bad request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/me/profile", strings.NewReader(`{"timezone":"Nowhere"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		assert.Equal(t, http.StatusBadRequest, do(req).Code)
+	})
+
+	t.Run("Look up someone else's profile", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(httptest.NewRequest(http.MethodGet, "/users/2/profile", nil)).Code)
+		assert.Equal(t, http.StatusNotFound, do(httptest.NewRequest(http.MethodGet, "/users/42/profile", nil)).Code)
+	})
+
+	t.Run("Upload an avatar and fetch it without a token", func(t *testing.T) {
+		image := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		part, _ := form.CreateFormFile("avatar", "me.png")
+		part.Write(image)
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPut, "/me/profile/avatar", body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := do(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var p profiles.Profile
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p.AvatarURL, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, image, rec.Body.Bytes())
+	})
+
+	t.Run("Non-image uploads are rejected", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		part, _ := form.CreateFormFile("avatar", "me.png")
+		part.Write([]byte("<html><script>alert(1)</script></html>"))
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPut, "/me/profile/avatar", body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		assert.Equal(t, http.StatusBadRequest, do(req).Code)
+	})
+}
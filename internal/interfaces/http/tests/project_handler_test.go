@@ -411,3 +411,50 @@ func TestAddUserToProject_Duplicate(t *testing.T) {
 		assert.Contains(t, rec.Body.String(), "already a member")
 	}
 }
+
+func TestChangeMemberRole(t *testing.T) {
+	handler, fakeRepo := setupProjectHandler()
+	e := echo.New()
+
+	ownerID := int64(100)
+	p, _ := fakeRepo.CreateProject(context.Background(), projects.Project{Name: "Roles", OwnerID: ownerID})
+	_ = fakeRepo.AddUserToProject(context.Background(), p.ID, ownerID, "owner")
+	_ = fakeRepo.AddUserToProject(context.Background(), p.ID, 200, "member")
+	_ = fakeRepo.AddUserToProject(context.Background(), p.ID, 300, "viewer")
+
+	changeRole := func(requesterID, userID int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/projects/:id/members/:user_id/role")
+		c.SetParamNames("id", "user_id")
+		c.SetParamValues(fmt.Sprintf("%d", p.ID), fmt.Sprintf("%d", userID))
+		c.Set("user", &auth.Claims{UserID: requesterID})
+		assert.NoError(t, handler.ChangeMemberRole(c))
+		return rec
+	}
+
+	// the owner promotes a member
+	rec := changeRole(ownerID, 200, `{"role":"maintainer"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	members, _ := fakeRepo.ListUsersInProject(context.Background(), p.ID)
+	for _, m := range members {
+		if m.ID == 200 {
+			assert.Equal(t, "maintainer", m.Role)
+		}
+	}
+
+	// a viewer can't change anyone's role
+	rec = changeRole(300, 200, `{"role":"viewer"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// nobody becomes owner this way, and the owner keeps their role
+	rec = changeRole(ownerID, 300, `{"role":"owner"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = changeRole(200, ownerID, `{"role":"member"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = changeRole(ownerID, 999, `{"role":"member"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
+	"github.com/nelfander/Playingfield/internal/infrastructure/ws"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionManagement(t *testing.T) {
+	fakeRepo := user.NewFakeRepository()
+	hashed, _ := auth.HashPassword("supersecret")
+	fakeRepo.Users = append(fakeRepo.Users, user.User{
+		ID:           1,
+		Email:        "owner@example.com",
+		PasswordHash: hashed,
+		Role:         "user",
+		Status:       "active",
+	})
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	jwtManager.UseDenylist(auth.NewDenylist(&memoryDenylistStore{entries: map[string]time.Time{}}))
+	tokenService := tokens.NewService(tokens.NewFakeRepository(), time.Hour)
+	verification := user.NewVerificationService(fakeRepo, mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+	twoFactor := user.NewTwoFactorService(fakeRepo, user.NewFakeTwoFactorRepository(), jwtManager, "Playingfield", time.Minute)
+	userHandler := handlers.NewUserHandler(user.NewService(fakeRepo), jwtManager, tokenService, verification, twoFactor)
+
+	hub := ws.NewHub()
+	go hub.Run()
+	defer hub.Stop()
+	sessionHandler := handlers.NewSessionHandler(tokenService, jwtManager, hub)
+
+	e := echo.New()
+	e.POST("/login", userHandler.Login)
+	e.POST("/auth/refresh", userHandler.Refresh)
+	e.GET("/ws", handlers.NewWSHandler(jwtManager, hub, nil).HandleConnection)
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.GET("/me/sessions", sessionHandler.List)
+	authGroup.DELETE("/me/sessions/:id", sessionHandler.Revoke)
+
+	login := func(userAgent string) dto.LoginResponse {
+		req := httptest.NewRequest(http.MethodPost, "/login",
+			strings.NewReader(`{"email":"owner@example.com","password":"supersecret"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp dto.LoginResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	laptop := login("Laptop Browser")
+	phone := login("Phone App")
+
+	laptopClaims, err := jwtManager.VerifyToken(laptop.Token)
+	assert.NoError(t, err)
+	assert.NotZero(t, laptopClaims.SessionID)
+
+	t.Run("List shows every device and marks the current one", func(t *testing.T) {
+		rec := do(http.MethodGet, "/me/sessions", phone.Token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var sessions []dto.SessionResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sessions))
+		assert.Len(t, sessions, 2)
+		for _, s := range sessions {
+			assert.Equal(t, s.UserAgent == "Phone App", s.Current)
+			assert.NotEmpty(t, s.IPAddress)
+		}
+	})
+
+	t.Run("Revoking the lost laptop logs it out everywhere", func(t *testing.T) {
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + laptop.Token
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		assert.NoError(t, err)
+		defer conn.Close()
+		time.Sleep(50 * time.Millisecond) // let the hub register the client
+
+		rec := do(http.MethodDelete, "/me/sessions/"+strconv.FormatInt(laptopClaims.SessionID, 10), phone.Token)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		// the socket gets a close frame
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "unexpected error: %v", err)
+
+		// the access token is dead
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me/sessions", laptop.Token).Code)
+
+		// and so is the refresh token
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh",
+			strings.NewReader(`{"refresh_token":"`+laptop.RefreshToken+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		refreshRec := httptest.NewRecorder()
+		e.ServeHTTP(refreshRec, req)
+		assert.Equal(t, http.StatusUnauthorized, refreshRec.Code)
+
+		// the phone carries on
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me/sessions", phone.Token).Code)
+	})
+
+	t.Run("Sessions of other users can't be revoked", func(t *testing.T) {
+		other, _ := jwtManager.GenerateToken(2, "other@example.com", "user")
+		phoneClaims, _ := jwtManager.VerifyToken(phone.Token)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/me/sessions/"+strconv.FormatInt(phoneClaims.SessionID, 10), other).Code)
+	})
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/organizations"
+	"github.com/nelfander/Playingfield/internal/domain/projects"
+	"github.com/nelfander/Playingfield/internal/domain/teams"
+	"github.com/nelfander/Playingfield/internal/domain/user"
+	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
+	"github.com/nelfander/Playingfield/internal/interfaces/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamEndpoints(t *testing.T) {
+	ctx := context.Background()
+
+	// 1 admins the organization and owns project 1, 2 and 3 are members
+	orgRepo := organizations.NewFakeRepository()
+	orgRepo.Create(ctx, "Acme", 1)
+	orgRepo.AddMember(ctx, 1, 2, organizations.RoleMember)
+	orgRepo.AddMember(ctx, 1, 3, organizations.RoleMember)
+	orgService := organizations.NewService(orgRepo, user.NewFakeRepository())
+
+	teamRepo := teams.NewFakeRepository()
+	projectRepo := projects.NewFakeRepository()
+	projectRepo.UseTeams(teamRepo)
+	projectRepo.CreateProject(ctx, projects.Project{Name: "Apollo", OwnerID: 1, OrganizationID: 1})
+	projectRepo.AddUserToProject(ctx, 1, 1, projects.RoleOwner)
+
+	policy := projects.NewCachedAuthorizer(projectRepo, time.Minute)
+	projectService := projects.NewService(projectRepo, nil)
+	projectService.UseAuthorizer(policy)
+	teamService := teams.NewService(teamRepo, projectRepo, orgService, nil)
+	teamService.UseAuthorizer(policy)
+
+	projectHandler := handlers.NewProjectHandler(projectService)
+	teamHandler := handlers.NewTeamHandler(teamService)
+
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	e := echo.New()
+	authGroup := e.Group("")
+	authGroup.Use(middleware.JWTMiddleware(jwtManager))
+	authGroup.POST("/teams", teamHandler.Create)
+	authGroup.GET("/teams", teamHandler.List)
+	authGroup.GET("/teams/:id", teamHandler.Get)
+	authGroup.DELETE("/teams/:id", teamHandler.Delete)
+	authGroup.POST("/teams/:id/members", teamHandler.AddMember)
+	authGroup.DELETE("/teams/:id/members/:user_id", teamHandler.RemoveMember)
+	authGroup.POST("/projects/:id/teams", teamHandler.AddToProject)
+	authGroup.GET("/projects/:id/teams", teamHandler.ListForProject)
+	authGroup.DELETE("/projects/:id/teams/:team_id", teamHandler.RemoveFromProject)
+	authGroup.GET("/projects/users", projectHandler.ListUsersInProject)
+	authGroup.PUT("/projects/:id/members/:user_id/role", projectHandler.ChangeMemberRole)
+
+	do := func(method, path string, userID int64, body string) *httptest.ResponseRecorder {
+		token, _ := jwtManager.GenerateToken(userID, "", "user")
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/teams", 1, `{"organization_id":1,"name":"Design"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var team teams.Team
+	json.Unmarshal(rec.Body.Bytes(), &team)
+	teamPath := fmt.Sprintf("/teams/%d", team.ID)
+
+	t.Run("Teams are managed inside the organization", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/teams", 2, `{"organization_id":1,"name":"Design"}`).Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/teams", 9, `{"organization_id":1,"name":"Ops"}`).Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, teamPath+"/members", 2, `{"user_id":3}`).Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, teamPath+"/members", 1, `{"user_id":9}`).Code)
+
+		rec := do(http.MethodGet, "/teams?organization_id=1", 2, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"Design"`)
+	})
+
+	t.Run("Members of a team on a project are listed through it", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, do(http.MethodPost, teamPath+"/members", 1, `{"user_id":2}`).Code)
+		body := fmt.Sprintf(`{"team_id":%d,"role":"viewer"}`, team.ID)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/projects/1/teams", 2, body).Code)
+		assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/projects/1/teams", 1, body).Code)
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/projects/1/teams", 1, body).Code)
+
+		rec := do(http.MethodGet, "/projects/users?project_id=1", 2, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var members []struct {
+			ID     int64              `json:"id"`
+			Role   string             `json:"role"`
+			Direct bool               `json:"direct"`
+			Teams  []projects.TeamRef `json:"teams"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &members)
+		assert.Len(t, members, 2)
+		for _, m := range members {
+			if m.ID == 2 {
+				assert.Equal(t, projects.RoleViewer, m.Role)
+				assert.False(t, m.Direct)
+				assert.Equal(t, []projects.TeamRef{{ID: team.ID, Name: "Design"}}, m.Teams)
+			} else {
+				assert.True(t, m.Direct)
+			}
+		}
+
+		// the role comes from the team, so it is changed there
+		assert.Equal(t, http.StatusConflict, do(http.MethodPut, "/projects/1/members/2/role", 1, `{"role":"member"}`).Code)
+	})
+
+	t.Run("Leaving the team or removing it from the project takes access away", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/projects/1/teams", 2, "").Code)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, teamPath+"/members/2", 2, "").Code)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/projects/1/teams", 2, "").Code)
+
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, fmt.Sprintf("/projects/1/teams/%d", team.ID), 1, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, fmt.Sprintf("/projects/1/teams/%d", team.ID), 1, "").Code)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, teamPath, 1, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, teamPath, 1, "").Code)
+	})
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -10,8 +11,10 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/nelfander/Playingfield/internal/domain/tokens"
 	"github.com/nelfander/Playingfield/internal/domain/user"
 	"github.com/nelfander/Playingfield/internal/infrastructure/auth"
+	"github.com/nelfander/Playingfield/internal/infrastructure/mail"
 	"github.com/nelfander/Playingfield/internal/interfaces/http/dto"
 	"github.com/nelfander/Playingfield/internal/interfaces/http/handlers"
 	"github.com/stretchr/testify/assert"
@@ -23,7 +26,10 @@ func setupHandler() (*handlers.UserHandler, *user.FakeRepository) {
 	fakeRepo := user.NewFakeRepository()
 	service := user.NewService(fakeRepo)
 	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
-	handler := handlers.NewUserHandler(service, jwtManager)
+	tokenService := tokens.NewService(tokens.NewFakeRepository(), time.Hour)
+	verification := user.NewVerificationService(fakeRepo, mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+	twoFactor := user.NewTwoFactorService(fakeRepo, user.NewFakeTwoFactorRepository(), jwtManager, "Playingfield", time.Minute)
+	handler := handlers.NewUserHandler(service, jwtManager, tokenService, verification, twoFactor)
 	return handler, fakeRepo
 }
 
@@ -41,10 +47,33 @@ func TestUserRegistration(t *testing.T) {
 		var resp map[string]interface{}
 		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
 		assert.Equal(t, "user", resp["role"])
+		assert.Equal(t, "pending_verification", resp["status"])
 		assert.Equal(t, http.StatusCreated, rec.Code)
 	}
 }
 
+func TestUserRegistration_PasswordPolicy(t *testing.T) {
+	handler, fakeRepo := setupHandler()
+	e := echo.New()
+
+	for _, password := range []string{"", "short", "password123"} {
+		reqBody := `{"email":"weak@example.com","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, handler.Register(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, password)
+
+		var resp struct {
+			Violations []user.PasswordViolation `json:"violations"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Violations, password)
+	}
+	assert.Empty(t, fakeRepo.Users)
+}
+
 func TestUserLogin(t *testing.T) {
 	handler, fakeRepo := setupHandler()
 	e := echo.New()
@@ -76,6 +105,7 @@ func TestUserLogin(t *testing.T) {
 		var resp map[string]interface{}
 		assert.NoError(t, json.Unmarshal(recLogin.Body.Bytes(), &resp))
 		assert.NotEmpty(t, resp["token"])
+		assert.NotEmpty(t, resp["refresh_token"])
 	}
 }
 
@@ -128,6 +158,36 @@ func TestUserLogin_InactiveAccount(t *testing.T) {
 	assert.Equal(t, "account is inactive or banned", resp["error"])
 }
 
+func TestUserLogin_UnverifiedAccount(t *testing.T) {
+	handler, fakeRepo := setupHandler()
+	e := echo.New()
+
+	hashed, err := auth.HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeRepo.Users = append(fakeRepo.Users, user.User{
+		Email:        "pending@example.com",
+		PasswordHash: hashed,
+		Role:         "user",
+		Status:       user.StatusPendingVerification,
+	})
+
+	loginBody := `{"email":"pending@example.com","password":"secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(loginBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler.Login(c)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "email address is not verified", resp["error"])
+}
+
 func TestMeEndpoint(t *testing.T) {
 	//  Create fake repo and service locally
 	fakeRepo := user.NewFakeRepository()
@@ -139,7 +199,8 @@ func TestMeEndpoint(t *testing.T) {
 
 	//  Create JWT manager and handler
 	jwtManager := auth.NewJWTManager("test-secret", 24*time.Hour)
-	handler := handlers.NewUserHandler(service, jwtManager)
+	verification := user.NewVerificationService(fakeRepo, mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+	handler := handlers.NewUserHandler(service, jwtManager, tokens.NewService(tokens.NewFakeRepository(), time.Hour), verification, nil)
 
 	//  Prepare echo request/recorder
 	e := echo.New()
@@ -165,5 +226,162 @@ func TestMeEndpoint(t *testing.T) {
 	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
 	assert.Equal(t, "me@example.com", resp.Email)
 	assert.Equal(t, "user", resp.Role)
-	assert.Equal(t, "active", resp.Status)
+	// fresh registrations wait for the verification link
+	assert.Equal(t, "pending_verification", resp.Status)
+}
+
+func TestUserLogin_TwoFactor(t *testing.T) {
+	fakeRepo := user.NewFakeRepository()
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	verification := user.NewVerificationService(fakeRepo, mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+	twoFactorRepo := user.NewFakeTwoFactorRepository()
+	twoFactor := user.NewTwoFactorService(fakeRepo, twoFactorRepo, jwtManager, "Playingfield", time.Minute)
+	handler := handlers.NewUserHandler(user.NewService(fakeRepo), jwtManager,
+		tokens.NewService(tokens.NewFakeRepository(), time.Hour), verification, twoFactor)
+	e := echo.New()
+
+	hashed, _ := auth.HashPassword("supersecret")
+	fakeRepo.Create(context.Background(), user.User{
+		Email:        "admin2fa@example.com",
+		PasswordHash: hashed,
+		Role:         "admin",
+		Status:       "active",
+	})
+	twoFactorRepo.SetRequired(context.Background(), "admin", true)
+
+	post := func(h echo.HandlerFunc, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, h(e.NewContext(req, rec)))
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	// step 1: the password alone only buys a challenge, no tokens
+	rec, resp := post(handler.Login, `{"email":"admin2fa@example.com","password":"supersecret"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, true, resp["two_factor_required"])
+	assert.Equal(t, true, resp["enrollment_required"])
+	assert.Nil(t, resp["token"])
+	challenge, _ := resp["challenge_token"].(string)
+	assert.NotEmpty(t, challenge)
+
+	// the role requires 2fa, so enrollment happens with the challenge
+	rec, resp = post(handler.LoginTwoFactorEnroll, `{"challenge_token":"`+challenge+`"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, resp["otpauth_uri"], "otpauth://totp/")
+	assert.Len(t, resp["recovery_codes"], 10)
+	secret, _ := resp["secret"].(string)
+
+	// a wrong code is refused
+	rec, _ = post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"000000x"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// step 2: the authenticator code finishes the login
+	code, err := auth.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+	rec, resp = post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"`+code+`"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, resp["token"])
+	assert.NotEmpty(t, resp["refresh_token"])
+
+	// the same code can't be replayed
+	rec, _ = post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"`+code+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestUserLogin_Throttled(t *testing.T) {
+	handler, fakeRepo := setupHandler()
+	e := echo.New()
+
+	hashed, _ := auth.HashPassword("supersecret")
+	fakeRepo.Create(context.Background(), user.User{Email: "victim@example.com", PasswordHash: hashed, Status: "active"})
+
+	var events []auth.ThrottleEvent
+	handler.UseLoginThrottle(auth.NewLoginThrottle(auth.ThrottleConfig{
+		PerEmail:    2,
+		PerIP:       100,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		Window:      time.Hour,
+	}, func(ev auth.ThrottleEvent) { events = append(events, ev) }))
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body := `{"email":"victim@example.com","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler.Login(e.NewContext(req, rec)))
+		return rec
+	}
+
+	// two misses are allowed, the third one locks the account
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	}
+	if assert.Len(t, events, 1) {
+		assert.True(t, events[0].Locked)
+		assert.Equal(t, "email:victim@example.com", events[0].Key)
+	}
+
+	// even the right password is refused while locked
+	rec := login("supersecret")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestUserLogin_TwoFactorThrottledPerAccount(t *testing.T) {
+	fakeRepo := user.NewFakeRepository()
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	verification := user.NewVerificationService(fakeRepo, mail.NewLogMailer(io.Discard), jwtManager, time.Hour, "")
+	twoFactorRepo := user.NewFakeTwoFactorRepository()
+	twoFactor := user.NewTwoFactorService(fakeRepo, twoFactorRepo, jwtManager, "Playingfield", time.Minute)
+	handler := handlers.NewUserHandler(user.NewService(fakeRepo), jwtManager,
+		tokens.NewService(tokens.NewFakeRepository(), time.Hour), verification, twoFactor)
+	e := echo.New()
+
+	hashed, _ := auth.HashPassword("supersecret")
+	fakeRepo.Create(context.Background(), user.User{Email: "admin2fa@example.com", PasswordHash: hashed, Role: "admin", Status: "active"})
+	twoFactorRepo.SetRequired(context.Background(), "admin", true)
+	handler.UseLoginThrottle(auth.NewLoginThrottle(auth.ThrottleConfig{
+		PerEmail:    2,
+		PerIP:       100,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		Window:      time.Hour,
+	}, nil))
+
+	post := func(h echo.HandlerFunc, body, ip string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, h(e.NewContext(req, rec)))
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	_, resp := post(handler.Login, `{"email":"admin2fa@example.com","password":"supersecret"}`, "10.0.0.1")
+	challenge, _ := resp["challenge_token"].(string)
+	_, resp = post(handler.LoginTwoFactorEnroll, `{"challenge_token":"`+challenge+`"}`, "10.0.0.1")
+	secret, _ := resp["secret"].(string)
+
+	// every guess comes from a new ip, the account still locks
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		rec, _ := post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"000000x"}`, ip)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	rec, _ := post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"`+code+`"}`, "10.0.0.5")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// a fresh password login doesn't reset the count
+	_, resp = post(handler.Login, `{"email":"admin2fa@example.com","password":"supersecret"}`, "10.0.0.6")
+	challenge, _ = resp["challenge_token"].(string)
+	rec, _ = post(handler.LoginTwoFactor, `{"challenge_token":"`+challenge+`","code":"`+code+`"}`, "10.0.0.6")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
 }
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -20,10 +21,14 @@ const (
 )
 
 func main() {
-	// 1. Login to get a fresh token
-	token, err := getAuthToken(testEmail, testPass)
-	if err != nil {
-		log.Fatalf("Login failed: %v", err)
+	// 1. Use a personal access token (needs chat:write) or login to get a fresh one
+	token := os.Getenv("PLAYINGFIELD_TOKEN")
+	if token == "" {
+		var err error
+		token, err = getAuthToken(testEmail, testPass)
+		if err != nil {
+			log.Fatalf("Login failed: %v", err)
+		}
 	}
 	fmt.Println("✅ Authenticated")
 